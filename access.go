@@ -0,0 +1,115 @@
+package pass
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+)
+
+// AccessReport is the result of VerifyAccess.
+type AccessReport struct {
+	Checked []string `json:"checked"` // The entries checked.
+
+	// The entries that none of the local secret keys can decrypt, mapped
+	// to the long key IDs of the keys that they are encrypted to. Either
+	// the secret key of one of them must be imported, or the entry must be
+	// re-encrypted to a local key by someone who can decrypt it.
+	MissingKeys map[string][]string `json:"missing_keys,omitempty"`
+
+	// The entries that failed to decrypt although a local secret key
+	// matches, for example because of a wrong passphrase.
+	Failed map[string]error `json:"failed,omitempty"`
+}
+
+// OK reports whether all the entries checked could be decrypted.
+func (r *AccessReport) OK() bool {
+	return len(r.MissingKeys) == 0 && len(r.Failed) == 0
+}
+
+// MarshalJSON implements json.Marshaler.
+func (r AccessReport) MarshalJSON() ([]byte, error) {
+	type report AccessReport
+	return json.Marshal(struct {
+		report
+		Failed map[string]string `json:"failed,omitempty"`
+	}{report(r), errorStrings(r.Failed)})
+}
+
+// VerifyAccess checks that the entries of the store can be decrypted with
+// the local keys, such as after Clone on a new machine. It checks a
+// sample of at most sample entries spread over the store, or all entries
+// if sample is not positive. Entries encrypted to a local secret key are
+// decrypted using gpgPassphrase, as in Show; for the others, the report
+// lists the keys that they are encrypted to, rather than failing with
+// "decryption failed: No secret key".
+func VerifyAccess(ctx context.Context, sample int, gpgPassphrase string, options ...Option) (*AccessReport, error) {
+	opts := resolveOptions(options)
+	names, err := List(ctx, "", opts)
+	if err != nil {
+		return nil, err
+	}
+	if sample > 0 && sample < len(names) {
+		picked := make([]string, sample)
+		for i := range picked {
+			picked[i] = names[i*len(names)/sample]
+		}
+		names = picked
+	}
+	gpgPassphrase, err = passphrase(ctx, gpgPassphrase, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var local map[string]bool
+	report := &AccessReport{
+		MissingKeys: make(map[string][]string),
+		Failed:      make(map[string]error),
+	}
+	storeDir := resolveStoreDir(opts)
+	for _, name := range names {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		report.Checked = append(report.Checked, name)
+		p := filepath.Join(storeDir, name+".gpg")
+		eopts := entryOptions(name, opts)
+		if eopts.Crypto == nil {
+			if local == nil {
+				card, disk, err := secretKeyLocations(ctx, opts)
+				if err != nil {
+					return nil, fmt.Errorf("list secret keys: %w", err)
+				}
+				local = disk
+				for k := range card {
+					local[k] = true
+				}
+			}
+			keys, err := fileRecipients(ctx, p, eopts)
+			if err != nil {
+				report.Failed[name] = err
+				continue
+			}
+			if !anyKey(keys, local) {
+				report.MissingKeys[name] = keys
+				continue
+			}
+		}
+		content, err := decryptFile(ctx, p, gpgPassphrase, eopts)
+		if err != nil {
+			report.Failed[name] = err
+			continue
+		}
+		wipe(content)
+	}
+	return report, nil
+}
+
+func anyKey(keys []string, set map[string]bool) bool {
+	for _, k := range keys {
+		if set[k] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package pass
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVerifyAccess(t *testing.T) {
+	dir := makeTestTree(nil)
+	defer os.RemoveAll(dir)
+	env, fprs := testKeyring(t, filepath.Join(dir, "gnupg"), "A <a@example.com>")
+	otherEnv, otherFprs := testKeyring(t, filepath.Join(dir, "other"), "B <b@example.com>")
+	storeDir := filepath.Join(dir, "store")
+	writeTestFile(t, storeDir, ".gpg-id", fprs[0]+"\n")
+	ctx := context.Background()
+
+	encrypt := func(name string, env []string, fpr string) {
+		t.Helper()
+		tmp, err := encryptTemp(ctx, storeDir, []byte(name), []string{fpr}, &Options{Env: env})
+		Ok(t, err)
+		Ok(t, os.Rename(tmp, filepath.Join(storeDir, name+".gpg")))
+	}
+	encrypt("a", env, fprs[0])
+	encrypt("b", otherEnv, otherFprs[0])
+	encrypt("c", env, fprs[0])
+
+	opts := &Options{StoreDir: storeDir, Env: env}
+	report, err := VerifyAccess(ctx, 0, "", opts)
+	Ok(t, err)
+	Equal(t, "[a b c]", fmt.Sprint(report.Checked))
+	Equal(t, "false", fmt.Sprint(report.OK()))
+	Equal(t, "0", fmt.Sprint(len(report.Failed)))
+	keys, err := fileRecipients(ctx, filepath.Join(storeDir, "b.gpg"), opts)
+	Ok(t, err)
+	Equal(t, fmt.Sprint(map[string][]string{"b": keys}), fmt.Sprint(report.MissingKeys))
+
+	b, err := json.Marshal(report)
+	Ok(t, err)
+	Equal(t, fmt.Sprintf(`{"checked":["a","b","c"],"missing_keys":{"b":["%s"]}}`, keys[0]), string(b))
+
+	report, err = VerifyAccess(ctx, 2, "", opts)
+	Ok(t, err)
+	Equal(t, "[a b]", fmt.Sprint(report.Checked))
+
+	Ok(t, os.Remove(filepath.Join(storeDir, "b.gpg")))
+	report, err = VerifyAccess(ctx, 0, "", opts)
+	Ok(t, err)
+	Equal(t, "true", fmt.Sprint(report.OK()))
+}
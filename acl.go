@@ -0,0 +1,106 @@
+package pass
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ErrPermissionDenied is returned when Options.ACL does not allow
+// Options.Principal to perform an operation.
+var ErrPermissionDenied = errors.New("permission denied")
+
+// ErrReadOnly is returned by the functions that change the store when
+// Options.ReadOnly is set.
+var ErrReadOnly = errors.New("store is read-only")
+
+// Permission is a set of operations on entries.
+type Permission int
+
+const (
+	Read  Permission = 1 << iota // Show the content of entries.
+	Write                        // Change entries.
+)
+
+// ACL is a list of rules granting principals permissions on entries. It
+// denies everything that is not explicitly allowed. Set it in Options.ACL,
+// along with Options.Principal, to have the package check it. An ACL is
+// safe for concurrent use.
+type ACL struct {
+	mu    sync.RWMutex
+	rules []aclRule
+}
+
+type aclRule struct {
+	principal string
+	re        *regexp.Regexp
+	perm      Permission
+}
+
+// NewACL returns an ACL that denies everything.
+func NewACL() *ACL {
+	return &ACL{}
+}
+
+// Allow grants the principal the permissions on the entries matching the
+// pattern. The principal "*" means any principal. In the pattern, "*"
+// matches any part of a single path element, "?" matches a single
+// character, and "**" matches across path elements, so "deploy/*" matches
+// "deploy/key" but not "deploy/prod/key", while "deploy/**" matches both.
+// It returns an error if the pattern is malformed, such as one with an
+// invalid character class.
+func (a *ACL) Allow(principal, pattern string, perm Permission) error {
+	re, err := regexp.Compile("^" + globToRegexp(pattern) + "$")
+	if err != nil {
+		return fmt.Errorf("bad pattern %q: %w", pattern, err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.rules = append(a.rules, aclRule{principal: principal, re: re, perm: perm})
+	return nil
+}
+
+// Check reports whether the principal has all of the permissions perm on
+// the named entry. Absolute names and names with a ".." element are always
+// denied, as "deploy/**" would otherwise match "deploy/../prod/key".
+func (a *ACL) Check(principal, name string, perm Permission) bool {
+	n := filepath.ToSlash(name)
+	if path.IsAbs(n) || filepath.VolumeName(name) != "" || n == ".." || strings.HasPrefix(n, "../") || strings.HasSuffix(n, "/..") || strings.Contains(n, "/../") {
+		return false
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	var granted Permission
+	for _, r := range a.rules {
+		if (r.principal == principal || r.principal == "*") && r.re.MatchString(name) {
+			granted |= r.perm
+		}
+	}
+	return granted&perm == perm
+}
+
+// checkACL returns ErrPermissionDenied if Options.ACL is set and does not
+// allow Options.Principal the permissions on all the named entries. It
+// returns ErrReadOnly for Write if Options.ReadOnly is set. All functions
+// that change the store call it before doing anything.
+func checkACL(names []string, perm Permission, opts *Options) error {
+	if opts != nil && opts.ReadOnly && perm&Write != 0 {
+		return ErrReadOnly
+	}
+	if opts == nil || opts.ACL == nil {
+		return nil
+	}
+	for _, name := range names {
+		if !opts.ACL.Check(opts.Principal, name, perm) {
+			return ErrPermissionDenied
+		}
+	}
+	return nil
+}
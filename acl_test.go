@@ -0,0 +1,170 @@
+package pass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestACL(t *testing.T) {
+	acl := NewACL()
+	Ok(t, acl.Allow("ci-bot", "deploy/*", Read))
+	Ok(t, acl.Allow("admin", "**", Read|Write))
+	Ok(t, acl.Allow("*", "public/**", Read))
+
+	testcases := []struct {
+		principal, name string
+		perm            Permission
+		expected        bool
+	}{
+		{"ci-bot", "deploy/key", Read, true},
+		{"ci-bot", "deploy/key", Write, false},
+		{"ci-bot", "deploy/prod/key", Read, false},
+		{"ci-bot", "other/key", Read, false},
+		{"admin", "deploy/prod/key", Read | Write, true},
+		{"someone", "public/a/b", Read, true},
+		{"someone", "deploy/key", Read, false},
+		{"", "deploy/key", Read, false},
+	}
+	for _, tc := range testcases {
+		if got := acl.Check(tc.principal, tc.name, tc.perm); got != tc.expected {
+			t.Errorf("Check(%q, %q, %d): expected: %t, got: %t", tc.principal, tc.name, tc.perm, tc.expected, got)
+		}
+	}
+}
+
+func TestACLBadPattern(t *testing.T) {
+	acl := NewACL()
+	if err := acl.Allow("ci-bot", "deploy/[z-a]", Read); err == nil {
+		t.Errorf("expected error for bad pattern")
+	}
+	if acl.Check("ci-bot", "deploy/b", Read) {
+		t.Errorf("expected bad pattern to grant nothing")
+	}
+}
+
+func TestACLDenies(t *testing.T) {
+	acl := NewACL()
+	Ok(t, acl.Allow("ci-bot", "deploy/*", Read))
+
+	opts := &Options{
+		StoreDir:  makeTestTree([]string{"deploy/key.gpg"}),
+		ACL:       acl,
+		Principal: "ci-bot",
+	}
+	ctx := context.Background()
+
+	_, err := Show(ctx, "other/key", testGpgPassphrase, opts)
+	if err != ErrPermissionDenied {
+		t.Errorf("expected: %s, got: %v", ErrPermissionDenied, err)
+	}
+	err = Remove(ctx, "deploy/key", false, true, opts)
+	if err != ErrPermissionDenied {
+		t.Errorf("expected: %s, got: %v", ErrPermissionDenied, err)
+	}
+	err = Git(ctx, []string{"log"}, opts)
+	if err != ErrPermissionDenied {
+		t.Errorf("expected: %s, got: %v", ErrPermissionDenied, err)
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	var ran []string
+	runner := RunnerFunc(func(cmd *exec.Cmd) error {
+		ran = append(ran, strings.Join(cmd.Args, " "))
+		return nil
+	})
+	opts := &Options{
+		StoreDir: makeTestTree([]string{".gpg-id", "a.gpg"}),
+		Runner:   runner,
+		ReadOnly: true,
+	}
+	defer os.RemoveAll(opts.StoreDir)
+	ctx := context.Background()
+
+	_, err := Show(ctx, "a", "", opts)
+	Ok(t, err)
+	Equal(t, "1", fmt.Sprint(len(ran)))
+
+	_, gcErr := GC(ctx, true, opts)
+	_, renameErr := RenameAll(ctx, func(old string) (string, bool) { return "b", false }, false, opts)
+	for _, err := range []error{
+		Insert(ctx, "b", []byte("x"), true, opts),
+		Remove(ctx, "a", false, true, opts),
+		Move(ctx, "a", "b", true, opts),
+		Copy(ctx, "a", "b", true, opts),
+		Init(ctx, "a@example.com", "", opts),
+		Git(ctx, []string{"log"}, opts),
+		Batch(ctx, "x", func(*Tx) error { return nil }, opts),
+		Destroy(ctx, func(string) bool { return true }, opts),
+		PurgeTrash(ctx, 0, opts),
+		gcErr,
+		renameErr,
+	} {
+		if err != ErrReadOnly {
+			t.Errorf("expected: %s, got: %v", ErrReadOnly, err)
+		}
+	}
+	Equal(t, "1", fmt.Sprint(len(ran)))
+}
+
+func TestACLAlias(t *testing.T) {
+	defer fakeCommand("pass", `exit 0`)()
+
+	acl := NewACL()
+	Ok(t, acl.Allow("ci-bot", "dev/*", Read))
+	opts := &Options{
+		StoreDir:  makeTestTree([]string{"prod/db.gpg", "dev/db.gpg"}),
+		ACL:       acl,
+		Principal: "ci-bot",
+	}
+	defer os.RemoveAll(opts.StoreDir)
+	Ok(t, os.Symlink(filepath.Join("..", "prod", "db.gpg"), filepath.Join(opts.StoreDir, "dev", "link.gpg")))
+	ctx := context.Background()
+
+	_, err := Show(ctx, "dev/db", "", opts)
+	Ok(t, err)
+	_, err = Show(ctx, "dev/link", "", opts)
+	if err != ErrPermissionDenied {
+		t.Errorf("expected: %s, got: %v", ErrPermissionDenied, err)
+	}
+}
+
+func TestACLTraversal(t *testing.T) {
+	var ran []string
+	runner := RunnerFunc(func(cmd *exec.Cmd) error {
+		ran = append(ran, strings.Join(cmd.Args, " "))
+		fmt.Fprint(cmd.Stdout, "secret")
+		return nil
+	})
+	acl := NewACL()
+	Ok(t, acl.Allow("ci-bot", "deploy/**", Read|Write))
+	opts := &Options{
+		StoreDir:  makeTestTree([]string{"prod/key.gpg", "deploy/a.gpg"}),
+		ACL:       acl,
+		Principal: "ci-bot",
+		Runner:    runner,
+	}
+	defer os.RemoveAll(opts.StoreDir)
+	ctx := context.Background()
+
+	const name = "deploy/../prod/key"
+	Equal(t, "false", fmt.Sprint(acl.Check("ci-bot", name, Read)))
+	_, err := Show(ctx, name, "", opts)
+	if !errors.Is(err, ErrInvalidName) {
+		t.Errorf("expected: %s, got: %v", ErrInvalidName, err)
+	}
+	_, err = ShowMany(ctx, []string{"deploy/a", name}, "", opts)
+	if !errors.Is(err, ErrInvalidName) {
+		t.Errorf("expected: %s, got: %v", ErrInvalidName, err)
+	}
+	if err := Insert(ctx, name, []byte("x"), true, opts); err != ErrPermissionDenied {
+		t.Errorf("expected: %s, got: %v", ErrPermissionDenied, err)
+	}
+	Equal(t, "0", fmt.Sprint(len(ran)))
+}
@@ -0,0 +1,142 @@
+package pass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AgentCachePolicy limits how long the passphrases used by the package
+// stay cached in gpg-agent, regardless of the configuration of the agent.
+// Set it in Options.AgentCache, and share it between calls, so that its
+// count and timer apply across them. An AgentCachePolicy is safe for
+// concurrent use.
+type AgentCachePolicy struct {
+	// Disables the caching of passphrases of symmetrically encrypted
+	// data, using the --no-symkey-cache option of gpg.
+	NoSymkeyCache bool
+
+	// If positive, the passphrases of the secret keys of the store are
+	// cleared from gpg-agent this long after the first decryption by the
+	// package that may have cached them, like the max-cache-ttl option of
+	// gpg-agent.
+	MaxTTL time.Duration
+
+	// If positive, the passphrases are cleared after this many
+	// decryptions by the package.
+	ClearAfter int
+
+	mu    sync.Mutex
+	count int
+	timer *time.Timer
+}
+
+// decrypted is called after each decryption using gpg. It clears the
+// passphrases if ClearAfter decryptions were made, and otherwise starts
+// the MaxTTL timer if it is not running.
+func (p *AgentCachePolicy) decrypted(ctx context.Context, opts *Options) error {
+	p.mu.Lock()
+	p.count++
+	if p.ClearAfter > 0 && p.count >= p.ClearAfter {
+		p.resetLocked()
+		p.mu.Unlock()
+		return clearAgentCache(ctx, opts)
+	}
+	if p.MaxTTL > 0 && p.timer == nil {
+		o := *opts
+		p.timer = time.AfterFunc(p.MaxTTL, func() {
+			p.mu.Lock()
+			p.resetLocked()
+			p.mu.Unlock()
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			clearAgentCache(ctx, &o)
+		})
+	}
+	p.mu.Unlock()
+	return nil
+}
+
+func (p *AgentCachePolicy) resetLocked() {
+	p.count = 0
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+	}
+}
+
+// agentCacheArgs returns the gpg options for the policy of opts.
+func agentCacheArgs(opts *Options) []string {
+	if opts != nil && opts.AgentCache != nil && opts.AgentCache.NoSymkeyCache {
+		return []string{"--no-symkey-cache"}
+	}
+	return nil
+}
+
+// afterDecrypt applies Options.AgentCache after a decryption using gpg.
+func afterDecrypt(ctx context.Context, opts *Options) error {
+	if opts == nil || opts.AgentCache == nil {
+		return nil
+	}
+	if err := opts.AgentCache.decrypted(ctx, opts); err != nil {
+		return fmt.Errorf("clear agent cache: %w", err)
+	}
+	return nil
+}
+
+// ClearAgentCache clears the cached passphrases of the secret keys of the
+// store from gpg-agent, using gpg-connect-agent, so that decrypting
+// entries needs the passphrase again. Applications can use it to
+// implement a lock button. It also restarts the count and timer of
+// Options.AgentCache.
+func ClearAgentCache(ctx context.Context, options ...Option) error {
+	opts := resolveOptions(options)
+	if p := opts.AgentCache; p != nil {
+		p.mu.Lock()
+		p.resetLocked()
+		p.mu.Unlock()
+	}
+	return clearAgentCache(ctx, opts)
+}
+
+// clearAgentCache clears the cached passphrases of the secret keys of the
+// store from gpg-agent.
+func clearAgentCache(ctx context.Context, opts *Options) error {
+	gpgIDs, err := allGPGIDs(opts)
+	if err != nil || len(gpgIDs) == 0 {
+		return err
+	}
+	args := []string{"--batch", "--with-colons", "--with-keygrip", "--list-secret-keys", "--"}
+	args = append(args, gpgIDs...)
+	stdout, _, err := execGPG(ctx, args, nil, opts)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return nil // no matching secret keys
+	}
+
+	var cmds []string
+	for _, fields := range colonRecords(stdout) {
+		if fields[0] == "grp" && len(fields) > 9 && fields[9] != "" {
+			cmds = append(cmds, "CLEAR_PASSPHRASE --mode=normal "+fields[9])
+		}
+	}
+	if len(cmds) == 0 {
+		return nil
+	}
+	cmds = append(cmds, "/bye")
+	stdout, _, err = runCommand(ctx, "gpg-connect-agent", "gpg-connect-agent", cmds, baseEnv(opts), nil, nil, opts)
+	if err != nil {
+		return fmt.Errorf("exec gpg-connect-agent: %w", err)
+	}
+	for _, line := range strings.Split(string(stdout), "\n") {
+		if strings.HasPrefix(line, "ERR ") {
+			return errors.New(strings.TrimPrefix(line, "ERR "))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package pass
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAgentCachePolicy(t *testing.T) {
+	storeDir := makeTestTree([]string{"a.gpg"})
+	defer os.RemoveAll(storeDir)
+	writeTestFile(t, storeDir, ".gpg-id", "alice@example.com\n")
+
+	var (
+		mu      sync.Mutex
+		clears  []string
+		gpgOpts string
+	)
+	runner := RunnerFunc(func(cmd *exec.Cmd) error {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case strings.HasSuffix(cmd.Args[0], "gpg-connect-agent"):
+			clears = append(clears, strings.Join(cmd.Args[1:], ";"))
+			fmt.Fprintln(cmd.Stdout, "OK")
+		case strings.Contains(strings.Join(cmd.Args, " "), "--list-secret-keys"):
+			fmt.Fprint(cmd.Stdout, `sec:u:255:22:AAAA000000000001:1:::u:::scESC:::+:::23::0:
+grp:::::::::1111111111111111111111111111111111111111:
+ssb:u:255:18:AAAA000000000002:1::::::e:::+:::23:
+grp:::::::::2222222222222222222222222222222222222222:
+`)
+		default:
+			for _, kv := range cmd.Env {
+				if strings.HasPrefix(kv, "PASSWORD_STORE_GPG_OPTS=") {
+					gpgOpts = kv
+				}
+			}
+		}
+		return nil
+	})
+	numClears := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(clears)
+	}
+
+	policy := &AgentCachePolicy{NoSymkeyCache: true, ClearAfter: 2}
+	opts := &Options{StoreDir: storeDir, Runner: runner, AgentCache: policy}
+	ctx := context.Background()
+
+	_, err := Show(ctx, "a", "", opts)
+	Ok(t, err)
+	Equal(t, "0", fmt.Sprint(numClears()))
+	if !strings.Contains(gpgOpts, "--no-symkey-cache") {
+		t.Errorf("expected --no-symkey-cache in %s", gpgOpts)
+	}
+	_, err = Show(ctx, "a", "", opts)
+	Ok(t, err)
+	Equal(t, "1", fmt.Sprint(numClears()))
+	Equal(t, "CLEAR_PASSPHRASE --mode=normal 1111111111111111111111111111111111111111;"+
+		"CLEAR_PASSPHRASE --mode=normal 2222222222222222222222222222222222222222;/bye", clears[0])
+
+	// MaxTTL clears the cache once, some time after the first decryption.
+	opts.AgentCache = &AgentCachePolicy{MaxTTL: 20 * time.Millisecond}
+	_, err = Show(ctx, "a", "", opts)
+	Ok(t, err)
+	_, err = Show(ctx, "a", "", opts)
+	Ok(t, err)
+	deadline := time.Now().Add(5 * time.Second)
+	for numClears() < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	Equal(t, "2", fmt.Sprint(numClears()))
+
+	Ok(t, ClearAgentCache(ctx, opts))
+	Equal(t, "3", fmt.Sprint(numClears()))
+}
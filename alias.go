@@ -0,0 +1,120 @@
+package pass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Alias makes aliasName refer to the password file target, by creating a
+// relative symbolic link to it. Reading the alias reads the target, and
+// changes to the target are seen through the alias. If the store is a git
+// repository, the new alias is committed. It needs Write access on
+// aliasName, and Read access on target.
+func Alias(ctx context.Context, target, aliasName string, options ...Option) error {
+	opts := resolveOptions(options)
+	return mutateWriting(ctx, "alias", []string{aliasName, target}, []string{aliasName}, opts, func() error {
+		names, err := accessNames(target, opts)
+		if err != nil {
+			return err
+		}
+		if err := checkACL(names, Read, opts); err != nil {
+			return err
+		}
+		if err := checkSourceApproval(ctx, target, opts); err != nil {
+			return err
+		}
+		return createAlias(ctx, target, aliasName, opts)
+	})
+}
+
+func createAlias(ctx context.Context, target, aliasName string, opts *Options) error {
+	storeDir := resolveStoreDir(opts)
+	targetPath := filepath.Join(storeDir, target+".gpg")
+	aliasPath := filepath.Join(storeDir, aliasName+".gpg")
+
+	info, err := os.Stat(targetPath)
+	if os.IsNotExist(err) {
+		return errors.New("target does not exist")
+	}
+	if err != nil {
+		return fmt.Errorf("stat: %w", err)
+	}
+	if info.IsDir() {
+		return errors.New("target is not a file")
+	}
+	if _, err := os.Lstat(aliasPath); err == nil {
+		return errors.New("alias name already exists")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(aliasPath), 0700); err != nil {
+		return fmt.Errorf("make alias dir: %w", err)
+	}
+	rel, err := filepath.Rel(filepath.Dir(aliasPath), targetPath)
+	if err != nil {
+		return fmt.Errorf("relative path: %w", err)
+	}
+	if err := os.Symlink(rel, aliasPath); err != nil {
+		return fmt.Errorf("symlink: %w", err)
+	}
+
+	msg := fmt.Sprintf("Add alias %s for %s.", aliasName, target)
+	if err := commitFiles(ctx, msg, []string{aliasName + ".gpg"}, opts); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+// Aliases returns the aliases in the subfolder of the store, as created by
+// Alias. The returned map is keyed by alias name, and the values are the
+// names of the entries the aliases refer to. Symbolic links that point
+// outside the store are not included.
+func Aliases(ctx context.Context, subfolder string, options ...Option) (map[string]string, error) {
+	opts := resolveOptions(options)
+	storeDir := filepath.Clean(resolveStoreDir(opts))
+
+	targetDir := storeDir
+	if subfolder != "" {
+		targetDir = filepath.Join(storeDir, subfolder)
+	}
+
+	ret := make(map[string]string)
+
+	err := filepath.Walk(targetDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if info.Mode()&os.ModeSymlink == 0 || !strings.HasSuffix(info.Name(), ".gpg") {
+			return nil
+		}
+
+		dest, err := os.Readlink(p)
+		if err != nil {
+			return err
+		}
+		if !filepath.IsAbs(dest) {
+			dest = filepath.Join(filepath.Dir(p), dest)
+		}
+		destRel, err := filepath.Rel(storeDir, filepath.Clean(dest))
+		if err != nil || destRel == ".." || strings.HasPrefix(destRel, ".."+string(filepath.Separator)) {
+			return nil // outside the store
+		}
+
+		rel, err := filepath.Rel(storeDir, p)
+		if err != nil {
+			panic(err) // should not happen
+		}
+		ret[strings.TrimSuffix(rel, ".gpg")] = strings.TrimSuffix(destRel, ".gpg")
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
@@ -0,0 +1,74 @@
+package pass
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAlias(t *testing.T) {
+	storeDir := makeTestTree([]string{
+		"shared/admin.gpg",
+	})
+	if err := ioutil.WriteFile(filepath.Join(storeDir, "shared/admin.gpg"), []byte("x"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("/etc/passwd", filepath.Join(storeDir, "outside.gpg")); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	opts := &Options{StoreDir: storeDir}
+
+	err := Alias(ctx, "shared/admin", "team/ops/admin", opts)
+	Ok(t, err)
+
+	b, err := ioutil.ReadFile(filepath.Join(storeDir, "team/ops/admin.gpg"))
+	Ok(t, err)
+	Equal(t, "x", string(b))
+
+	dest, err := os.Readlink(filepath.Join(storeDir, "team/ops/admin.gpg"))
+	Ok(t, err)
+	Equal(t, "../../shared/admin.gpg", dest)
+
+	if err := Alias(ctx, "shared/admin", "team/ops/admin", opts); err == nil {
+		t.Errorf("expected error for existing alias")
+	}
+	if err := Alias(ctx, "shared/missing", "team/missing", opts); err == nil {
+		t.Errorf("expected error for missing target")
+	}
+
+	aliases, err := Aliases(ctx, "", opts)
+	Ok(t, err)
+	if len(aliases) != 1 {
+		t.Errorf("expected 1 alias, got %d", len(aliases))
+		return
+	}
+	Equal(t, "shared/admin", aliases["team/ops/admin"])
+}
+
+func TestAliasACL(t *testing.T) {
+	acl := NewACL()
+	Ok(t, acl.Allow("ci-bot", "shared/*", Read))
+	Ok(t, acl.Allow("ci-bot", "team/**", Read|Write))
+	opts := &Options{
+		StoreDir:  makeTestTree([]string{"shared/admin.gpg", "secret/db.gpg"}),
+		ACL:       acl,
+		Principal: "ci-bot",
+	}
+	defer os.RemoveAll(opts.StoreDir)
+	ctx := context.Background()
+
+	// Aliasing needs Read on the target, not Write.
+	Ok(t, Alias(ctx, "shared/admin", "team/admin", opts))
+	for _, err := range []error{
+		Alias(ctx, "secret/db", "team/db", opts),
+		Alias(ctx, "shared/admin", "other/admin", opts),
+	} {
+		if err != ErrPermissionDenied {
+			t.Errorf("expected: %s, got: %v", ErrPermissionDenied, err)
+		}
+	}
+}
@@ -0,0 +1,342 @@
+package pass
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrApprovalRequired is returned by Show, and by Copy, Move, and Alias,
+// for entries covered by Options.Approvals that have no valid approved
+// AccessRequest.
+var ErrApprovalRequired = errors.New("approval required")
+
+// approvalsDir is the folder, relative to the root of the store, that
+// access requests and their approvals are kept in.
+const approvalsDir = ".approvals"
+
+// ApprovalPolicy requires a second person to approve each access to some
+// entries, for dual control. A principal must request access to such an
+// entry using RequestAccess, and one of the approvers must approve the
+// request using Approve, before Show shows the entry to the principal, or
+// the principal copies, moves, or aliases it. Aliases of the entries are
+// covered too. Requests and approvals are committed to the store.
+//
+// Principals cannot approve their own requests: an approval only counts if
+// the approver holding the signing key is not the requester.
+type ApprovalPolicy struct {
+	// The entries that need approval, as patterns in the format of
+	// ACL.Allow.
+	Patterns []string
+
+	// The fingerprints of the gpg keys whose signatures approve requests,
+	// mapped to the principals who hold them.
+	Approvers map[string]string
+
+	// How long an approval is valid for after it is given. If zero,
+	// approvals are valid for an hour.
+	TTL time.Duration
+
+	once sync.Once
+	res  []*regexp.Regexp
+	err  error // from compiling Patterns
+}
+
+func (p *ApprovalPolicy) covers(name string) (bool, error) {
+	p.once.Do(func() {
+		for _, pat := range p.Patterns {
+			re, err := regexp.Compile("^" + globToRegexp(pat) + "$")
+			if err != nil {
+				p.err = fmt.Errorf("bad pattern %q: %w", pat, err)
+				return
+			}
+			p.res = append(p.res, re)
+		}
+	})
+	if p.err != nil {
+		return false, p.err
+	}
+	for _, re := range p.res {
+		if re.MatchString(filepath.ToSlash(name)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (p *ApprovalPolicy) ttl() time.Duration {
+	if p.TTL > 0 {
+		return p.TTL
+	}
+	return time.Hour
+}
+
+// AccessRequest is a request by a principal to show an entry.
+type AccessRequest struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Requester string    `json:"requester"`
+	Reason    string    `json:"reason,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+func requestPath(storeDir, id string) string {
+	return filepath.Join(storeDir, approvalsDir, id+".json")
+}
+
+func approvalPath(storeDir, id string) string {
+	return filepath.Join(storeDir, approvalsDir, id+".sig")
+}
+
+// RequestAccess records a request by Options.Principal to show the named
+// entry, and returns it. The request needs to be approved using Approve.
+func RequestAccess(ctx context.Context, name, reason string, options ...Option) (*AccessRequest, error) {
+	opts := resolveOptions(options)
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	r := &AccessRequest{
+		ID:        hex.EncodeToString(id),
+		Name:      name,
+		Requester: opts.Principal,
+		Reason:    reason,
+		Time:      time.Now().UTC(),
+	}
+
+	err := mutate(ctx, "request-access", nil, opts, func() error {
+		b, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		p := requestPath(resolveStoreDir(opts), r.ID)
+		if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(p, b, 0600); err != nil {
+			return err
+		}
+		msg := fmt.Sprintf("Request access to %s.", name)
+		if err := commitFiles(ctx, msg, []string{filepath.Join(approvalsDir, r.ID+".json")}, opts); err != nil {
+			return fmt.Errorf("commit: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// PendingRequests returns the access requests that have not been approved,
+// oldest first.
+func PendingRequests(ctx context.Context, options ...Option) ([]AccessRequest, error) {
+	opts := resolveOptions(options)
+	requests, err := readAccessRequests(opts)
+	if err != nil {
+		return nil, err
+	}
+	storeDir := resolveStoreDir(opts)
+	var ret []AccessRequest
+	for _, r := range requests {
+		if _, err := os.Stat(approvalPath(storeDir, r.ID)); os.IsNotExist(err) {
+			ret = append(ret, r)
+		}
+	}
+	return ret, nil
+}
+
+func readAccessRequests(opts *Options) ([]AccessRequest, error) {
+	dir := filepath.Join(resolveStoreDir(opts), approvalsDir)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ret []AccessRequest
+	for _, e := range entries {
+		if !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		b, err := ioutil.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var r AccessRequest
+		if err := json.Unmarshal(b, &r); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", e.Name(), err)
+		}
+		ret = append(ret, r)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Time.Before(ret[j].Time) })
+	return ret, nil
+}
+
+// Approve approves the access request with the id by signing it with the
+// gpg key signingKey, which must be usable without a passphrase prompt and
+// be one of the ApprovalPolicy.Approvers. Options.Principal cannot approve
+// its own requests.
+func Approve(ctx context.Context, id, signingKey string, options ...Option) error {
+	opts := resolveOptions(options)
+	return mutate(ctx, "approve", nil, opts, func() error {
+		storeDir := resolveStoreDir(opts)
+		b, err := ioutil.ReadFile(requestPath(storeDir, filepath.Base(id)))
+		if os.IsNotExist(err) {
+			return errors.New("request does not exist")
+		}
+		if err != nil {
+			return err
+		}
+		var r AccessRequest
+		if err := json.Unmarshal(b, &r); err != nil {
+			return fmt.Errorf("parse request: %w", err)
+		}
+		if opts.Principal != "" && opts.Principal == r.Requester {
+			return errors.New("cannot approve own request")
+		}
+
+		args := []string{"--batch", "--detach-sign", "--local-user", signingKey}
+		sig, _, err := execGPG(ctx, args, bytes.NewReader(b), opts)
+		if err != nil {
+			return fmt.Errorf("sign request: %w", err)
+		}
+		if err := ioutil.WriteFile(approvalPath(storeDir, r.ID), sig, 0600); err != nil {
+			return err
+		}
+		msg := fmt.Sprintf("Approve access to %s by %s.", r.Name, r.Requester)
+		if err := commitFiles(ctx, msg, []string{filepath.Join(approvalsDir, r.ID+".sig")}, opts); err != nil {
+			return fmt.Errorf("commit: %w", err)
+		}
+		return nil
+	})
+}
+
+// checkApproval returns ErrApprovalRequired if the named entry is covered
+// by Options.Approvals and Options.Principal has no request to show it
+// that was approved within ApprovalPolicy.TTL.
+func checkApproval(ctx context.Context, name string, opts *Options) error {
+	if opts == nil || opts.Approvals == nil {
+		return nil
+	}
+	covered, err := opts.Approvals.covers(name)
+	if err != nil {
+		return fmt.Errorf("approval policy: %w", err)
+	}
+	if !covered {
+		return nil
+	}
+	requests, err := readAccessRequests(opts)
+	if err != nil {
+		return fmt.Errorf("read access requests: %w", err)
+	}
+	storeDir := resolveStoreDir(opts)
+	for _, r := range requests {
+		if r.Name != name || r.Requester != opts.Principal {
+			continue
+		}
+		if t, ok := verifyApproval(ctx, storeDir, r.ID, opts); ok && time.Since(t) <= opts.Approvals.ttl() {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrApprovalRequired, name)
+}
+
+// checkSourceApproval is checkApproval for copying, moving, or aliasing the
+// entry or folder name, which would make the content of the entries
+// readable under another name. It checks every entry in a folder, and the
+// targets of aliases.
+func checkSourceApproval(ctx context.Context, name string, opts *Options) error {
+	if opts == nil || opts.Approvals == nil {
+		return nil
+	}
+	storeDir := resolveStoreDir(opts)
+	names := []string{filepath.Clean(name)}
+	root := filepath.Join(storeDir, name)
+	if info, err := os.Stat(root); err == nil && info.IsDir() {
+		names = nil
+		err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() && info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			if !info.IsDir() && strings.HasSuffix(info.Name(), ".gpg") {
+				rel, err := filepath.Rel(storeDir, p)
+				if err != nil {
+					panic(err) // should not happen
+				}
+				names = append(names, strings.TrimSuffix(rel, ".gpg"))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for _, n := range names {
+		access, err := accessNames(n, opts)
+		if err != nil {
+			return err
+		}
+		for _, n := range access {
+			if err := checkApproval(ctx, n, opts); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// verifyApproval reports whether the approval of the request with the id
+// is a valid signature of the request by one of the approvers other than
+// the requester, and if so returns the time it was made.
+func verifyApproval(ctx context.Context, storeDir, id string, opts *Options) (time.Time, bool) {
+	b, err := ioutil.ReadFile(requestPath(storeDir, id))
+	if err != nil {
+		return time.Time{}, false
+	}
+	var r AccessRequest
+	if err := json.Unmarshal(b, &r); err != nil {
+		return time.Time{}, false
+	}
+	args := []string{"--batch", "--status-fd=2", "--verify", approvalPath(storeDir, id), "-"}
+	_, stderr, err := execGPG(ctx, args, bytes.NewReader(b), opts)
+	if err != nil {
+		return time.Time{}, false
+	}
+	for _, s := range statusLines(stderr) {
+		// VALIDSIG has the fingerprint of the signing key first, then the
+		// date and the Unix time of the signature, and the fingerprint of
+		// the primary key last.
+		if s.keyword != "VALIDSIG" || len(s.args) < 3 {
+			continue
+		}
+		sec, err := strconv.ParseInt(s.args[2], 10, 64)
+		if err != nil {
+			continue
+		}
+		for a, principal := range opts.Approvals.Approvers {
+			a = strings.ToUpper(strings.ReplaceAll(a, " ", ""))
+			if (a == s.args[0] || a == s.args[len(s.args)-1]) && principal != r.Requester {
+				return time.Unix(sec, 0), true
+			}
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,124 @@
+package pass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestApprovals(t *testing.T) {
+	defer fakeCommand("pass", `for arg; do name="$arg"; done; cat "$PASSWORD_STORE_DIR/$name.gpg"`)()
+
+	dir := makeTestTree(nil)
+	defer os.RemoveAll(dir)
+	env, fprs := testKeyring(t, filepath.Join(dir, "gnupg"), "Approver <approver@example.com>", "Other <other@example.com>")
+	opts := &Options{
+		StoreDir:  filepath.Join(dir, "store"),
+		Env:       env,
+		Principal: "alice",
+		Approvals: &ApprovalPolicy{
+			Patterns:  []string{"prod/**"},
+			Approvers: map[string]string{fprs[0]: "carol", fprs[1]: "alice"},
+		},
+	}
+	writeTestFile(t, opts.StoreDir, "prod/db.gpg", "secret")
+	writeTestFile(t, opts.StoreDir, "dev/db.gpg", "dev")
+	ctx := context.Background()
+
+	_, err := Show(ctx, "dev/db", "", opts)
+	Ok(t, err)
+	_, err = Show(ctx, "prod/db", "", opts)
+	if !errors.Is(err, ErrApprovalRequired) {
+		t.Fatalf("expected ErrApprovalRequired, got: %v", err)
+	}
+
+	r, err := RequestAccess(ctx, "prod/db", "incident 42", opts)
+	Ok(t, err)
+	pending, err := PendingRequests(ctx, opts)
+	Ok(t, err)
+	Equal(t, "1 alice incident 42", fmt.Sprint(len(pending), " ", pending[0].Requester, " ", pending[0].Reason))
+
+	// alice cannot approve her own request, with Approve or by signing it
+	// with her key.
+	if err := Approve(ctx, r.ID, fprs[1], opts); err == nil {
+		t.Errorf("expected error approving own request")
+	}
+	carol := *opts
+	carol.Principal = "carol"
+	Ok(t, Approve(ctx, r.ID, fprs[1], &carol))
+	_, err = Show(ctx, "prod/db", "", opts)
+	if !errors.Is(err, ErrApprovalRequired) {
+		t.Errorf("expected ErrApprovalRequired, got: %v", err)
+	}
+
+	Ok(t, Approve(ctx, r.ID, fprs[0], &carol))
+	pending, err = PendingRequests(ctx, opts)
+	Ok(t, err)
+	Equal(t, "0", fmt.Sprint(len(pending)))
+	got, err := Show(ctx, "prod/db", "", opts)
+	Ok(t, err)
+	Equal(t, "secret", string(got))
+
+	// The approval is for alice only.
+	_, err = Show(ctx, "prod/db", "", opts, &Options{StoreDir: opts.StoreDir, Env: env, Principal: "bob", Approvals: opts.Approvals})
+	if !errors.Is(err, ErrApprovalRequired) {
+		t.Errorf("expected ErrApprovalRequired, got: %v", err)
+	}
+}
+
+func TestApprovalsAliases(t *testing.T) {
+	defer fakeCommand("pass", `for arg; do name="$arg"; done; cat "$PASSWORD_STORE_DIR/$name.gpg"`)()
+
+	opts := &Options{
+		StoreDir:  makeTestTree(nil),
+		Principal: "alice",
+		Approvals: &ApprovalPolicy{Patterns: []string{"prod/**"}},
+	}
+	defer os.RemoveAll(opts.StoreDir)
+	writeTestFile(t, opts.StoreDir, "prod/db.gpg", "secret")
+	writeTestFile(t, opts.StoreDir, "dev/db.gpg", "dev")
+	Ok(t, os.Symlink(filepath.Join("..", "prod", "db.gpg"), filepath.Join(opts.StoreDir, "dev", "link.gpg")))
+	ctx := context.Background()
+
+	got, err := Show(ctx, "dev/db", "", opts)
+	Ok(t, err)
+	Equal(t, "dev", string(got))
+
+	// Neither reading an alias of a covered entry, nor copying, moving,
+	// or aliasing one gets around the approval.
+	_, err = Show(ctx, "dev/link", "", opts)
+	if !errors.Is(err, ErrApprovalRequired) {
+		t.Errorf("Show: expected ErrApprovalRequired, got: %v", err)
+	}
+	_, copyFolderErr := CopyFolder(ctx, "prod", "copied", false, nil, opts)
+	for name, err := range map[string]error{
+		"Alias":      Alias(ctx, "prod/db", "dev/alias", opts),
+		"Copy":       Copy(ctx, "prod/db", "dev/copy", false, opts),
+		"Move":       Move(ctx, "prod", "dev/prod", false, opts),
+		"Copy alias": Copy(ctx, "dev/link", "dev/copy", false, opts),
+		"CopyFolder": copyFolderErr,
+	} {
+		if !errors.Is(err, ErrApprovalRequired) {
+			t.Errorf("%s: expected ErrApprovalRequired, got: %v", name, err)
+		}
+	}
+	_, err = os.Lstat(filepath.Join(opts.StoreDir, "prod", "db.gpg"))
+	Ok(t, err)
+}
+
+func TestApprovalsBadPattern(t *testing.T) {
+	defer fakeCommand("pass", `exit 0`)()
+
+	opts := &Options{
+		StoreDir:  makeTestTree([]string{"a.gpg"}),
+		Approvals: &ApprovalPolicy{Patterns: []string{"[z-a]"}},
+	}
+	defer os.RemoveAll(opts.StoreDir)
+
+	if _, err := Show(context.Background(), "a", "", opts); err == nil {
+		t.Errorf("expected error for bad pattern")
+	}
+}
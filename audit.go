@@ -0,0 +1,205 @@
+package pass
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"time"
+)
+
+// AuditRecord is a line of the audit log. It never contains the content
+// of entries.
+type AuditRecord struct {
+	Time      time.Time `json:"time"`
+	User      string    `json:"user"`
+	Operation string    `json:"op"`
+	Names     []string  `json:"names,omitempty"`
+	Error     string    `json:"error,omitempty"`
+
+	// Base64 encoded detached gpg signature of the record with an empty
+	// Signature. Only set if Options.AuditSigningKey is set.
+	Signature string `json:"sig,omitempty"`
+}
+
+// audit appends a record of the operation to the audit log, if one is
+// configured. opErr is the error the operation failed with, if any.
+func audit(ctx context.Context, op string, names []string, opErr error, opts *Options) error {
+	if opts == nil || opts.AuditLog == "" {
+		return nil
+	}
+
+	r := AuditRecord{
+		Time:      time.Now().UTC(),
+		User:      auditUser(opts),
+		Operation: op,
+		Names:     names,
+	}
+	if opErr != nil {
+		r.Error = opErr.Error()
+	}
+
+	if opts.AuditSigningKey != "" {
+		b, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		args := []string{"--batch", "--detach-sign", "--local-user", opts.AuditSigningKey}
+		sig, _, err := execGPG(ctx, args, bytes.NewReader(b), opts)
+		if err != nil {
+			return fmt.Errorf("sign audit record: %w", err)
+		}
+		r.Signature = base64.StdEncoding.EncodeToString(sig)
+	}
+
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	f, err := os.OpenFile(opts.AuditLog, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("open audit log: %w", err)
+	}
+	// A single write to a file opened for appending is not interleaved
+	// with the writes of other processes.
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return fmt.Errorf("write audit log: %w", err)
+	}
+	return f.Close()
+}
+
+func auditUser(opts *Options) string {
+	if opts.AuditUser != "" {
+		return opts.AuditUser
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return fmt.Sprintf("uid %d", os.Getuid())
+}
+
+// maxAuditRecord is the maximum length of a line of the audit log read by
+// ReadAuditLog. Records of batches that change many entries are long.
+const maxAuditRecord = 16 << 20
+
+// ReadAuditLog returns the records in an audit log written using
+// Options.AuditLog. If verify is set, the signature of each record is
+// checked using the gpg keyring, and an error is returned for the first
+// record that is not signed by Options.AuditSigningKey.
+func ReadAuditLog(ctx context.Context, r io.Reader, verify bool, options ...Option) ([]AuditRecord, error) {
+	opts := resolveOptions(options)
+	var signers map[string]bool
+	if verify {
+		if opts.AuditSigningKey == "" {
+			return nil, errors.New("verify: no audit signing key set")
+		}
+		var err error
+		signers, err = publicKeyFingerprints(ctx, opts.AuditSigningKey, opts)
+		if err != nil {
+			return nil, fmt.Errorf("list audit signing key: %w", err)
+		}
+	}
+
+	var ret []AuditRecord
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, maxAuditRecord)
+	for n := 1; sc.Scan(); n++ {
+		var rec AuditRecord
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("line %d: %s", n, err)
+		}
+		if verify {
+			if err := verifyAuditRecord(ctx, rec, signers, opts); err != nil {
+				return nil, fmt.Errorf("line %d: %s", n, err)
+			}
+		}
+		ret = append(ret, rec)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// verifyAuditRecord checks that the record has a valid signature by the
+// key with one of the primary key fingerprints signers.
+func verifyAuditRecord(ctx context.Context, rec AuditRecord, signers map[string]bool, opts *Options) error {
+	if rec.Signature == "" {
+		return errors.New("record is not signed")
+	}
+	sig, err := base64.StdEncoding.DecodeString(rec.Signature)
+	if err != nil {
+		return fmt.Errorf("decode signature: %w", err)
+	}
+
+	rec.Signature = ""
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	// gpg needs the detached signature in a file when the signed data
+	// comes from stdin.
+	f, err := ioutil.TempFile("", "go-pass-audit-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(sig); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	args := []string{"--batch", "--status-fd=2", "--verify", f.Name(), "-"}
+	_, stderr, err := execGPG(ctx, args, bytes.NewReader(b), opts)
+	if err != nil {
+		return fmt.Errorf("bad signature: %w", err)
+	}
+	for _, s := range statusLines(stderr) {
+		// The last argument of VALIDSIG is the fingerprint of the
+		// primary key.
+		if s.keyword == "VALIDSIG" && len(s.args) > 0 && signers[s.args[len(s.args)-1]] {
+			return nil
+		}
+	}
+	return errors.New("not signed by the audit signing key")
+}
+
+// publicKeyFingerprints returns the fingerprints of the primary keys in
+// the keyring matching gpgID.
+func publicKeyFingerprints(ctx context.Context, gpgID string, opts *Options) (map[string]bool, error) {
+	stdout, _, err := execGPG(ctx, []string{"--batch", "--with-colons", "--list-keys", "--", gpgID}, nil, opts)
+	if err != nil {
+		return nil, fmt.Errorf("exec gpg: %w", err)
+	}
+	ret := make(map[string]bool)
+	inPrimary := false
+	for _, fields := range colonRecords(stdout) {
+		switch fields[0] {
+		case "pub":
+			inPrimary = true
+		case "sub":
+			inPrimary = false
+		case "fpr":
+			if inPrimary && len(fields) > 9 {
+				ret[fields[9]] = true
+				inPrimary = false
+			}
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,83 @@
+package pass
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAudit(t *testing.T) {
+	dir, err := ioutil.TempDir("", tmpDirPrefix)
+	if err != nil {
+		log.Fatalf("create tmp dir: %s", err)
+	}
+
+	ctx := context.Background()
+	opts := &Options{
+		AuditLog:  filepath.Join(dir, "audit.log"),
+		AuditUser: "alice",
+	}
+
+	err = audit(ctx, "show", []string{"google.com/bar"}, nil, opts)
+	Ok(t, err)
+	err = audit(ctx, "rm", []string{"google.com/baz"}, errors.New("exec rm: exit status 1"), opts)
+	Ok(t, err)
+
+	b, err := ioutil.ReadFile(opts.AuditLog)
+	Ok(t, err)
+	records, err := ReadAuditLog(ctx, bytes.NewReader(b), false)
+	Ok(t, err)
+	if len(records) != 2 {
+		t.Errorf("expected 2 records, got %d", len(records))
+		return
+	}
+	Equal(t, "alice", records[0].User)
+	Equal(t, "show", records[0].Operation)
+	Equal(t, "google.com/bar", records[0].Names[0])
+	Equal(t, "", records[0].Error)
+	Equal(t, "exec rm: exit status 1", records[1].Error)
+
+	if _, err := ReadAuditLog(ctx, bytes.NewReader(b), true); err == nil {
+		t.Errorf("expected error verifying unsigned records")
+	}
+
+	// No audit log configured.
+	err = audit(ctx, "show", []string{"google.com/bar"}, nil, &Options{})
+	Ok(t, err)
+}
+
+func TestAuditSignature(t *testing.T) {
+	dir := makeTestTree(nil)
+	defer os.RemoveAll(dir)
+	env, fprs := testKeyring(t, filepath.Join(dir, "gnupg"), "A <a@example.com>", "B <b@example.com>")
+	ctx := context.Background()
+	opts := &Options{
+		AuditLog:        filepath.Join(dir, "audit.log"),
+		AuditUser:       "alice",
+		AuditSigningKey: fprs[0],
+		Env:             env,
+	}
+
+	// A record longer than the default limit of bufio.Scanner.
+	names := make([]string, 10000)
+	for i := range names {
+		names[i] = fmt.Sprintf("team/service-%d/password", i)
+	}
+	Ok(t, audit(ctx, "batch", names, nil, opts))
+
+	b, err := ioutil.ReadFile(opts.AuditLog)
+	Ok(t, err)
+	records, err := ReadAuditLog(ctx, bytes.NewReader(b), true, opts)
+	Ok(t, err)
+	Equal(t, "1", fmt.Sprint(len(records)))
+
+	// A good signature by another key in the keyring is rejected.
+	_, err = ReadAuditLog(ctx, bytes.NewReader(b), true, &Options{AuditSigningKey: fprs[1], Env: env})
+	Equal(t, "line 1: not signed by the audit signing key", fmt.Sprint(err))
+}
@@ -0,0 +1,76 @@
+package pass
+
+import (
+	"context"
+	"time"
+)
+
+// Lock wipes the secrets that the store keeps for a while: it closes the
+// sessions returned by Unlock, clears Options.Cache, and clears the
+// passphrases of the secret keys of the store from gpg-agent, as with
+// ClearAgentCache. Then it calls Options.OnLock. The store can still be
+// used after it is locked. If Options.AutoLock is set, the store locks
+// itself after it was not used for that long.
+func (s *Store) Lock(ctx context.Context) error {
+	s.mu.Lock()
+	if s.idle != nil {
+		s.idle.Stop()
+	}
+	sessions := s.sessions
+	s.sessions = nil
+	s.mu.Unlock()
+
+	for sess := range sessions {
+		sess.Close()
+	}
+	if s.opts.Cache != nil {
+		s.opts.Cache.Clear()
+	}
+	opts := s.opts // without touching the store
+	if p := opts.AgentCache; p != nil {
+		p.mu.Lock()
+		p.resetLocked()
+		p.mu.Unlock()
+	}
+	err := clearAgentCache(ctx, &opts)
+	if s.opts.OnLock != nil {
+		s.opts.OnLock()
+	}
+	return err
+}
+
+// touch records that the store is used, restarting the Options.AutoLock
+// timer.
+func (s *Store) touch() {
+	if s.opts.AutoLock <= 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.idle == nil {
+		s.idle = time.AfterFunc(s.opts.AutoLock, func() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			s.Lock(ctx)
+		})
+		return
+	}
+	s.idle.Reset(s.opts.AutoLock)
+}
+
+// addSession records a session to be closed by Lock.
+func (s *Store) addSession(sess *Session) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sessions == nil {
+		s.sessions = make(map[*Session]struct{})
+	}
+	s.sessions[sess] = struct{}{}
+}
+
+// removeSession forgets a closed session.
+func (s *Store) removeSession(sess *Session) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, sess)
+}
@@ -0,0 +1,82 @@
+package pass
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Aliases of the keys of the fields read by ReadAWSCredentials, in order of
+// preference.
+var (
+	awsAccessKeyIDKeys     = []string{"aws_access_key_id", "access_key_id", "access_key"}
+	awsSecretAccessKeyKeys = []string{"aws_secret_access_key", "secret_access_key", "secret_key"}
+	awsSessionTokenKeys    = []string{"aws_session_token", "session_token"}
+	awsExpirationKeys      = []string{"expiration", "expires"}
+)
+
+// AWSCredentials is the output of an AWS credential_process, as read by
+// the AWS CLI and SDKs. Unlike Secret, it is not redacted when printed or
+// encoded.
+type AWSCredentials struct {
+	Version         int        `json:"Version"`
+	AccessKeyID     string     `json:"AccessKeyId"`
+	SecretAccessKey string     `json:"SecretAccessKey"`
+	SessionToken    string     `json:"SessionToken,omitempty"`
+	Expiration      *time.Time `json:"Expiration,omitempty"`
+}
+
+// ReadAWSCredentials reads AWS credentials from the named entry, decrypted
+// as in Show. The secret access key is the "aws_secret_access_key",
+// "secret_access_key", or "secret_key" field, or else the first line of the
+// entry. The access key ID is the "aws_access_key_id", "access_key_id", or
+// "access_key" field, and is required. The session token is the optional
+// "aws_session_token" or "session_token" field, and its expiration, in
+// RFC 3339 format, the optional "expiration" or "expires" field.
+func ReadAWSCredentials(ctx context.Context, name, gpgPassphrase string, options ...Option) (*AWSCredentials, error) {
+	content, err := Show(ctx, name, gpgPassphrase, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &AWSCredentials{
+		Version:         1,
+		AccessKeyID:     content.firstField(awsAccessKeyIDKeys),
+		SecretAccessKey: content.firstField(awsSecretAccessKeyKeys),
+		SessionToken:    content.firstField(awsSessionTokenKeys),
+	}
+	if c.AccessKeyID == "" {
+		return nil, errors.New("no access key id field")
+	}
+	if c.SecretAccessKey == "" {
+		c.SecretAccessKey = string(content.Password())
+	}
+	if c.SecretAccessKey == "" {
+		return nil, errors.New("no secret access key")
+	}
+	if s := content.firstField(awsExpirationKeys); s != "" {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return nil, fmt.Errorf("parse expiration: %w", err)
+		}
+		c.Expiration = &t
+	}
+	return c, nil
+}
+
+// AWSCredentialProcess writes the AWS credentials in the named entry to w
+// in the JSON format of credential_process. A program that calls it can be
+// used as the credential_process of an AWS profile:
+//
+//	[profile prod]
+//	credential_process = /usr/local/bin/aws-pass aws/prod
+func AWSCredentialProcess(ctx context.Context, w io.Writer, name, gpgPassphrase string, options ...Option) error {
+	c, err := ReadAWSCredentials(ctx, name, gpgPassphrase, options...)
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(c)
+}
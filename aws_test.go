@@ -0,0 +1,31 @@
+package pass
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"log"
+	"testing"
+)
+
+func TestAWSCredentialProcess(t *testing.T) {
+	storeDir, err := ioutil.TempDir("", tmpDirPrefix)
+	if err != nil {
+		log.Fatalf("create tmp dir: %s", err)
+	}
+
+	opts := &Options{
+		StoreDir: storeDir,
+	}
+	ctx := context.Background()
+	err = Init(ctx, testGpgID, "", opts)
+	Ok(t, err)
+
+	err = Insert(ctx, "aws/prod", []byte("secret\naws_access_key_id: AKIA123\nexpiration: 2030-01-02T03:04:05Z\n"), false, opts)
+	Ok(t, err)
+
+	var buf bytes.Buffer
+	err = AWSCredentialProcess(ctx, &buf, "aws/prod", testGpgPassphrase, opts)
+	Ok(t, err)
+	Equal(t, `{"Version":1,"AccessKeyId":"AKIA123","SecretAccessKey":"secret","Expiration":"2030-01-02T03:04:05Z"}`+"\n", buf.String())
+}
@@ -0,0 +1,113 @@
+package pass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Tx accumulates changes to the store within a call to Batch.
+type Tx struct {
+	ctx  context.Context
+	opts *Options
+}
+
+// Insert is like the package-level Insert, within the transaction.
+func (tx *Tx) Insert(name string, content []byte, force bool) error {
+	return Insert(tx.ctx, name, content, force, tx.opts)
+}
+
+// Remove is like the package-level Remove, within the transaction.
+func (tx *Tx) Remove(name string, recursive, force bool) error {
+	return Remove(tx.ctx, name, recursive, force, tx.opts)
+}
+
+// Move is like the package-level Move, within the transaction.
+func (tx *Tx) Move(oldPath, newPath string, force bool) error {
+	return Move(tx.ctx, oldPath, newPath, force, tx.opts)
+}
+
+// Copy is like the package-level Copy, within the transaction.
+func (tx *Tx) Copy(oldPath, newPath string, force bool) error {
+	return Copy(tx.ctx, oldPath, newPath, force, tx.opts)
+}
+
+// Batch calls fn, and commits all the changes made using tx as a single git
+// commit with the given message. If fn returns an error, all the changes
+// made using tx are rolled back, and the error is returned.
+//
+// With Options.AutoPush and Options.Notifier, only the single commit is
+// pushed and announced, as the operation "batch".
+//
+// The store must be a git repository with no uncommitted changes, and must
+// not be modified by others during the call.
+func Batch(ctx context.Context, message string, fn func(tx *Tx) error, options ...Option) error {
+	opts := resolveOptions(options)
+	return mutate(ctx, "batch", nil, opts, func() error {
+		return batch(ctx, message, fn, opts)
+	})
+}
+
+func batch(ctx context.Context, message string, fn func(tx *Tx) error, opts *Options) error {
+	if !isGitRepo(opts) {
+		return errors.New("store is not a git repository")
+	}
+
+	status, err := gitOutput(ctx, []string{"status", "--porcelain"}, opts)
+	if err != nil {
+		return err
+	}
+	if len(status) > 0 {
+		return errors.New("store has uncommitted changes")
+	}
+
+	start, err := gitHead(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	// The commits of the individual changes are squashed, so there is no
+	// need to rewrite their messages, and they must not be pushed or
+	// announced: Batch does that once for the squashed commit.
+	var txOpts Options
+	if opts != nil {
+		txOpts = *opts
+	}
+	txOpts.CommitMessageTemplate = ""
+	txOpts.AutoPush = false
+	txOpts.Notifier = nil
+
+	if err := fn(&Tx{ctx: ctx, opts: &txOpts}); err != nil {
+		if rbErr := rollback(ctx, start, opts); rbErr != nil {
+			return fmt.Errorf("%s (rollback failed: %s)", err, rbErr)
+		}
+		return err
+	}
+
+	end, err := gitHead(ctx, opts)
+	if err != nil {
+		return err
+	}
+	if end == start {
+		return nil // nothing changed
+	}
+
+	// Squash the commits made by the individual changes.
+	if err := runGit(ctx, []string{"reset", "--soft", start}, opts); err != nil {
+		return err
+	}
+	return runGit(ctx, []string{"commit", "-m", message}, opts)
+}
+
+// rollback restores the store to the commit start, discarding all later
+// commits and changes.
+func rollback(ctx context.Context, start string, opts *Options) error {
+	// Use a context that is not done, so that the store is not left in a
+	// partially rolled back state if ctx was canceled.
+	ctx = context.Background()
+
+	if err := runGit(ctx, []string{"reset", "--hard", start}, opts); err != nil {
+		return err
+	}
+	return runGit(ctx, []string{"clean", "-fd"}, opts)
+}
@@ -0,0 +1,123 @@
+package pass
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBatch(t *testing.T) {
+	storeDir, err := ioutil.TempDir("", tmpDirPrefix)
+	if err != nil {
+		log.Fatalf("create tmp dir: %s", err)
+	}
+
+	opts := &Options{
+		StoreDir: storeDir,
+	}
+	ctx := context.Background()
+	err = Init(ctx, testGpgID, "", opts)
+	Ok(t, err)
+	err = Git(ctx, []string{"init"}, opts)
+	Ok(t, err)
+
+	err = Batch(ctx, "Add bar and baz.", func(tx *Tx) error {
+		if err := tx.Insert("bar", []byte("my_password"), false); err != nil {
+			return err
+		}
+		return tx.Insert("baz", []byte("my_password"), false)
+	}, opts)
+	Ok(t, err)
+
+	out, err := gitOutput(ctx, []string{"log", "-1", "--format=%s"}, opts)
+	Ok(t, err)
+	Equal(t, "Add bar and baz.", strings.TrimSpace(string(out)))
+
+	errBatch := errors.New("batch failed")
+	err = Batch(ctx, "Add qux.", func(tx *Tx) error {
+		if err := tx.Insert("qux", []byte("my_password"), false); err != nil {
+			return err
+		}
+		return errBatch
+	}, opts)
+	if err != errBatch {
+		t.Errorf("expected: %s, got: %v", errBatch, err)
+	}
+	if _, err := os.Stat(filepath.Join(storeDir, "qux.gpg")); !os.IsNotExist(err) {
+		t.Errorf("expected qux to be rolled back")
+	}
+}
+
+func TestBatchPushesOnce(t *testing.T) {
+	defer fakeCommand("pass", `shift; exec git -C "$PASSWORD_STORE_DIR" "$@"`)()
+
+	var events []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e WebhookEvent
+		if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+			t.Errorf("decode event: %s", err)
+		}
+		events = append(events, e.Operation)
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", tmpDirPrefix)
+	if err != nil {
+		log.Fatalf("create tmp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	remote := filepath.Join(dir, "remote.git")
+	Ok(t, exec.Command("git", "init", "-q", "--bare", remote).Run())
+
+	opts := &Options{
+		StoreDir:   makeTestTree(nil),
+		Crypto:     fakeCrypto{"key"},
+		Passphrase: "hunter2",
+		AutoPush:   true,
+		Notifier:   &Notifier{URLs: []string{srv.URL}},
+		Env: []string{
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+		},
+	}
+	defer os.RemoveAll(opts.StoreDir)
+	writeTestFile(t, opts.StoreDir, ".gpg-id", "alice\n")
+	ctx := context.Background()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"remote", "add", "origin", remote},
+		{"config", "remote.pushDefault", "origin"},
+		{"config", "push.default", "current"},
+		{"add", ".gpg-id"},
+		{"commit", "-q", "-m", "Initial commit."},
+		{"push", "-q"},
+	} {
+		Ok(t, runGit(ctx, args, opts))
+	}
+
+	err = Batch(ctx, "Add bar and baz.", func(tx *Tx) error {
+		if err := tx.Insert("bar", []byte("my_password"), false); err != nil {
+			return err
+		}
+		return tx.Insert("baz", []byte("my_password"), false)
+	}, opts)
+	Ok(t, err)
+
+	Equal(t, "[batch]", fmt.Sprint(events))
+	out, err := exec.Command("git", "-C", remote, "log", "--format=%s").Output()
+	Ok(t, err)
+	Equal(t, "Add bar and baz.\nInitial commit.\n", string(out))
+	pending, err := PendingPushes(ctx, opts)
+	Ok(t, err)
+	Equal(t, "0", fmt.Sprint(len(pending)))
+}
@@ -0,0 +1,103 @@
+package pass
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CommitInfo describes a git commit.
+type CommitInfo struct {
+	Hash        string    `json:"hash"`
+	AuthorName  string    `json:"author_name"`
+	AuthorEmail string    `json:"author_email"`
+	Date        time.Time `json:"date"`
+	Subject     string    `json:"subject"`
+}
+
+// commitInfoFormat is the git log format parsed by parseCommitInfo.
+const commitInfoFormat = "%H%x00%an%x00%ae%x00%at%x00%s"
+
+func parseCommitInfo(line string) (CommitInfo, error) {
+	f := strings.Split(line, "\x00")
+	if len(f) != 5 {
+		return CommitInfo{}, fmt.Errorf("unexpected git log output: %q", line)
+	}
+	sec, err := strconv.ParseInt(f[3], 10, 64)
+	if err != nil {
+		return CommitInfo{}, fmt.Errorf("parse commit time: %w", err)
+	}
+	return CommitInfo{
+		Hash:        f[0],
+		AuthorName:  f[1],
+		AuthorEmail: f[2],
+		Date:        time.Unix(sec, 0),
+		Subject:     f[4],
+	}, nil
+}
+
+// LastModified returns the last commit that changed the named entry.
+func LastModified(ctx context.Context, name string, options ...Option) (CommitInfo, error) {
+	opts := resolveOptions(options)
+	p := filepath.ToSlash(resolveName(name, opts)) + ".gpg"
+	out, err := gitOutput(ctx, []string{"log", "-1", "--format=" + commitInfoFormat, "--", p}, opts)
+	if err != nil {
+		return CommitInfo{}, err
+	}
+	line := strings.TrimSuffix(string(out), "\n")
+	if line == "" {
+		return CommitInfo{}, errors.New("name has no commits")
+	}
+	return parseCommitInfo(line)
+}
+
+// LastModifiedAll returns the last commit that changed each of the entries
+// in the subfolder, keyed by name, using a single pass over the history.
+// Entries that were never committed are not included.
+func LastModifiedAll(ctx context.Context, subfolder string, options ...Option) (map[string]CommitInfo, error) {
+	opts := resolveOptions(options)
+	names, err := List(ctx, subfolder, opts)
+	if err != nil {
+		return nil, err
+	}
+	pending := make(map[string]bool, len(names))
+	for _, name := range names {
+		pending[filepath.ToSlash(name)+".gpg"] = true
+	}
+
+	// Each commit is a line starting with a NUL, followed by the paths it
+	// changed, newest commit first.
+	args := []string{"-c", "core.quotePath=false", "log", "--format=%x00" + commitInfoFormat, "--name-only", "--no-renames"}
+	if subfolder != "" {
+		args = append(args, "--", filepath.ToSlash(filepath.Clean(subfolder)))
+	}
+	out, err := gitOutput(ctx, args, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(map[string]CommitInfo, len(names))
+	var cur CommitInfo
+	for _, line := range bytes.Split(out, []byte("\n")) {
+		if len(pending) == 0 {
+			break
+		}
+		if bytes.HasPrefix(line, []byte{0}) {
+			if cur, err = parseCommitInfo(string(line[1:])); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		p := string(line)
+		if pending[p] {
+			ret[filepath.FromSlash(strings.TrimSuffix(p, ".gpg"))] = cur
+			delete(pending, p)
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,43 @@
+package pass
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLastModified(t *testing.T) {
+	defer fakeCommand("pass", `shift; exec git -C "$PASSWORD_STORE_DIR" "$@"`)()
+
+	opts := &Options{StoreDir: makeTestTree([]string{"a.gpg", "x/b.gpg", "x/caf\u00e9.gpg"})}
+	defer os.RemoveAll(opts.StoreDir)
+	ctx := context.Background()
+	commit := func(author, msg string) {
+		opts.GitAuthor = author
+		Ok(t, runGit(ctx, []string{"add", "--all"}, opts))
+		Ok(t, runGit(ctx, []string{"commit", "--quiet", "-m", msg}, opts))
+	}
+	Ok(t, runGit(ctx, []string{"init", "--quiet"}, opts))
+	commit("Alice <alice@example.com>", "init")
+	writeTestFile(t, opts.StoreDir, "x/b.gpg", "changed")
+	commit("Bob <bob@example.com>", "change b")
+	writeTestFile(t, opts.StoreDir, "x/new.gpg", "")
+
+	info, err := LastModified(ctx, "a", opts)
+	Ok(t, err)
+	Equal(t, "Alice alice@example.com init", fmt.Sprint(info.AuthorName, " ", info.AuthorEmail, " ", info.Subject))
+	info, err = LastModified(ctx, filepath.Join("x", "b"), opts)
+	Ok(t, err)
+	Equal(t, "Bob change b", fmt.Sprint(info.AuthorName, " ", info.Subject))
+	if _, err := LastModified(ctx, filepath.Join("x", "new"), opts); err == nil {
+		t.Errorf("expected error for uncommitted entry")
+	}
+
+	all, err := LastModifiedAll(ctx, "x", opts)
+	Ok(t, err)
+	Equal(t, "2", fmt.Sprint(len(all)))
+	Equal(t, "Bob", all[filepath.Join("x", "b")].AuthorName)
+	Equal(t, "Alice", all[filepath.Join("x", "caf\u00e9")].AuthorName)
+}
@@ -0,0 +1,164 @@
+package pass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache keeps the decrypted content of entries in memory for a while, so
+// that showing them again does not run gpg. Set it in Options.Cache. A
+// cached entry is used only while its password file is unchanged, and is
+// dropped when it is changed using the package. A Cache is safe for
+// concurrent use.
+//
+// The cached content is kept in memory unencrypted until it expires and
+// is removed by a later call, or until Clear is called.
+type Cache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry // password file path -> entry
+	now     func() time.Time
+}
+
+type cacheEntry struct {
+	content Secret
+	modTime time.Time
+	size    int64
+	expires time.Time
+}
+
+// NewCache returns a Cache that keeps content for ttl after it was
+// decrypted.
+func NewCache(ttl time.Duration) *Cache {
+	return &Cache{
+		ttl:     ttl,
+		entries: make(map[string]cacheEntry),
+		now:     time.Now,
+	}
+}
+
+// Clear removes all content from the cache, overwriting it in memory.
+func (c *Cache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for p, e := range c.entries {
+		wipe(e.content)
+		delete(c.entries, p)
+	}
+}
+
+// get returns a copy of the content of the password file at p, if it is
+// cached and the file, as described by info, has not changed.
+func (c *Cache) get(p string, info os.FileInfo) (Secret, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.expireLocked()
+	e, ok := c.entries[p]
+	if !ok || !e.modTime.Equal(info.ModTime()) || e.size != info.Size() {
+		return nil, false
+	}
+	return append(Secret(nil), e.content...), true
+}
+
+func (c *Cache) put(p string, info os.FileInfo, content Secret) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if old, ok := c.entries[p]; ok {
+		wipe(old.content)
+	}
+	c.entries[p] = cacheEntry{
+		content: append(Secret(nil), content...),
+		modTime: info.ModTime(),
+		size:    info.Size(),
+		expires: c.now().Add(c.ttl),
+	}
+}
+
+// invalidate removes the content of the password files at or under the
+// paths.
+func (c *Cache) invalidate(paths []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for p, e := range c.entries {
+		for _, prefix := range paths {
+			if p == prefix+".gpg" || p == prefix || strings.HasPrefix(p, prefix+string(filepath.Separator)) {
+				wipe(e.content)
+				delete(c.entries, p)
+				break
+			}
+		}
+	}
+}
+
+func (c *Cache) expireLocked() {
+	now := c.now()
+	for p, e := range c.entries {
+		if !now.Before(e.expires) {
+			wipe(e.content)
+			delete(c.entries, p)
+		}
+	}
+}
+
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// invalidateCache drops the cached content of the named entries, or of
+// the whole store if names is nil, after they were changed.
+func invalidateCache(names []string, opts *Options) {
+	if opts == nil || opts.Cache == nil {
+		return
+	}
+	storeDir := resolveStoreDir(opts)
+	if names == nil {
+		opts.Cache.invalidate([]string{filepath.Clean(storeDir)})
+		return
+	}
+	paths := make([]string, len(names))
+	for i, name := range names {
+		paths[i] = filepath.Join(storeDir, name)
+	}
+	opts.Cache.invalidate(paths)
+}
+
+// ErrNoCache is returned by Prefetch when Options.Cache is not set.
+var ErrNoCache = errors.New("no cache")
+
+// Prefetch decrypts the named entries into Options.Cache in the
+// background, at Bulk priority, so that showing them soon after is fast.
+// It returns once the entries are queued; the entries that cannot be
+// decrypted are skipped. The decryption stops when ctx is done.
+func Prefetch(ctx context.Context, names []string, gpgPassphrase string, options ...Option) error {
+	opts := resolveOptions(options)
+	if opts.Cache == nil {
+		return ErrNoCache
+	}
+	if err := checkACL(names, Read, opts); err != nil {
+		return err
+	}
+	if err := audit(ctx, "prefetch", names, nil, opts); err != nil {
+		return fmt.Errorf("write audit log: %w", err)
+	}
+
+	ctx = withDefaultPriority(ctx, Bulk)
+	names = append([]string(nil), names...)
+	go func() {
+		for _, name := range names {
+			if ctx.Err() != nil {
+				return
+			}
+			show(ctx, name, gpgPassphrase, opts)
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,85 @@
+package pass
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	storeDir := makeTestTree([]string{"a.gpg", "b.gpg"})
+	defer os.RemoveAll(storeDir)
+
+	var mu sync.Mutex
+	shows := 0
+	runner := RunnerFunc(func(cmd *exec.Cmd) error {
+		if cmd.Args[1] == "show" {
+			mu.Lock()
+			shows++
+			mu.Unlock()
+			io.WriteString(cmd.Stdout, "secret "+cmd.Args[len(cmd.Args)-1])
+		}
+		return nil
+	})
+	count := func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		return fmt.Sprint(shows)
+	}
+
+	cache := NewCache(time.Minute)
+	opts := &Options{StoreDir: storeDir, Runner: runner, Cache: cache}
+	ctx := context.Background()
+	show := func(name string) {
+		t.Helper()
+		got, err := Show(ctx, name, "", opts)
+		Ok(t, err)
+		Equal(t, "secret "+name, string(got))
+	}
+
+	show("a")
+	show("a")
+	Equal(t, "1", count())
+
+	// Changed using the package.
+	Ok(t, Insert(ctx, "a", []byte("x"), true, opts))
+	show("a")
+	Equal(t, "2", count())
+
+	// Changed by others.
+	future := time.Now().Add(time.Hour)
+	Ok(t, os.Chtimes(filepath.Join(storeDir, "a.gpg"), future, future))
+	show("a")
+	Equal(t, "3", count())
+
+	// Expired.
+	cache.now = func() time.Time { return future.Add(time.Minute) }
+	show("a")
+	Equal(t, "4", count())
+	cache.now = time.Now
+	cache.Clear()
+
+	Ok(t, Prefetch(ctx, []string{"a", "b"}, "", opts))
+	for {
+		cache.mu.Lock()
+		n := len(cache.entries)
+		cache.mu.Unlock()
+		if n == 2 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	show("a")
+	show("b")
+	Equal(t, "6", count())
+
+	if err := Prefetch(ctx, []string{"a"}, "", WithStoreDir(storeDir)); err != ErrNoCache {
+		t.Errorf("expected: %s, got: %v", ErrNoCache, err)
+	}
+}
@@ -0,0 +1,78 @@
+package pass
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ChangeType is the type of a Change.
+type ChangeType int
+
+const (
+	Added ChangeType = iota + 1
+	Modified
+	Removed
+)
+
+func (t ChangeType) String() string {
+	switch t {
+	case Added:
+		return "added"
+	case Modified:
+		return "modified"
+	case Removed:
+		return "removed"
+	}
+	return fmt.Sprintf("ChangeType(%d)", int(t))
+}
+
+// Change is a change to an entry. Renamed entries are reported as removed
+// under the old name and added under the new one.
+type Change struct {
+	Name string     `json:"name"`
+	Type ChangeType `json:"type"`
+}
+
+// ChangesSince returns the changes to entries between the git revision,
+// such as a commit hash returned by Head at an earlier time, and the
+// current HEAD, in the order of the names. Entries in the trash are not
+// included.
+func ChangesSince(ctx context.Context, revision string, options ...Option) ([]Change, error) {
+	opts := resolveOptions(options)
+	args := []string{"diff", "--name-status", "-z", "--no-renames", revision, "HEAD", "--"}
+	out, err := gitOutput(ctx, args, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// The output is a sequence of status and path pairs.
+	fields := bytes.Split(bytes.TrimSuffix(out, []byte{0}), []byte{0})
+	var ret []Change
+	for i := 0; i+1 < len(fields); i += 2 {
+		status, p := string(fields[i]), string(fields[i+1])
+		if !strings.HasSuffix(p, ".gpg") || strings.HasPrefix(p, trashDir+"/") {
+			continue
+		}
+		c := Change{Name: filepath.FromSlash(strings.TrimSuffix(p, ".gpg"))}
+		switch status {
+		case "A":
+			c.Type = Added
+		case "D":
+			c.Type = Removed
+		default: // M, or T for a type change
+			c.Type = Modified
+		}
+		ret = append(ret, c)
+	}
+	return ret, nil
+}
+
+// Head returns the commit hash of the HEAD of the git repository of the
+// store, for use with ChangesSince.
+func Head(ctx context.Context, options ...Option) (string, error) {
+	opts := resolveOptions(options)
+	return gitHead(ctx, opts)
+}
@@ -0,0 +1,46 @@
+package pass
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestChangesSince(t *testing.T) {
+	defer fakeCommand("pass", `shift; exec git -C "$PASSWORD_STORE_DIR" "$@"`)()
+
+	opts := &Options{
+		StoreDir:  makeTestTree([]string{"a.gpg", "b.gpg", "c.gpg", "notes.txt"}),
+		GitAuthor: "test <test@example.com>",
+	}
+	defer os.RemoveAll(opts.StoreDir)
+	ctx := context.Background()
+	commit := func(msg string) {
+		Ok(t, runGit(ctx, []string{"add", "--all"}, opts))
+		Ok(t, runGit(ctx, []string{"commit", "--quiet", "-m", msg}, opts))
+	}
+	Ok(t, runGit(ctx, []string{"init", "--quiet"}, opts))
+	commit("init")
+
+	rev, err := Head(ctx, opts)
+	Ok(t, err)
+
+	writeTestFile(t, opts.StoreDir, "a.gpg", "changed")
+	writeTestFile(t, opts.StoreDir, "x/new.gpg", "")
+	writeTestFile(t, opts.StoreDir, "notes.txt", "changed")
+	Ok(t, os.Remove(filepath.Join(opts.StoreDir, "b.gpg")))
+	Ok(t, os.Rename(filepath.Join(opts.StoreDir, "c.gpg"), filepath.Join(opts.StoreDir, "d.gpg")))
+	commit("change")
+
+	changes, err := ChangesSince(ctx, rev, opts)
+	Ok(t, err)
+	Equal(t, fmt.Sprint([]Change{
+		{"a", Modified},
+		{"b", Removed},
+		{"c", Removed},
+		{"d", Added},
+		{filepath.Join("x", "new"), Added},
+	}), fmt.Sprint(changes))
+}
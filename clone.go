@@ -0,0 +1,56 @@
+package pass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ErrNotStore is returned by Clone when the repository is not a password
+// store.
+var ErrNotStore = errors.New("not a password store")
+
+// Clone clones the git repository of a password store at gitURL into
+// storeDir, which must not exist or be empty, and returns a Store for it
+// that uses the options, for setting up a store on a new machine. If the
+// repository has no .gpg-id file in its root, the clone is removed and
+// the error wraps ErrNotStore.
+func Clone(ctx context.Context, gitURL, storeDir string, options ...Option) (*Store, error) {
+	options = append(options[:len(options):len(options)], WithStoreDir(storeDir))
+	opts := resolveOptions(options)
+	err := checkACL([]string{""}, Write, opts)
+	if err == nil {
+		err = clone(ctx, gitURL, storeDir, opts)
+	}
+	if aErr := audit(ctx, "clone", nil, err, opts); aErr != nil && err == nil {
+		return nil, fmt.Errorf("write audit log: %w", aErr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return NewStore(options...), nil
+}
+
+func clone(ctx context.Context, gitURL, storeDir string, opts *Options) error {
+	_, statErr := os.Stat(storeDir)
+	existed := statErr == nil
+	args := []string{"clone", "--", gitURL, storeDir}
+	if _, _, err := runCommand(ctx, "git", "git", args, baseEnv(opts), nil, nil, opts); err != nil {
+		return fmt.Errorf("exec git: %w", err)
+	}
+	if _, err := os.Stat(filepath.Join(storeDir, ".gpg-id")); err == nil {
+		return nil
+	}
+
+	if err := os.RemoveAll(storeDir); err != nil {
+		return err
+	}
+	if existed {
+		if err := os.Mkdir(storeDir, 0700); err != nil {
+			return err
+		}
+	}
+	return fmt.Errorf("%w: no .gpg-id file in %s", ErrNotStore, gitURL)
+}
@@ -0,0 +1,49 @@
+package pass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	dir := makeTestTree(nil)
+	defer os.RemoveAll(dir)
+	env := []string{
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+	}
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Env = append(os.Environ(), env...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %s: %s", args, err, out)
+		}
+	}
+	origin := filepath.Join(dir, "origin")
+	writeTestFile(t, origin, "a.gpg", "a")
+	git("-C", origin, "init", "-q")
+	git("-C", origin, "add", "-A")
+	git("-C", origin, "commit", "-q", "-m", "initial")
+	ctx := context.Background()
+
+	storeDir := filepath.Join(dir, "store")
+	_, err := Clone(ctx, origin, storeDir, &Options{Env: env})
+	Equal(t, "true", fmt.Sprint(errors.Is(err, ErrNotStore)))
+	_, err = os.Stat(storeDir)
+	Equal(t, "true", fmt.Sprint(os.IsNotExist(err)))
+
+	writeTestFile(t, origin, ".gpg-id", "alice@example.com\n")
+	git("-C", origin, "add", "-A")
+	git("-C", origin, "commit", "-q", "-m", "add .gpg-id")
+	s, err := Clone(ctx, origin, storeDir, &Options{Env: env})
+	Ok(t, err)
+	Equal(t, storeDir, s.Dir())
+	_, err = os.Stat(filepath.Join(storeDir, "a.gpg"))
+	Ok(t, err)
+}
@@ -0,0 +1,230 @@
+// Command gopass-lite is a password manager with the commands and output of
+// pass, built on the go-pass package. It uses the store in
+// $PASSWORD_STORE_DIR, or ~/.password-store, and the configuration read by
+// pass.LoadDefaultOptions.
+//
+// Usage:
+//
+//	gopass-lite [ls] [subfolder]
+//	gopass-lite init [-p subfolder] gpg-id
+//	gopass-lite show name
+//	gopass-lite insert [-m] [-f] name
+//	gopass-lite rm [-r] [-f] name
+//	gopass-lite mv [-f] old-path new-path
+//	gopass-lite cp [-f] old-path new-path
+//	gopass-lite grep pattern
+//	gopass-lite otp name
+//	gopass-lite git git-args...
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	pass "github.com/littleroot/go-pass"
+)
+
+const usage = `usage:
+  gopass-lite [ls] [subfolder]
+  gopass-lite init [-p subfolder] gpg-id
+  gopass-lite show name
+  gopass-lite insert [-m] [-f] name
+  gopass-lite rm [-r] [-f] name
+  gopass-lite mv [-f] old-path new-path
+  gopass-lite cp [-f] old-path new-path
+  gopass-lite grep pattern
+  gopass-lite otp name
+  gopass-lite git git-args...
+`
+
+var errUsage = errors.New("usage error")
+
+func main() {
+	opts, err := pass.LoadDefaultOptions()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "gopass-lite: %s\n", err)
+		os.Exit(1)
+	}
+	if err := run(context.Background(), os.Args[1:], os.Stdin, os.Stdout, opts); err != nil {
+		if errors.Is(err, errUsage) {
+			fmt.Fprint(os.Stderr, usage)
+			os.Exit(2)
+		}
+		fmt.Fprintf(os.Stderr, "gopass-lite: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout io.Writer, options ...pass.Option) error {
+	if len(args) == 0 {
+		return list(ctx, "", stdout, options)
+	}
+	cmd, args := args[0], args[1:]
+	fs := flag.NewFlagSet(cmd, flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	force := fs.Bool("f", false, "")
+	recursive := fs.Bool("r", false, "")
+	multiline := fs.Bool("m", false, "")
+	subfolder := fs.String("p", "", "")
+	if cmd != "git" {
+		if err := fs.Parse(args); err != nil {
+			return errUsage
+		}
+		args = fs.Args()
+	}
+
+	switch cmd {
+	case "ls", "list":
+		if len(args) > 1 {
+			return errUsage
+		}
+		return list(ctx, strings.Join(args, ""), stdout, options)
+	case "init":
+		if len(args) != 1 {
+			return errUsage
+		}
+		return pass.Init(ctx, args[0], *subfolder, options...)
+	case "show":
+		if len(args) != 1 {
+			return errUsage
+		}
+		content, err := pass.Show(ctx, args[0], "", options...)
+		if err != nil {
+			return err
+		}
+		_, err = stdout.Write(content)
+		return err
+	case "insert", "add":
+		if len(args) != 1 {
+			return errUsage
+		}
+		content, err := readContent(stdin, *multiline)
+		if err != nil {
+			return err
+		}
+		return pass.Insert(ctx, args[0], content, *force, options...)
+	case "rm", "remove", "delete":
+		if len(args) != 1 {
+			return errUsage
+		}
+		return pass.Remove(ctx, args[0], *recursive, *force, options...)
+	case "mv", "rename":
+		if len(args) != 2 {
+			return errUsage
+		}
+		return pass.Move(ctx, args[0], args[1], *force, options...)
+	case "cp", "copy":
+		if len(args) != 2 {
+			return errUsage
+		}
+		return pass.Copy(ctx, args[0], args[1], *force, options...)
+	case "grep":
+		if len(args) != 1 {
+			return errUsage
+		}
+		return grep(ctx, args[0], stdout, options)
+	case "otp":
+		if len(args) != 1 {
+			return errUsage
+		}
+		code, err := pass.OTP(ctx, args[0], "", options...)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(stdout, code.Value)
+		return err
+	case "git":
+		return pass.Git(ctx, args, options...)
+	case "help", "-h", "--help":
+		_, err := fmt.Fprint(stdout, usage)
+		return err
+	}
+	// Like pass, an unknown command is the name of an entry to show.
+	return run(ctx, append([]string{"show", cmd}, args...), stdin, stdout, options...)
+}
+
+// readContent reads the content of an entry to insert: the first line, or
+// everything until EOF if multiline is set.
+func readContent(r io.Reader, multiline bool) ([]byte, error) {
+	if multiline {
+		return ioutil.ReadAll(r)
+	}
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("empty password")
+	}
+	return []byte(line + "\n"), nil
+}
+
+// list prints the entries in subfolder as a tree, like pass ls.
+func list(ctx context.Context, subfolder string, w io.Writer, options []pass.Option) error {
+	names, err := pass.List(ctx, subfolder, options...)
+	if err != nil {
+		return err
+	}
+	root := "Password Store"
+	if subfolder != "" {
+		root = strings.Trim(subfolder, "/")
+	}
+	fmt.Fprintln(w, root)
+	printTree(w, pass.NewTree(names), "")
+	return nil
+}
+
+func printTree(w io.Writer, t *pass.Tree, indent string) {
+	type item struct {
+		name   string
+		folder *pass.Tree
+	}
+	var items []item
+	for _, f := range t.Folders {
+		items = append(items, item{f.Name, f})
+	}
+	for _, e := range t.Entries {
+		items = append(items, item{name: e})
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].name < items[j].name })
+	for i, it := range items {
+		branch, next := "├── ", "│   "
+		if i == len(items)-1 {
+			branch, next = "└── ", "    "
+		}
+		fmt.Fprintln(w, indent+branch+it.name)
+		if it.folder != nil {
+			printTree(w, it.folder, indent+next)
+		}
+	}
+}
+
+func grep(ctx context.Context, pattern string, w io.Writer, options []pass.Option) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+	matches, err := pass.SearchContent(ctx, re, "", "", options...)
+	if err != nil {
+		return err
+	}
+	last := ""
+	for _, m := range matches {
+		if m.Name != last {
+			fmt.Fprintf(w, "%s:\n", m.Name)
+			last = m.Name
+		}
+		fmt.Fprintln(w, string(m.Text))
+	}
+	return nil
+}
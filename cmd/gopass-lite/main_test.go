@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	pass "github.com/littleroot/go-pass"
+)
+
+func TestList(t *testing.T) {
+	storeDir, err := ioutil.TempDir("", "gopass-lite-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(storeDir)
+	for _, name := range []string{".gpg-id", "email.gpg", "web/github.gpg", "web/aws/root.gpg", "zzz.gpg"} {
+		p := filepath.Join(storeDir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := run(context.Background(), []string{"ls"}, nil, &out, pass.WithStoreDir(storeDir)); err != nil {
+		t.Fatal(err)
+	}
+	expected := `Password Store
+├── email
+├── web
+│   ├── aws
+│   │   └── root
+│   └── github
+└── zzz
+`
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
@@ -0,0 +1,453 @@
+package pass
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Codec converts between the content of entries and Go values, for Get and
+// Set. Set Options.Codec to choose the format of entries.
+type Codec interface {
+	Decode(content []byte, v interface{}) error
+	Encode(v interface{}) ([]byte, error)
+}
+
+// The codecs provided by the package.
+//
+// LineCodec, DotenvCodec, and YAMLCodec work with values of type
+// map[string]string, and structs whose string fields are mapped to keys by
+// their `pass` tag, or their name if there is no tag. Keys are matched
+// ignoring case when decoding.
+var (
+	// LineCodec is the usual format of pass entries: the password on
+	// the first line, which has the key "password", followed by
+	// "key: value" lines. It is the default.
+	LineCodec Codec = lineCodec{}
+
+	// JSONCodec stores the value as JSON, using encoding/json.
+	JSONCodec Codec = jsonCodec{}
+
+	// DotenvCodec stores the value as KEY=value lines, as read by
+	// docker compose and dotenv libraries.
+	DotenvCodec Codec = dotenvCodec{}
+
+	// YAMLCodec stores the value as a YAML mapping of keys to strings.
+	// Only flat mappings are supported: nested mappings, sequences,
+	// block scalars, anchors, and documents with several mappings are
+	// rejected when decoding.
+	YAMLCodec Codec = yamlCodec{}
+)
+
+// passwordKey is the key of the first line in LineCodec.
+const passwordKey = "password"
+
+// Get decodes the content of the named entry into v, using
+// Options.Codec. The content is decrypted as in Show.
+func Get(ctx context.Context, name, gpgPassphrase string, v interface{}, options ...Option) error {
+	opts := resolveOptions(options)
+	content, err := Show(ctx, name, gpgPassphrase, opts)
+	if err != nil {
+		return err
+	}
+	if err := codec(opts).Decode(content, v); err != nil {
+		return fmt.Errorf("decode: %w", err)
+	}
+	return nil
+}
+
+// Set encodes v using Options.Codec and inserts it as the content of the
+// named entry, as in Insert.
+func Set(ctx context.Context, name string, v interface{}, force bool, options ...Option) error {
+	opts := resolveOptions(options)
+	content, err := codec(opts).Encode(v)
+	if err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+	return Insert(ctx, name, content, force, opts)
+}
+
+func codec(opts *Options) Codec {
+	if opts != nil && opts.Codec != nil {
+		return opts.Codec
+	}
+	return LineCodec
+}
+
+type lineCodec struct{}
+
+func (lineCodec) Decode(content []byte, v interface{}) error {
+	var keys, values []string
+	sc := bufio.NewScanner(bytes.NewReader(content))
+	for n := 0; sc.Scan(); n++ {
+		line := strings.TrimSuffix(sc.Text(), "\r")
+		if n == 0 {
+			keys, values = append(keys, passwordKey), append(values, line)
+			continue
+		}
+		i := strings.IndexByte(line, ':')
+		if i == -1 {
+			continue
+		}
+		keys = append(keys, strings.TrimSpace(line[:i]))
+		values = append(values, strings.TrimSpace(line[i+1:]))
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	return setFields(v, keys, values)
+}
+
+func (lineCodec) Encode(v interface{}) ([]byte, error) {
+	keys, values, err := getFields(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var password string
+	var buf bytes.Buffer
+	for i, k := range keys {
+		if strings.EqualFold(k, passwordKey) {
+			password = values[i]
+			continue
+		}
+		if strings.ContainsAny(values[i], "\n") {
+			return nil, fmt.Errorf("value of %s contains newline", k)
+		}
+		fmt.Fprintf(&buf, "%s: %s\n", k, values[i])
+	}
+	if strings.ContainsAny(password, "\n") {
+		return nil, errors.New("password contains newline")
+	}
+	return append([]byte(password+"\n"), buf.Bytes()...), nil
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Decode(content []byte, v interface{}) error {
+	return json.Unmarshal(content, v)
+}
+
+func (jsonCodec) Encode(v interface{}) ([]byte, error) {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+type dotenvCodec struct{}
+
+func (dotenvCodec) Decode(content []byte, v interface{}) error {
+	var keys, values []string
+	sc := bufio.NewScanner(bytes.NewReader(content))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		i := strings.IndexByte(line, '=')
+		if i == -1 {
+			return fmt.Errorf("line %d: missing =", n)
+		}
+		value, err := dotenvUnquote(strings.TrimSpace(line[i+1:]))
+		if err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+		keys = append(keys, strings.TrimSpace(line[:i]))
+		values = append(values, value)
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	return setFields(v, keys, values)
+}
+
+func (dotenvCodec) Encode(v interface{}) ([]byte, error) {
+	keys, values, err := getFields(v)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	for i, k := range keys {
+		fmt.Fprintf(&buf, "%s=%s\n", k, dotenvQuote(values[i]))
+	}
+	return buf.Bytes(), nil
+}
+
+// dotenvQuote returns value quoted for a dotenv file, if needed.
+func dotenvQuote(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t\r\n\"'\\#$`=") {
+		return value
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "$", `\$`, "`", "\\`")
+	return `"` + r.Replace(value) + `"`
+}
+
+// dotenvUnquote returns the value of a dotenv line with the quotes, if
+// any, removed.
+func dotenvUnquote(s string) (string, error) {
+	switch {
+	case strings.HasPrefix(s, `"`):
+		if len(s) < 2 || !strings.HasSuffix(s, `"`) {
+			return "", errors.New("unterminated quoted value")
+		}
+		var b strings.Builder
+		s = s[1 : len(s)-1]
+		for i := 0; i < len(s); i++ {
+			if s[i] != '\\' || i == len(s)-1 {
+				b.WriteByte(s[i])
+				continue
+			}
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(s[i])
+			}
+		}
+		return b.String(), nil
+	case strings.HasPrefix(s, "'"):
+		if len(s) < 2 || !strings.HasSuffix(s, "'") {
+			return "", errors.New("unterminated quoted value")
+		}
+		return s[1 : len(s)-1], nil
+	}
+	// An unquoted value ends at a comment.
+	if i := strings.Index(s, " #"); i != -1 {
+		s = strings.TrimSpace(s[:i])
+	}
+	return s, nil
+}
+
+type yamlCodec struct{}
+
+func (yamlCodec) Decode(content []byte, v interface{}) error {
+	var keys, values []string
+	sc := bufio.NewScanner(bytes.NewReader(content))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimRight(sc.Text(), " \t\r")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || (n == 1 && line == "---") {
+			continue
+		}
+		if line != strings.TrimLeft(line, " \t") {
+			return fmt.Errorf("line %d: nested values are not supported", n)
+		}
+		key, rest, err := yamlScalar(line, true)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+		if !strings.HasPrefix(rest, ":") {
+			return fmt.Errorf("line %d: missing :", n)
+		}
+		value, rest, err := yamlScalar(strings.TrimSpace(rest[1:]), false)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+		if rest = strings.TrimSpace(rest); rest != "" && !strings.HasPrefix(rest, "#") {
+			return fmt.Errorf("line %d: unexpected %q after value", n, rest)
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	return setFields(v, keys, values)
+}
+
+// yamlScalar parses the quoted or plain scalar at the start of s, and
+// returns it and the rest of s. A plain key ends at ": " or a final ":";
+// a plain value ends at " #".
+func yamlScalar(s string, isKey bool) (string, string, error) {
+	switch {
+	case strings.HasPrefix(s, `"`):
+		for i := 1; i < len(s); i++ {
+			switch s[i] {
+			case '\\':
+				i++
+			case '"':
+				v, err := strconv.Unquote(s[:i+1])
+				if err != nil {
+					return "", "", fmt.Errorf("bad quoted string %s", s[:i+1])
+				}
+				return v, s[i+1:], nil
+			}
+		}
+		return "", "", errors.New("unterminated quoted string")
+	case strings.HasPrefix(s, "'"):
+		var b strings.Builder
+		for i := 1; i < len(s); i++ {
+			if s[i] != '\'' {
+				b.WriteByte(s[i])
+				continue
+			}
+			if i+1 < len(s) && s[i+1] == '\'' {
+				b.WriteByte('\'')
+				i++
+				continue
+			}
+			return b.String(), s[i+1:], nil
+		}
+		return "", "", errors.New("unterminated quoted string")
+	}
+	if s != "" && strings.ContainsRune("[]{}&*!|>%@`", rune(s[0])) {
+		return "", "", fmt.Errorf("unsupported value %q", s)
+	}
+	if isKey {
+		i := strings.Index(s, ": ")
+		if i == -1 && strings.HasSuffix(s, ":") {
+			i = len(s) - 1
+		}
+		if i == -1 {
+			return "", "", errors.New("missing :")
+		}
+		return strings.TrimSpace(s[:i]), s[i:], nil
+	}
+	if i := strings.Index(s, " #"); i != -1 {
+		return strings.TrimSpace(s[:i]), s[i:], nil
+	}
+	return s, "", nil
+}
+
+func (yamlCodec) Encode(v interface{}) ([]byte, error) {
+	keys, values, err := getFields(v)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	for i, k := range keys {
+		fmt.Fprintf(&buf, "%s: %s\n", yamlQuote(k), yamlQuote(values[i]))
+	}
+	return buf.Bytes(), nil
+}
+
+// yamlQuote returns s as a YAML scalar, double quoted if it would not be
+// read back as the same string otherwise, such as "true" or "1.0".
+func yamlQuote(s string) string {
+	plain := s != "" &&
+		s == strings.TrimSpace(s) &&
+		!strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@`") &&
+		!strings.Contains(s, ": ") && !strings.Contains(s, " #") && !strings.HasSuffix(s, ":")
+	for _, r := range s {
+		if r < ' ' || r == 0x7f {
+			plain = false
+		}
+	}
+	switch strings.ToLower(s) {
+	case "~", "null", "true", "false", "yes", "no", "on", "off", "y", "n", ".inf", "-.inf", ".nan":
+		plain = false
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		plain = false
+	}
+	if _, err := strconv.ParseInt(s, 0, 64); err == nil {
+		plain = false
+	}
+	if plain {
+		return s
+	}
+	return strconv.Quote(s)
+}
+
+// getFields returns the keys and values of v, which is a map[string]string
+// or a struct, or a pointer to one, as described for LineCodec. The keys of
+// maps are sorted.
+func getFields(v interface{}) (keys, values []string, err error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+
+	switch {
+	case rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String && rv.Type().Elem().Kind() == reflect.String:
+		for _, k := range rv.MapKeys() {
+			keys = append(keys, k.String())
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			values = append(values, rv.MapIndex(reflect.ValueOf(k).Convert(rv.Type().Key())).String())
+		}
+		return keys, values, nil
+	case rv.Kind() == reflect.Struct:
+		t := rv.Type()
+		for i := 0; i < t.NumField(); i++ {
+			key, ok := fieldKey(t.Field(i))
+			if !ok {
+				continue
+			}
+			keys = append(keys, key)
+			values = append(values, rv.Field(i).String())
+		}
+		return keys, values, nil
+	}
+	return nil, nil, fmt.Errorf("unsupported type %T", v)
+}
+
+// setFields sets the keys of v, which is a pointer to a map[string]string
+// or to a struct, to the values. Keys that v has no field for are ignored
+// for structs.
+func setFields(v interface{}, keys, values []string) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("unsupported type %T", v)
+	}
+	rv = rv.Elem()
+
+	switch {
+	case rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String && rv.Type().Elem().Kind() == reflect.String:
+		if rv.IsNil() {
+			rv.Set(reflect.MakeMap(rv.Type()))
+		}
+		for i, k := range keys {
+			rv.SetMapIndex(reflect.ValueOf(k).Convert(rv.Type().Key()), reflect.ValueOf(values[i]).Convert(rv.Type().Elem()))
+		}
+		return nil
+	case rv.Kind() == reflect.Struct:
+		t := rv.Type()
+		for i := 0; i < t.NumField(); i++ {
+			key, ok := fieldKey(t.Field(i))
+			if !ok {
+				continue
+			}
+			for j, k := range keys {
+				if strings.EqualFold(k, key) {
+					rv.Field(i).SetString(values[j])
+					break
+				}
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported type %T", v)
+}
+
+// fieldKey returns the key of the struct field, and whether the field is
+// used by the codecs.
+func fieldKey(f reflect.StructField) (string, bool) {
+	if f.PkgPath != "" || f.Type.Kind() != reflect.String {
+		return "", false // unexported or not a string
+	}
+	tag := f.Tag.Get("pass")
+	if tag == "-" {
+		return "", false
+	}
+	if tag != "" {
+		return tag, true
+	}
+	return f.Name, true
+}
@@ -0,0 +1,133 @@
+package pass
+
+import (
+	"fmt"
+	"testing"
+)
+
+type testCredentials struct {
+	Password string
+	User     string `pass:"username"`
+	URL      string
+	Ignored  string `pass:"-"`
+	Count    int
+}
+
+func TestLineCodec(t *testing.T) {
+	var c testCredentials
+	err := LineCodec.Decode([]byte("hunter2\nUsername: alice\nurl: https://example.com\nignored: x\n"), &c)
+	Ok(t, err)
+	Equal(t, "hunter2", c.Password)
+	Equal(t, "alice", c.User)
+	Equal(t, "https://example.com", c.URL)
+	Equal(t, "", c.Ignored)
+
+	b, err := LineCodec.Encode(c)
+	Ok(t, err)
+	Equal(t, "hunter2\nusername: alice\nURL: https://example.com\n", string(b))
+
+	var m map[string]string
+	err = LineCodec.Decode(b, &m)
+	Ok(t, err)
+	Equal(t, "map[URL:https://example.com password:hunter2 username:alice]", fmt.Sprint(m))
+
+	b, err = LineCodec.Encode(m)
+	Ok(t, err)
+	Equal(t, "hunter2\nURL: https://example.com\nusername: alice\n", string(b))
+
+	_, err = LineCodec.Encode(map[string]string{"note": "a\nb"})
+	if err == nil {
+		t.Errorf("expected error for newline in value")
+	}
+}
+
+func TestDotenvCodec(t *testing.T) {
+	m := map[string]string{
+		"A": "plain",
+		"B": "with space",
+		"C": "multi\nline \"quoted\" $HOME",
+		"D": "",
+	}
+	b, err := DotenvCodec.Encode(m)
+	Ok(t, err)
+	Equal(t, `A=plain
+B="with space"
+C="multi\nline \"quoted\" \$HOME"
+D=""
+`, string(b))
+
+	var got map[string]string
+	err = DotenvCodec.Decode(append([]byte("# comment\nexport E='single $x'\nF=value # comment\n"), b...), &got)
+	Ok(t, err)
+	Equal(t, fmt.Sprint(map[string]string{
+		"A": "plain",
+		"B": "with space",
+		"C": "multi\nline \"quoted\" $HOME",
+		"D": "",
+		"E": "single $x",
+		"F": "value",
+	}), fmt.Sprint(got))
+}
+
+func TestJSONCodec(t *testing.T) {
+	b, err := JSONCodec.Encode(map[string]string{"password": "hunter2"})
+	Ok(t, err)
+	Equal(t, "{\n  \"password\": \"hunter2\"\n}\n", string(b))
+
+	var c testCredentials
+	err = JSONCodec.Decode(b, &c)
+	Ok(t, err)
+	Equal(t, "hunter2", c.Password)
+}
+
+func TestYAMLCodec(t *testing.T) {
+	m := map[string]string{
+		"a": "plain value",
+		"b": "true",
+		"c": "multi\nline \"quoted\"",
+		"d": "",
+		"e": "1.5",
+		"f": "key: value",
+	}
+	b, err := YAMLCodec.Encode(m)
+	Ok(t, err)
+	Equal(t, `a: plain value
+b: "true"
+c: "multi\nline \"quoted\""
+d: ""
+e: "1.5"
+f: "key: value"
+`, string(b))
+
+	var got map[string]string
+	err = YAMLCodec.Decode(append([]byte("---\n# comment\ng: 'it''s' # comment\nh:\n"), b...), &got)
+	Ok(t, err)
+	Equal(t, fmt.Sprint(map[string]string{
+		"a": "plain value",
+		"b": "true",
+		"c": "multi\nline \"quoted\"",
+		"d": "",
+		"e": "1.5",
+		"f": "key: value",
+		"g": "it's",
+		"h": "",
+	}), fmt.Sprint(got))
+
+	var c testCredentials
+	err = YAMLCodec.Decode([]byte("password: hunter2\nusername: alice\n"), &c)
+	Ok(t, err)
+	Equal(t, "hunter2", c.Password)
+	Equal(t, "alice", c.User)
+
+	for _, content := range []string{
+		"a:\n  b: c\n",
+		"- a\n",
+		"a: |\n  text\n",
+		"a: [1, 2]\n",
+		"a: \"unterminated\n",
+	} {
+		if err := YAMLCodec.Decode([]byte(content), &got); err == nil {
+			t.Errorf("expected error for %q", content)
+		}
+	}
+}
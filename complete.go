@@ -0,0 +1,58 @@
+package pass
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// CompletionCandidates returns the completions of prefix, a partial entry
+// name, for shell completion: the entries and folders in the folder of
+// prefix whose names start with prefix, in lexical order. Folders end in
+// "/". For example, the prefix "web/g" may complete to "web/github" and
+// "web/gitlab/".
+//
+// Only the folder of prefix is read, so the time it takes does not depend
+// on the size of the store. A missing folder has no completions.
+func CompletionCandidates(ctx context.Context, prefix string, options ...Option) ([]string, error) {
+	opts := resolveOptions(options)
+	storeDir := resolveStoreDir(opts)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	prefix = filepath.ToSlash(prefix)
+	dir, base := path.Split(prefix)
+	rel := path.Clean(dir) // "." for the root
+
+	ignore, err := loadIgnoreFile(opts)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(filepath.Join(storeDir, filepath.FromSlash(rel)))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []string
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, base) {
+			continue
+		}
+		switch {
+		case e.IsDir() && (name == ".git" || path.Join(rel, name) == trashDir):
+		case ignore.ignored(path.Join(rel, name), e.IsDir()):
+		case e.IsDir():
+			ret = append(ret, dir+name+"/")
+		case strings.HasSuffix(name, ".gpg"):
+			ret = append(ret, dir+strings.TrimSuffix(name, ".gpg"))
+		}
+	}
+	return ret, nil
+}
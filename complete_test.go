@@ -0,0 +1,40 @@
+package pass
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestCompletionCandidates(t *testing.T) {
+	storeDir := makeTestTree([]string{
+		".gpg-id",
+		".git/config",
+		"email.gpg",
+		"web/github.gpg",
+		"web/gitlab/work.gpg",
+		"web/google.gpg",
+		"web/notes.txt",
+		"work.gpg",
+	})
+	defer os.RemoveAll(storeDir)
+
+	ctx := context.Background()
+	for _, tt := range []struct {
+		prefix   string
+		expected []string
+	}{
+		{"", []string{"email", "web/", "work"}},
+		{"w", []string{"web/", "work"}},
+		{"web/", []string{"web/github", "web/gitlab/", "web/google"}},
+		{"web/git", []string{"web/github", "web/gitlab/"}},
+		{"web/gitlab/", []string{"web/gitlab/work"}},
+		{"x", nil},
+		{"missing/", nil},
+	} {
+		got, err := CompletionCandidates(ctx, tt.prefix, &Options{StoreDir: storeDir})
+		Ok(t, err)
+		Equal(t, fmt.Sprint(tt.expected), fmt.Sprint(got))
+	}
+}
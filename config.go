@@ -0,0 +1,432 @@
+package pass
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultConfigFile returns the path of the configuration file read by
+// LoadDefaultOptions: go-pass/config.toml in the user's configuration
+// directory, such as ~/.config on Linux.
+func DefaultConfigFile() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "go-pass", "config.toml"), nil
+}
+
+// LoadDefaultOptions returns the Options for the user's environment, so
+// that applications using the package share the configuration of the
+// store. The options are read from DefaultConfigFile, if it exists, and
+// the environment variables that pass reads, PASSWORD_STORE_DIR and
+// PASSWORD_STORE_SIGNING_KEY, take precedence over them.
+//
+// The configuration file is in a subset of TOML: keys set to strings,
+// booleans, integers, or arrays of strings. The keys are:
+//
+//	store_dir               = "~/.password-store"
+//	pinentry_mode           = "loopback"
+//	gpg_tty                 = "/dev/pts/0"
+//	no_tty                  = false
+//	non_interactive         = false
+//	ignore_file             = ".go-pass-ignore"
+//	trash                   = false
+//	shred                   = false
+//	git_author              = "Name <email>"
+//	auto_push               = false
+//	read_only               = false
+//	audit_log               = "~/.local/state/go-pass/audit.log"
+//	recent_file             = "~/.cache/go-pass/recent.gpg"
+//	timeout                 = "30s"
+//	env                     = ["KEY=value"]
+//	store_signing_keys      = ["fingerprint"]
+//	require_signed_commits  = false
+//	commit_signing_key      = "fingerprint"
+//	case_insensitive_lookup = false
+//	crypto                  = "gpg" # or "kms", for KMSCrypto
+//
+// Paths starting with "~/" are relative to the home directory. Tables
+// named "profiles.<name>" hold the settings of the profiles loaded by
+// LoadProfile.
+func LoadDefaultOptions() (*Options, error) {
+	return loadOptions("")
+}
+
+// ErrProfileNotFound is returned by LoadProfile and OpenProfile when the
+// configuration file has no such profile.
+var ErrProfileNotFound = errors.New("profile not found")
+
+// LoadProfile returns the Options of the named profile of the
+// configuration file, for users with several stores, such as "work" and
+// "personal":
+//
+//	[profiles.work]
+//	store_dir = "~/.password-store-work"
+//	auto_push = true
+//
+// The settings of the profile take precedence over the ones of
+// LoadDefaultOptions, which it otherwise uses.
+func LoadProfile(name string) (*Options, error) {
+	if name == "" {
+		return nil, fmt.Errorf("%w: empty name", ErrProfileNotFound)
+	}
+	return loadOptions(name)
+}
+
+// OpenProfile returns a Store for the named profile, as loaded by
+// LoadProfile. The options are applied after the ones of the profile.
+func OpenProfile(name string, options ...Option) (*Store, error) {
+	opts, err := LoadProfile(name)
+	if err != nil {
+		return nil, err
+	}
+	return NewStore(append([]Option{opts}, options...)...), nil
+}
+
+// Profiles returns the names of the profiles in the configuration file,
+// sorted.
+func Profiles() ([]string, error) {
+	p, err := DefaultConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := readConfigFile(p)
+	if err != nil {
+		return nil, err
+	}
+	var ret []string
+	for table := range cfg.tables {
+		if name := strings.TrimPrefix(table, profileTablePrefix); name != table {
+			ret = append(ret, name)
+		}
+	}
+	sort.Strings(ret)
+	return ret, nil
+}
+
+const profileTablePrefix = "profiles."
+
+func loadOptions(profile string) (*Options, error) {
+	p, err := DefaultConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := readConfigFile(p)
+	if err != nil {
+		return nil, err
+	}
+	opts := &Options{}
+	if err := cfg.apply(opts, ""); err != nil {
+		return nil, err
+	}
+	applyEnv(opts)
+	if profile != "" {
+		table := profileTablePrefix + profile
+		if _, ok := cfg.tables[table]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrProfileNotFound, profile)
+		}
+		if err := cfg.apply(opts, table); err != nil {
+			return nil, err
+		}
+	}
+	return opts, nil
+}
+
+// applyEnv applies the environment variables read by pass to opts.
+func applyEnv(opts *Options) {
+	if dir := os.Getenv("PASSWORD_STORE_DIR"); dir != "" {
+		opts.StoreDir = dir
+	}
+	if keys := strings.Fields(os.Getenv("PASSWORD_STORE_SIGNING_KEY")); len(keys) > 0 {
+		opts.StoreSigningKeys = keys
+	}
+}
+
+// config is a parsed configuration file. It maps the names of the tables
+// to their keys and values; the keys before the first table are in the
+// table "".
+type config struct {
+	path   string
+	tables map[string]map[string]interface{}
+}
+
+// readConfigFile reads and parses the configuration file at p. A missing
+// file is an empty configuration.
+func readConfigFile(p string) (*config, error) {
+	cfg := &config{path: p, tables: map[string]map[string]interface{}{"": {}}}
+	b, err := ioutil.ReadFile(p)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+	if err := cfg.parse(b); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (c *config) parse(b []byte) error {
+	table := ""
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(stripComment(sc.Text()))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") || strings.HasPrefix(line, "[[") {
+				return c.errorf(n, "bad table header")
+			}
+			table = strings.TrimSpace(line[1 : len(line)-1])
+			if name := strings.TrimPrefix(table, profileTablePrefix); name == table || name == "" {
+				return c.errorf(n, "unknown table %s", table)
+			}
+			if _, ok := c.tables[table]; ok {
+				return c.errorf(n, "duplicate table %s", table)
+			}
+			c.tables[table] = make(map[string]interface{})
+			continue
+		}
+		i := strings.IndexByte(line, '=')
+		if i == -1 {
+			return c.errorf(n, "expected key = value")
+		}
+		key := strings.TrimSpace(line[:i])
+		v, err := parseConfigValue(strings.TrimSpace(line[i+1:]))
+		if err != nil {
+			return c.errorf(n, "%s: %s", key, err)
+		}
+		if _, ok := c.tables[table][key]; ok {
+			return c.errorf(n, "duplicate key %s", key)
+		}
+		c.tables[table][key] = v
+	}
+	return sc.Err()
+}
+
+func (c *config) errorf(line int, format string, args ...interface{}) error {
+	return fmt.Errorf("%s:%d: %s", c.path, line, fmt.Sprintf(format, args...))
+}
+
+// stripComment removes a comment that starts outside of a string.
+func stripComment(line string) string {
+	var quote rune
+	escaped := false
+	for i, r := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && r == '\\':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func parseConfigValue(s string) (interface{}, error) {
+	switch {
+	case s == "true":
+		return true, nil
+	case s == "false":
+		return false, nil
+	case strings.HasPrefix(s, "["):
+		if !strings.HasSuffix(s, "]") {
+			return nil, errors.New("arrays must be on one line")
+		}
+		var ret []string
+		for _, item := range splitConfigArray(s[1 : len(s)-1]) {
+			v, err := parseConfigValue(item)
+			if err != nil {
+				return nil, err
+			}
+			str, ok := v.(string)
+			if !ok {
+				return nil, errors.New("arrays must hold strings")
+			}
+			ret = append(ret, str)
+		}
+		return ret, nil
+	case strings.HasPrefix(s, `"`):
+		return strconv.Unquote(s)
+	case strings.HasPrefix(s, "'"):
+		if len(s) < 2 || !strings.HasSuffix(s, "'") || strings.Contains(s[1:len(s)-1], "'") {
+			return nil, errors.New("bad literal string")
+		}
+		return s[1 : len(s)-1], nil
+	}
+	n, err := strconv.ParseInt(strings.ReplaceAll(s, "_", ""), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("bad value %s", s)
+	}
+	return n, nil
+}
+
+// splitConfigArray splits the items of an array at the commas outside of
+// strings. A trailing comma is allowed.
+func splitConfigArray(s string) []string {
+	var ret []string
+	var quote rune
+	escaped := false
+	start := 0
+	for i, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && r == '\\':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == ',':
+			ret = append(ret, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if last := strings.TrimSpace(s[start:]); last != "" {
+		ret = append(ret, last)
+	}
+	return ret
+}
+
+// apply sets the options in the table to opts.
+func (c *config) apply(opts *Options, table string) error {
+	for key, v := range c.tables[table] {
+		if err := applyConfigValue(opts, key, v); err != nil {
+			if table != "" {
+				key = table + "." + key
+			}
+			return fmt.Errorf("%s: %s: %w", c.path, key, err)
+		}
+	}
+	return nil
+}
+
+func applyConfigValue(opts *Options, key string, v interface{}) error {
+	str := func(p *string, path bool) error {
+		s, ok := v.(string)
+		if !ok {
+			return errors.New("must be a string")
+		}
+		if path {
+			s = expandHome(s)
+		}
+		*p = s
+		return nil
+	}
+	boolean := func(p *bool) error {
+		b, ok := v.(bool)
+		if !ok {
+			return errors.New("must be a boolean")
+		}
+		*p = b
+		return nil
+	}
+	list := func(p *[]string) error {
+		l, ok := v.([]string)
+		if !ok {
+			return errors.New("must be an array of strings")
+		}
+		*p = l
+		return nil
+	}
+
+	switch key {
+	case "store_dir":
+		return str(&opts.StoreDir, true)
+	case "pinentry_mode":
+		return str(&opts.PinentryMode, false)
+	case "gpg_tty":
+		return str(&opts.GPGTTY, false)
+	case "no_tty":
+		return boolean(&opts.NoTTY)
+	case "non_interactive":
+		return boolean(&opts.NonInteractive)
+	case "ignore_file":
+		return str(&opts.IgnoreFile, false)
+	case "trash":
+		return boolean(&opts.Trash)
+	case "shred":
+		return boolean(&opts.Shred)
+	case "git_author":
+		return str(&opts.GitAuthor, false)
+	case "auto_push":
+		return boolean(&opts.AutoPush)
+	case "read_only":
+		return boolean(&opts.ReadOnly)
+	case "audit_log":
+		return str(&opts.AuditLog, true)
+	case "recent_file":
+		return str(&opts.RecentFile, true)
+	case "timeout":
+		var s string
+		if err := str(&s, false); err != nil {
+			return err
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		opts.Timeout = d
+		return nil
+	case "env":
+		return list(&opts.Env)
+	case "store_signing_keys":
+		return list(&opts.StoreSigningKeys)
+	case "require_signed_commits":
+		return boolean(&opts.RequireSignedCommits)
+	case "commit_signing_key":
+		return str(&opts.CommitSigningKey, false)
+	case "case_insensitive_lookup":
+		return boolean(&opts.CaseInsensitiveLookup)
+	case "crypto":
+		var s string
+		if err := str(&s, false); err != nil {
+			return err
+		}
+		switch s {
+		case "gpg":
+			opts.Crypto = nil
+		case "kms":
+			opts.Crypto = KMSCrypto{}
+		default:
+			return fmt.Errorf("unknown crypto %s", s)
+		}
+		return nil
+	}
+	return errors.New("unknown key")
+}
+
+// expandHome replaces a leading "~/" in p with the home directory.
+func expandHome(p string) string {
+	if !strings.HasPrefix(p, "~/") {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return p
+	}
+	return filepath.Join(home, p[2:])
+}
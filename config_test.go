@@ -0,0 +1,104 @@
+package pass
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadDefaultOptions(t *testing.T) {
+	dir := makeTestTree(nil)
+	defer os.RemoveAll(dir)
+	os.Setenv("XDG_CONFIG_HOME", dir)
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+	os.Unsetenv("PASSWORD_STORE_DIR")
+
+	opts, err := LoadDefaultOptions()
+	Ok(t, err)
+	Equal(t, fmt.Sprint(Options{}), fmt.Sprint(*opts))
+
+	writeTestFile(t, dir, "go-pass/config.toml", `# go-pass
+store_dir = "/srv/store" # shared
+pinentry_mode = 'ask'
+trash = true
+timeout = "30s"
+env = ["A=1", "B=#2",]
+store_signing_keys = []
+`)
+	opts, err = LoadDefaultOptions()
+	Ok(t, err)
+	Equal(t, "/srv/store", opts.StoreDir)
+	Equal(t, "ask", opts.PinentryMode)
+	Equal(t, "true", fmt.Sprint(opts.Trash))
+	Equal(t, "30s", opts.Timeout.String())
+	Equal(t, "[A=1 B=#2]", fmt.Sprint(opts.Env))
+
+	os.Setenv("PASSWORD_STORE_DIR", "/env/store")
+	defer os.Unsetenv("PASSWORD_STORE_DIR")
+	os.Setenv("PASSWORD_STORE_SIGNING_KEY", "AAAA BBBB")
+	defer os.Unsetenv("PASSWORD_STORE_SIGNING_KEY")
+	opts, err = LoadDefaultOptions()
+	Ok(t, err)
+	Equal(t, "/env/store", opts.StoreDir)
+	Equal(t, "[AAAA BBBB]", fmt.Sprint(opts.StoreSigningKeys))
+
+	p := filepath.Join(dir, "go-pass", "config.toml")
+	for _, tc := range []struct{ content, err string }{
+		{"colour = \"red\"\n", p + ": colour: unknown key"},
+		{"trash = \"yes\"\n", p + ": trash: must be a boolean"},
+		{"\nstore_dir\n", p + ":2: expected key = value"},
+		{"env = [1]\n", p + ":1: env: arrays must hold strings"},
+		{"trash = true\ntrash = false\n", p + ":2: duplicate key trash"},
+	} {
+		writeTestFile(t, dir, "go-pass/config.toml", tc.content)
+		_, err = LoadDefaultOptions()
+		Equal(t, tc.err, fmt.Sprint(err))
+	}
+}
+
+func TestLoadProfile(t *testing.T) {
+	dir := makeTestTree(nil)
+	defer os.RemoveAll(dir)
+	os.Setenv("XDG_CONFIG_HOME", dir)
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+	os.Setenv("PASSWORD_STORE_DIR", "/env/store")
+	defer os.Unsetenv("PASSWORD_STORE_DIR")
+
+	writeTestFile(t, dir, "go-pass/config.toml", `trash = true
+
+[profiles.work]
+store_dir = "/srv/work"
+crypto = "kms"
+
+[profiles.personal]
+trash = false
+`)
+	names, err := Profiles()
+	Ok(t, err)
+	Equal(t, "[personal work]", fmt.Sprint(names))
+
+	opts, err := LoadProfile("work")
+	Ok(t, err)
+	Equal(t, "/srv/work", opts.StoreDir)
+	Equal(t, "true", fmt.Sprint(opts.Trash))
+	Equal(t, "pass.KMSCrypto", fmt.Sprintf("%T", opts.Crypto))
+
+	opts, err = LoadProfile("personal")
+	Ok(t, err)
+	Equal(t, "/env/store", opts.StoreDir)
+	Equal(t, "false", fmt.Sprint(opts.Trash))
+
+	_, err = LoadProfile("ci")
+	Equal(t, "true", fmt.Sprint(errors.Is(err, ErrProfileNotFound)))
+
+	s, err := OpenProfile("work", WithStoreDir("/tmp/override"))
+	Ok(t, err)
+	Equal(t, "/tmp/override", s.opts.StoreDir)
+	Equal(t, "true", fmt.Sprint(s.opts.Trash))
+
+	writeTestFile(t, dir, "go-pass/config.toml", "[work]\n")
+	_, err = Profiles()
+	Equal(t, filepath.Join(dir, "go-pass", "config.toml")+":1: unknown table work", fmt.Sprint(err))
+}
@@ -0,0 +1,175 @@
+package pass
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Crypto encrypts and decrypts the content of password files. Set it in
+// Options.Crypto to back the store with something other than gpg, such as
+// age or a cloud KMS, while keeping the layout of the store: entries are
+// still .gpg files, and the recipients of a folder are still listed in its
+// .gpg-id file, in whatever form the Crypto understands.
+//
+// When Options.Crypto is set, the package encrypts and decrypts entries
+// itself instead of running pass, in Show, ShowMany, Insert, Init, Move,
+// Copy, Reencrypt and the functions built on them. Functions that deal
+// with gpg keys as such, like CheckKeys, still use gpg.
+type Crypto interface {
+	// Encrypt encrypts content to the recipients, as listed in a .gpg-id
+	// file.
+	Encrypt(ctx context.Context, content []byte, recipients []string) ([]byte, error)
+
+	// Decrypt decrypts data. The passphrase is the one given to the
+	// function that decrypts the entry, or Options.Passphrase; it may be
+	// empty.
+	Decrypt(ctx context.Context, data []byte, passphrase string) ([]byte, error)
+
+	// RecipientKeys returns the keys that content encrypted to the
+	// recipients is encrypted to.
+	RecipientKeys(ctx context.Context, recipients []string) ([]string, error)
+
+	// EncryptedTo returns the keys that data is encrypted to, comparable
+	// with the keys returned by RecipientKeys.
+	EncryptedTo(ctx context.Context, data []byte) ([]string, error)
+}
+
+// usesCrypto reports whether some entries may be encrypted with a Crypto,
+// so that the package must encrypt and decrypt them instead of pass.
+func usesCrypto(opts *Options) bool {
+	return opts != nil && (opts.Crypto != nil || len(opts.Cryptos) > 0)
+}
+
+// recipientsFiles returns the names of the files that list the recipients
+// of a folder: .gpg-id, and those in Options.Cryptos.
+func recipientsFiles(opts *Options) []string {
+	ret := []string{".gpg-id"}
+	if opts != nil {
+		for f := range opts.Cryptos {
+			ret = append(ret, f)
+		}
+	}
+	sort.Strings(ret[1:])
+	return ret
+}
+
+// entryOptions returns opts with Options.Crypto set to the Crypto of the
+// named entry, as selected by Options.Cryptos.
+func entryOptions(name string, opts *Options) *Options {
+	if opts == nil || len(opts.Cryptos) == 0 {
+		return opts
+	}
+	return folderOptions(name, opts)
+}
+
+// folderOptions is like entryOptions, for any path in the store.
+func folderOptions(name string, opts *Options) *Options {
+	f, err := findGPGIDFile(name, opts)
+	if err != nil {
+		return opts // fails later, the same way as without Options.Cryptos
+	}
+	return cryptoOptions(f, opts)
+}
+
+// cryptoOptions returns opts with Options.Crypto set to the Crypto for the
+// recipients file at p.
+func cryptoOptions(p string, opts *Options) *Options {
+	if opts == nil {
+		return opts
+	}
+	c, ok := opts.Cryptos[filepath.Base(p)]
+	if !ok {
+		return opts
+	}
+	o := *opts
+	o.Crypto = c
+	return &o
+}
+
+// writeEntry encrypts content to the recipients of the named entry using
+// Options.Crypto, and stores and commits it the same way pass insert does.
+func writeEntry(ctx context.Context, name string, content []byte, opts *Options) error {
+	gpgIDFile, err := findGPGIDFile(name, opts)
+	if err != nil {
+		return err
+	}
+	if err := verifyGPGIDFile(ctx, gpgIDFile, opts); err != nil {
+		return err
+	}
+	recipients, err := readGPGIDFile(gpgIDFile)
+	if err != nil {
+		return err
+	}
+	opts = cryptoOptions(gpgIDFile, opts)
+	p := filepath.Join(resolveStoreDir(opts), name+".gpg")
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return fmt.Errorf("make dir: %w", err)
+	}
+	tmp, err := encryptTemp(ctx, filepath.Dir(p), content, recipients, opts)
+	if err != nil {
+		return fmt.Errorf("encrypt: %w", err)
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("rename: %w", err)
+	}
+	msg := fmt.Sprintf("Add given password for %s to store.", name)
+	if err := commitFiles(ctx, msg, []string{name + ".gpg"}, opts); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+// initWithCrypto writes the .gpg-id file of the subfolder and re-encrypts
+// the entries in it, the same way pass init does, using Options.Crypto.
+func initWithCrypto(ctx context.Context, gpgIDs []string, subfolder string, opts *Options) error {
+	dir := filepath.Join(resolveStoreDir(opts), subfolder)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("make dir: %w", err)
+	}
+	content := strings.Join(gpgIDs, "\n") + "\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, ".gpg-id"), []byte(content), 0600); err != nil {
+		return err
+	}
+	msg := fmt.Sprintf("Set GPG id to %s.", strings.Join(gpgIDs, ", "))
+	if subfolder != "" {
+		msg = fmt.Sprintf("Set GPG id to %s (%s).", strings.Join(gpgIDs, ", "), subfolder)
+	}
+	if err := commitFiles(ctx, msg, []string{filepath.Join(subfolder, ".gpg-id")}, opts); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+
+	names, err := List(ctx, subfolder, opts)
+	if err != nil {
+		return fmt.Errorf("list: %w", err)
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	report, err := Reencrypt(ctx, names, "", opts)
+	if err != nil {
+		return err
+	}
+	for name, err := range report.Failed {
+		return fmt.Errorf("reencrypt %s: %w", name, err)
+	}
+	return nil
+}
+
+// moveFolderWithCrypto moves, or copies if keep is set, the folder at
+// oldPath to newPath using Options.Crypto, like the mv and cp subcommands.
+func moveFolderWithCrypto(ctx context.Context, oldPath, newPath string, force, keep bool, opts *Options) error {
+	report, err := moveFolder(ctx, oldPath, newPath, force, keep, nil, opts)
+	if err != nil {
+		return err
+	}
+	for name, err := range report.Failed {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package pass
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeCrypto "encrypts" content as a line with its tag, a line listing
+// the keys of the recipients, and the content in base64.
+type fakeCrypto struct{ tag string }
+
+func (c fakeCrypto) Encrypt(ctx context.Context, content []byte, recipients []string) ([]byte, error) {
+	keys, _ := c.RecipientKeys(ctx, recipients)
+	return []byte(c.tag + "\n" + strings.Join(keys, ",") + "\n" + base64.StdEncoding.EncodeToString(content)), nil
+}
+
+func (c fakeCrypto) Decrypt(ctx context.Context, data []byte, passphrase string) ([]byte, error) {
+	if passphrase != "hunter2" {
+		return nil, errors.New("bad passphrase")
+	}
+	lines, err := c.parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(lines[2])
+}
+
+func (c fakeCrypto) RecipientKeys(ctx context.Context, recipients []string) ([]string, error) {
+	var ret []string
+	for _, r := range recipients {
+		ret = append(ret, c.tag+"-"+r)
+	}
+	return ret, nil
+}
+
+func (c fakeCrypto) EncryptedTo(ctx context.Context, data []byte) ([]string, error) {
+	lines, err := c.parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(lines[1], ","), nil
+}
+
+func (c fakeCrypto) parse(data []byte) ([]string, error) {
+	lines := strings.Split(string(data), "\n")
+	if len(lines) != 3 || lines[0] != c.tag {
+		return nil, errors.New("bad data")
+	}
+	return lines, nil
+}
+
+func TestCrypto(t *testing.T) {
+	storeDir, err := ioutil.TempDir("", tmpDirPrefix)
+	Ok(t, err)
+	defer os.RemoveAll(storeDir)
+
+	// No pass or gpg is run.
+	runner := RunnerFunc(func(cmd *exec.Cmd) error {
+		return fmt.Errorf("unexpected command: %s", cmd.Args)
+	})
+	opts := &Options{StoreDir: storeDir, Crypto: fakeCrypto{"key"}, Passphrase: "hunter2", Runner: runner}
+	ctx := context.Background()
+
+	Ok(t, Init(ctx, "alice", "", opts))
+	Ok(t, Init(ctx, "bob", "team", opts))
+	Ok(t, Insert(ctx, "a", []byte("secret\n"), false, opts))
+	content, err := Show(ctx, "a", "", opts)
+	Ok(t, err)
+	Equal(t, "secret\n", string(content))
+
+	recipients := func(name string) string {
+		keys, err := Recipients(ctx, name, opts)
+		Ok(t, err)
+		return fmt.Sprint(keys)
+	}
+	Equal(t, "[key-alice]", recipients("a"))
+
+	// Moving the entry to a folder with other recipients re-encrypts it.
+	Ok(t, Copy(ctx, "a", "team/a", false, opts))
+	Equal(t, "[key-bob]", recipients("team/a"))
+	Ok(t, Move(ctx, "team", "moved", false, opts))
+	Equal(t, "[key-bob]", recipients("moved/a"))
+	content, err = Show(ctx, "moved/a", "", opts)
+	Ok(t, err)
+	Equal(t, "secret\n", string(content))
+
+	// Init re-encrypts the entries to the new recipients.
+	Ok(t, Init(ctx, "carol", "moved", opts))
+	Equal(t, "[key-carol]", recipients("moved/a"))
+	Equal(t, "[key-alice]", recipients("a"))
+
+	_, err = Show(ctx, "a", "wrong", opts)
+	if err == nil {
+		t.Errorf("expected error for wrong passphrase")
+	}
+	_, err = os.Stat(filepath.Join(storeDir, "team"))
+	if !os.IsNotExist(err) {
+		t.Errorf("expected moved folder to be removed, got: %v", err)
+	}
+}
+
+func TestCryptos(t *testing.T) {
+	storeDir := makeTestTree(nil)
+	defer os.RemoveAll(storeDir)
+	writeTestFile(t, storeDir, ".gpg-id", "alice\n")
+	writeTestFile(t, storeDir, "kms/.kms-keys", "k1\n")
+
+	opts := &Options{
+		StoreDir:   storeDir,
+		Crypto:     fakeCrypto{"gpg"},
+		Cryptos:    map[string]Crypto{".kms-keys": fakeCrypto{"kms"}},
+		Passphrase: "hunter2",
+	}
+	ctx := context.Background()
+
+	Ok(t, Insert(ctx, "a", []byte("secret\n"), false, opts))
+	Ok(t, Insert(ctx, "kms/b", []byte("other\n"), false, opts))
+	recipients := func(name string) string {
+		keys, err := Recipients(ctx, name, opts)
+		Ok(t, err)
+		return fmt.Sprint(keys)
+	}
+	Equal(t, "[gpg-alice]", recipients("a"))
+	Equal(t, "[kms-k1]", recipients("kms/b"))
+
+	// Moving between the folders re-encrypts with the other Crypto.
+	Ok(t, Move(ctx, "a", "kms/a", false, opts))
+	Equal(t, "[kms-k1]", recipients("kms/a"))
+	Ok(t, Copy(ctx, "kms/b", "b", false, opts))
+	Equal(t, "[gpg-alice]", recipients("b"))
+
+	m, err := ShowMany(ctx, []string{"kms/a", "b"}, "", opts)
+	Ok(t, err)
+	Equal(t, "secret\n", string(m["kms/a"]))
+	Equal(t, "other\n", string(m["b"]))
+
+	// The recipients files move with their folder.
+	Ok(t, Move(ctx, "kms", "moved", false, opts))
+	Equal(t, "[kms-k1]", recipients("moved/a"))
+
+	writeTestFile(t, storeDir, "moved/.gpg-id", "bob\n")
+	_, err = Show(ctx, "moved/a", "", opts)
+	if err == nil {
+		t.Errorf("expected error for folder with two recipients files")
+	}
+}
@@ -0,0 +1,198 @@
+package pass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// maxParallelDecrypt is the number of gpg processes ShowMany runs at once.
+const maxParallelDecrypt = 4
+
+// ShowMany returns the content of each of the named password files, keyed
+// by name. It makes the same access checks as Show, but is much faster for
+// large numbers of entries: it runs gpg directly instead of going through
+// pass, and decrypts several entries in parallel. As pass does not check
+// the names, ShowMany rejects names that are not canonical or leave the
+// store, such as "a/../b", with ErrInvalidName. It does not use
+// Options.OnCardWait or Options.RecentFile.
+//
+// It still runs one gpg process per entry: gpg cannot decrypt several
+// messages in one process without writing the plaintext of each to a
+// file. The unlocked key stays cached in gpg-agent between the processes.
+//
+// ShowMany stops and returns an error on the first entry that cannot be
+// decrypted.
+func ShowMany(ctx context.Context, names []string, gpgPassphrase string, options ...Option) (map[string]Secret, error) {
+	opts := resolveOptions(options)
+	ret, err := showMany(ctx, names, gpgPassphrase, opts)
+	if aErr := audit(ctx, "show-many", names, err, opts); aErr != nil && err == nil {
+		return nil, fmt.Errorf("write audit log: %w", aErr)
+	}
+	return ret, err
+}
+
+func showMany(ctx context.Context, names []string, gpgPassphrase string, opts *Options) (map[string]Secret, error) {
+	ctx = withDefaultPriority(ctx, Bulk)
+	gpgPassphrase, err := passphrase(ctx, gpgPassphrase, opts)
+	if err != nil {
+		return nil, err
+	}
+	// The checks apply to the entries that the names refer to, as in Show.
+	// lookupName and accessNames reject invalid names, which pass would
+	// reject otherwise, before they are joined to the store directory.
+	resolved := make([]string, len(names))
+	var checked, targets []string
+	for i, name := range names {
+		resolved[i], err = lookupName(ctx, name, opts)
+		if err != nil {
+			return nil, fmt.Errorf("show %s: %w", name, err)
+		}
+		access, err := accessNames(resolved[i], opts)
+		if err != nil {
+			return nil, fmt.Errorf("show %s: %w", name, err)
+		}
+		checked = append(checked, access...)
+		targets = append(targets, access[len(access)-1])
+	}
+	if err := checkACL(checked, Read, opts); err != nil {
+		return nil, err
+	}
+	for _, name := range checked {
+		if err := checkApproval(ctx, name, opts); err != nil {
+			return nil, err
+		}
+	}
+	defer lockNames(resolved, false, opts)()
+	if opts != nil && opts.RateLimiter != nil {
+		for _, name := range targets {
+			if !opts.RateLimiter.allow(name) {
+				return nil, fmt.Errorf("show %s: %w", name, ErrRateLimited)
+			}
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	storeDir := resolveStoreDir(opts)
+
+	var (
+		mu       sync.Mutex
+		ret      = make(map[string]Secret, len(names))
+		firstErr error
+	)
+
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < maxParallelDecrypt; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				name := resolved[i]
+				content, err := decryptCached(ctx, filepath.Join(storeDir, name+".gpg"), gpgPassphrase, entryOptions(name, opts))
+
+				mu.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = fmt.Errorf("show %s: %w", names[i], err)
+						cancel()
+					}
+				} else {
+					ret[names[i]] = content
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+loop:
+	for i := range names {
+		select {
+		case work <- i:
+		case <-ctx.Done():
+			break loop
+		}
+	}
+	close(work)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// decryptCached is like decryptFile, but uses Options.Cache.
+func decryptCached(ctx context.Context, p, gpgPassphrase string, opts *Options) ([]byte, error) {
+	if opts == nil || opts.Cache == nil {
+		return decryptFile(ctx, p, gpgPassphrase, opts)
+	}
+	info, err := os.Stat(p)
+	if err == nil {
+		if content, ok := opts.Cache.get(p, info); ok {
+			return content, nil
+		}
+	}
+	content, err := decryptFile(ctx, p, gpgPassphrase, opts)
+	if err == nil && info != nil {
+		opts.Cache.put(p, info, content)
+	}
+	return content, err
+}
+
+// decryptFile decrypts the password file at p using gpg directly, with the
+// same gpg options that pass uses.
+func decryptFile(ctx context.Context, p, gpgPassphrase string, opts *Options) ([]byte, error) {
+	info, err := os.Stat(p)
+	if os.IsNotExist(err) {
+		return nil, errors.New("name does not exist")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("stat: %w", err)
+	}
+	if info.IsDir() {
+		return nil, errors.New("name is not a file")
+	}
+
+	if opts != nil && opts.Crypto != nil {
+		data, err := ioutil.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		content, err := opts.Crypto.Decrypt(ctx, data, gpgPassphrase)
+		if err != nil {
+			return nil, fmt.Errorf("decrypt: %w", err)
+		}
+		return content, nil
+	}
+
+	args := []string{"--quiet", "--yes", "--compress-algo=none", "--no-encrypt-to", "--batch", "--status-fd=2"}
+	if mode := pinentryMode(opts); mode == "loopback" {
+		args = append(args, "--passphrase-fd=0", "--pinentry-mode=loopback")
+	} else {
+		args = append(args, "--pinentry-mode="+mode)
+	}
+	if noTTY(opts) {
+		args = append(args, "--no-tty")
+	}
+	args = append(args, agentCacheArgs(opts)...)
+	args = append(args, "--decrypt", p)
+
+	stdout, _, err := execGPG(ctx, args, passphraseReader(gpgPassphrase), opts)
+	if err != nil {
+		return nil, fmt.Errorf("exec gpg: %w", err)
+	}
+	if err := afterDecrypt(ctx, opts); err != nil {
+		return nil, err
+	}
+	return stdout, nil
+}
@@ -0,0 +1,82 @@
+package pass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestShowMany(t *testing.T) {
+	storeDir, err := ioutil.TempDir("", tmpDirPrefix)
+	if err != nil {
+		log.Fatalf("create tmp dir: %s", err)
+	}
+
+	opts := &Options{
+		StoreDir: storeDir,
+	}
+	ctx := context.Background()
+	err = Init(ctx, testGpgID, "", opts)
+	Ok(t, err)
+
+	err = Insert(ctx, "google.com/bar", []byte("bar_password"), false, opts)
+	Ok(t, err)
+	err = Insert(ctx, "google.com/baz", []byte("baz_password"), false, opts)
+	Ok(t, err)
+
+	m, err := ShowMany(ctx, []string{"google.com/bar", "google.com/baz"}, testGpgPassphrase, opts)
+	Ok(t, err)
+	Equal(t, "bar_password", string(m["google.com/bar"]))
+	Equal(t, "baz_password", string(m["google.com/baz"]))
+
+	_, err = ShowMany(ctx, []string{"google.com/bar", "google.com/missing"}, testGpgPassphrase, opts)
+	if err == nil {
+		t.Errorf("expected error for missing entry")
+	}
+}
+
+func TestShowManyChecksResolvedNames(t *testing.T) {
+	acl := NewACL()
+	Ok(t, acl.Allow("ci-bot", "deploy/*", Read))
+
+	runner := RunnerFunc(func(cmd *exec.Cmd) error { return nil })
+	opts := &Options{
+		StoreDir:              makeTestTree([]string{"Deploy/key.gpg"}),
+		Runner:                runner,
+		ACL:                   acl,
+		Principal:             "ci-bot",
+		CaseInsensitiveLookup: true,
+	}
+	defer os.RemoveAll(opts.StoreDir)
+
+	// deploy/key refers to Deploy/key, which the ACL does not allow.
+	_, err := ShowMany(context.Background(), []string{"deploy/key"}, "", opts)
+	if err != ErrPermissionDenied {
+		t.Errorf("expected: %s, got: %v", ErrPermissionDenied, err)
+	}
+}
+
+func TestShowManyInvalidName(t *testing.T) {
+	decrypted := false
+	runner := RunnerFunc(func(cmd *exec.Cmd) error {
+		decrypted = true
+		return nil
+	})
+	dir := makeTestTree([]string{"store/a.gpg", "outside.gpg"})
+	defer os.RemoveAll(dir)
+	opts := &Options{StoreDir: filepath.Join(dir, "store"), Runner: runner}
+
+	for _, name := range []string{"../outside", "a/../../outside", "/a", "./a"} {
+		_, err := ShowMany(context.Background(), []string{name}, "", opts)
+		if !errors.Is(err, ErrInvalidName) {
+			t.Errorf("%s: expected: %s, got: %v", name, ErrInvalidName, err)
+		}
+	}
+	Equal(t, "false", fmt.Sprint(decrypted))
+}
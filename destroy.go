@@ -0,0 +1,178 @@
+package pass
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ConfirmFunc is called by Destroy with the directory of the store about
+// to be destroyed. Destroy only proceeds if it returns true.
+type ConfirmFunc func(storeDir string) bool
+
+// ErrNotConfirmed is returned by Destroy when the ConfirmFunc does not
+// confirm the destruction, and with Options.NonInteractive for changes
+// that pass would ask to confirm.
+var ErrNotConfirmed = errors.New("not confirmed")
+
+// Destroy permanently removes the store, including its git repository.
+// Each file is overwritten with random data before it is removed. This
+// does not guarantee that the data cannot be recovered on copy-on-write
+// filesystems, journaling filesystems, or SSDs, where overwriting a file
+// may not overwrite its blocks.
+//
+// The confirm function must confirm the destruction. If Options.ACL is
+// set, Options.Principal must have Write permission on every entry.
+func Destroy(ctx context.Context, confirm ConfirmFunc, options ...Option) error {
+	opts := resolveOptions(options)
+	defer lockNames(nil, true, opts)()
+	err := destroy(confirm, opts)
+	invalidateCache(nil, opts)
+	if aErr := audit(ctx, "destroy", nil, err, opts); aErr != nil && err == nil {
+		return fmt.Errorf("write audit log: %w", aErr)
+	}
+	return err
+}
+
+func destroy(confirm ConfirmFunc, opts *Options) error {
+	if err := checkACL([]string{""}, Write, opts); err != nil {
+		return err
+	}
+
+	storeDir := resolveStoreDir(opts)
+	info, err := os.Stat(storeDir)
+	if err != nil {
+		return fmt.Errorf("stat: %w", err)
+	}
+	if !info.IsDir() {
+		return errors.New("store dir is not a directory")
+	}
+	if confirm == nil || !confirm(storeDir) {
+		return ErrNotConfirmed
+	}
+
+	return shredAll(storeDir)
+}
+
+// shredAll overwrites every file at or under p with random data, and then
+// removes p.
+func shredAll(p string) error {
+	err := filepath.Walk(p, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		return shredFile(p, info.Size())
+	})
+	if err != nil {
+		return fmt.Errorf("shred: %w", err)
+	}
+	if err := os.RemoveAll(p); err != nil {
+		return fmt.Errorf("remove: %w", err)
+	}
+	return nil
+}
+
+// shredEntry removes the named entry, or folder if recursive is set, like
+// the rm subcommand, overwriting its files before they are removed. If no
+// version of the entry was pushed, it is also dropped from the git
+// history, so that no copy of it is left in the repository.
+func shredEntry(ctx context.Context, name string, recursive bool, opts *Options) error {
+	storeDir := resolveStoreDir(opts)
+	name = filepath.Clean(name)
+
+	p := name + ".gpg"
+	if _, err := os.Stat(filepath.Join(storeDir, p)); os.IsNotExist(err) {
+		info, err := os.Stat(filepath.Join(storeDir, name))
+		if err != nil || !info.IsDir() {
+			return errors.New("name does not exist")
+		}
+		if !recursive {
+			return errors.New("name is a folder; use recursive")
+		}
+		p = name
+	}
+
+	scrub := false
+	if isGitRepo(opts) {
+		var err error
+		if scrub, err = unpushedHistory(ctx, filepath.ToSlash(p), opts); err != nil {
+			return err
+		}
+	}
+	if scrub {
+		// Rewriting the history needs a clean work tree; check before
+		// removing anything.
+		status, err := gitOutput(ctx, []string{"status", "--porcelain"}, opts)
+		if err != nil {
+			return err
+		}
+		if len(status) > 0 {
+			return errors.New("store has uncommitted changes")
+		}
+	}
+
+	if err := shredAll(filepath.Join(storeDir, p)); err != nil {
+		return err
+	}
+
+	msg := fmt.Sprintf("Remove %s from store.", name)
+	if err := commitFiles(ctx, msg, []string{p}, opts); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	if scrub {
+		// The commits that added and removed the entry are kept, empty,
+		// so that HEAD is still the commit made above.
+		if err := dropFromHistory(ctx, filepath.ToSlash(p), false, opts); err != nil {
+			return fmt.Errorf("remove from history: %w", err)
+		}
+	}
+	return nil
+}
+
+// unpushedHistory reports whether the file or folder p, a slash separated
+// path relative to the store, is in the git history but in none of the
+// commits of the remote-tracking branches.
+func unpushedHistory(ctx context.Context, p string, opts *Options) (bool, error) {
+	touched, err := gitOutput(ctx, []string{"log", "--all", "--format=%H", "--", p}, opts)
+	if err != nil {
+		return false, err
+	}
+	if len(strings.TrimSpace(string(touched))) == 0 {
+		return false, nil
+	}
+	pushed, err := gitOutput(ctx, []string{"log", "--remotes", "--format=%H", "--", p}, opts)
+	if err != nil {
+		return false, err
+	}
+	return len(strings.TrimSpace(string(pushed))) == 0, nil
+}
+
+// shredFile overwrites the size bytes of the file at p with random data
+// and flushes it to disk. The file is not removed.
+func shredFile(p string, size int64) error {
+	// Files in the git repository are read-only.
+	if err := os.Chmod(p, 0600); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(p, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	if _, err := io.CopyN(f, rand.Reader, size); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,132 @@
+package pass
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDestroy(t *testing.T) {
+	storeDir := makeTestTree([]string{".gpg-id", "a.gpg", "b/c.gpg", ".git/objects/ab/cdef"})
+	if err := os.Chmod(filepath.Join(storeDir, ".git/objects/ab/cdef"), 0444); err != nil {
+		t.Fatal(err)
+	}
+	opts := &Options{StoreDir: storeDir}
+	ctx := context.Background()
+
+	err := Destroy(ctx, func(string) bool { return false }, opts)
+	if err != ErrNotConfirmed {
+		t.Errorf("expected: %s, got: %v", ErrNotConfirmed, err)
+	}
+	if _, err := os.Stat(storeDir); err != nil {
+		t.Errorf("expected store to remain: %s", err)
+	}
+
+	var confirmed string
+	err = Destroy(ctx, func(dir string) bool {
+		confirmed = dir
+		return true
+	}, opts)
+	Ok(t, err)
+	Equal(t, storeDir, confirmed)
+	if _, err := os.Stat(storeDir); !os.IsNotExist(err) {
+		t.Errorf("expected store to be removed, got: %v", err)
+	}
+}
+
+func TestRemoveShred(t *testing.T) {
+	storeDir := makeTestTree([]string{"dir/b.gpg"})
+	defer os.RemoveAll(storeDir)
+	Ok(t, ioutil.WriteFile(filepath.Join(storeDir, "a.gpg"), []byte("secret"), 0600))
+
+	// A hard link sees the overwritten content after the entry is removed.
+	link := filepath.Join(storeDir, "link")
+	Ok(t, os.Link(filepath.Join(storeDir, "a.gpg"), link))
+
+	opts := &Options{StoreDir: storeDir, Shred: true}
+	ctx := context.Background()
+	Ok(t, Remove(ctx, "a", false, true, opts))
+	if _, err := os.Stat(filepath.Join(storeDir, "a.gpg")); !os.IsNotExist(err) {
+		t.Errorf("expected a.gpg to be removed")
+	}
+	b, err := ioutil.ReadFile(link)
+	Ok(t, err)
+	if string(b) == "secret" {
+		t.Errorf("expected content to be overwritten")
+	}
+
+	if err := Remove(ctx, "dir", false, true, opts); err == nil {
+		t.Errorf("expected error for folder without recursive")
+	}
+	Ok(t, Remove(ctx, "dir", true, true, opts))
+	if _, err := os.Stat(filepath.Join(storeDir, "dir")); !os.IsNotExist(err) {
+		t.Errorf("expected dir to be removed")
+	}
+}
+
+func TestRemoveShredHistory(t *testing.T) {
+	defer fakeCommand("pass", `shift; exec git -C "$PASSWORD_STORE_DIR" "$@"`)()
+
+	dir, err := ioutil.TempDir("", tmpDirPrefix)
+	if err != nil {
+		log.Fatalf("create tmp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	remote := filepath.Join(dir, "remote.git")
+	Ok(t, exec.Command("git", "init", "-q", "--bare", remote).Run())
+
+	opts := &Options{
+		StoreDir: makeTestTree(nil),
+		Shred:    true,
+		Env: []string{
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+		},
+	}
+	defer os.RemoveAll(opts.StoreDir)
+	writeTestFile(t, opts.StoreDir, "pushed.gpg", "pushed secret")
+	writeTestFile(t, opts.StoreDir, "local.gpg", "local secret")
+	ctx := context.Background()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"remote", "add", "origin", remote},
+		{"config", "push.default", "current"},
+		{"add", "pushed.gpg"},
+		{"commit", "-q", "-m", "Add pushed."},
+		{"push", "-q", "-u", "origin"},
+		{"add", "local.gpg"},
+		{"commit", "-q", "-m", "Add local."},
+	} {
+		Ok(t, runGit(ctx, args, opts))
+	}
+	blob := func(name string) string {
+		out, err := gitOutput(ctx, []string{"rev-parse", "HEAD:" + name}, opts)
+		Ok(t, err)
+		return strings.TrimSpace(string(out))
+	}
+	exists := func(obj string) bool {
+		return runGit(ctx, []string{"cat-file", "-e", obj}, opts) == nil
+	}
+	pushedBlob, localBlob := blob("pushed.gpg"), blob("local.gpg")
+
+	Ok(t, Remove(ctx, "local", false, true, opts))
+	if exists(localBlob) {
+		t.Errorf("expected unpushed entry to be removed from the history")
+	}
+	out, err := gitOutput(ctx, []string{"log", "-1", "--format=%s"}, opts)
+	Ok(t, err)
+	Equal(t, "Remove local from store.", strings.TrimSpace(string(out)))
+	// The pushed commits are not rewritten.
+	Ok(t, runGit(ctx, []string{"merge-base", "--is-ancestor", "@{upstream}", "HEAD"}, opts))
+
+	// Pushed entries stay in the history.
+	Ok(t, Remove(ctx, "pushed", false, true, opts))
+	if !exists(pushedBlob) {
+		t.Errorf("expected pushed entry to stay in the history")
+	}
+}
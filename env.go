@@ -0,0 +1,162 @@
+package pass
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// envRefPrefix is the prefix of the values substituted by ResolveEnv.
+const envRefPrefix = "pass://"
+
+// EnvName returns the name of an environment variable for the entry name:
+// the name in upper case, with characters other than letters and digits
+// replaced by "_". For example, "db/admin-password" becomes
+// "DB_ADMIN_PASSWORD".
+func EnvName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, filepath.ToSlash(name))
+}
+
+// ExportDotenv writes the entries in folder to w as KEY=value lines, in
+// the format read by docker compose and dotenv libraries. The value is the
+// password, that is the first line, of the entry. The key is the name of
+// the entry relative to folder, mapped by mapping, or EnvName if mapping
+// is nil. Entries that mapping maps to "" are skipped. The entries are
+// decrypted as in ShowMany.
+func ExportDotenv(ctx context.Context, folder string, w io.Writer, mapping func(name string) string, gpgPassphrase string, options ...Option) error {
+	opts := resolveOptions(options)
+	err := exportDotenv(ctx, folder, w, mapping, gpgPassphrase, opts)
+	if aErr := audit(ctx, "export-dotenv", []string{folder}, err, opts); aErr != nil && err == nil {
+		return fmt.Errorf("write audit log: %w", aErr)
+	}
+	return err
+}
+
+func exportDotenv(ctx context.Context, folder string, w io.Writer, mapping func(name string) string, gpgPassphrase string, opts *Options) error {
+	if mapping == nil {
+		mapping = EnvName
+	}
+
+	names, err := List(ctx, folder, opts)
+	if err != nil {
+		return fmt.Errorf("list: %w", err)
+	}
+
+	keys := make(map[string]string, len(names)) // name -> key
+	seen := make(map[string]string, len(names)) // key -> name
+	var export []string
+	for _, name := range names {
+		rel := name
+		if folder != "" {
+			if rel, err = filepath.Rel(folder, name); err != nil {
+				panic(err) // should not happen
+			}
+		}
+		key := mapping(rel)
+		if key == "" {
+			continue
+		}
+		if other, ok := seen[key]; ok {
+			return fmt.Errorf("%s and %s both map to %s", other, name, key)
+		}
+		keys[name], seen[key] = key, name
+		export = append(export, name)
+	}
+
+	contents, err := showMany(ctx, export, gpgPassphrase, opts)
+	if err != nil {
+		return err
+	}
+	for _, name := range export {
+		line := fmt.Sprintf("%s=%s\n", keys[name], dotenvQuote(string(contents[name].Password())))
+		if _, err := io.WriteString(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ResolveEnv returns a copy of environ, a list of "key=value" strings as
+// returned by os.Environ, with the values of the form
+// "pass://name#field" replaced by the field of the named entry. The field
+// is read using Secret.Field, except for "password", the default, which is
+// the first line of the entry. The name may be escaped as in URL paths.
+// The entries are decrypted as in ShowMany, using Options.Passphrase.
+func ResolveEnv(ctx context.Context, environ []string, options ...Option) ([]string, error) {
+	opts := resolveOptions(options)
+
+	type ref struct{ name, field string }
+	refs := make(map[int]ref)
+	var names []string
+	seen := make(map[string]bool)
+	for i, kv := range environ {
+		j := strings.IndexByte(kv, '=')
+		if j == -1 || !strings.HasPrefix(kv[j+1:], envRefPrefix) {
+			continue
+		}
+		name, field, err := parseEnvRef(kv[j+1:])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", kv[:j], err)
+		}
+		refs[i] = ref{name, field}
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	if len(refs) == 0 {
+		return append([]string(nil), environ...), nil
+	}
+
+	contents, err := ShowMany(ctx, names, "", opts)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]string, len(environ))
+	for i, kv := range environ {
+		r, ok := refs[i]
+		if !ok {
+			ret[i] = kv
+			continue
+		}
+		key := kv[:strings.IndexByte(kv, '=')]
+		content := contents[r.name]
+		value := content.Password()
+		if r.field != passwordKey {
+			if value, ok = content.Field(r.field); !ok {
+				return nil, fmt.Errorf("%s: %s has no field %s", key, r.name, r.field)
+			}
+		}
+		ret[i] = key + "=" + string(value)
+	}
+	return ret, nil
+}
+
+// parseEnvRef returns the name and field of a "pass://name#field" value.
+func parseEnvRef(s string) (name, field string, err error) {
+	s = strings.TrimPrefix(s, envRefPrefix)
+	field = passwordKey
+	if i := strings.IndexByte(s, '#'); i != -1 {
+		s, field = s[:i], s[i+1:]
+	}
+	name, err = url.PathUnescape(s)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid name: %w", err)
+	}
+	if name == "" || field == "" {
+		return "", "", fmt.Errorf("invalid reference %s%s", envRefPrefix, s)
+	}
+	return name, field, nil
+}
@@ -0,0 +1,94 @@
+package pass
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"testing"
+)
+
+func TestEnvName(t *testing.T) {
+	Equal(t, "DB_ADMIN_PASSWORD", EnvName("db/admin-password"))
+	Equal(t, "API_KEY2", EnvName("api.key2"))
+}
+
+func TestExportDotenv(t *testing.T) {
+	storeDir, err := ioutil.TempDir("", tmpDirPrefix)
+	if err != nil {
+		log.Fatalf("create tmp dir: %s", err)
+	}
+
+	opts := &Options{
+		StoreDir: storeDir,
+	}
+	ctx := context.Background()
+	err = Init(ctx, testGpgID, "", opts)
+	Ok(t, err)
+
+	err = Insert(ctx, "dev/db/password", []byte("p@ss word\nuser: alice\n"), false, opts)
+	Ok(t, err)
+	err = Insert(ctx, "dev/api-key", []byte("abc123\n"), false, opts)
+	Ok(t, err)
+
+	var buf bytes.Buffer
+	err = ExportDotenv(ctx, "dev", &buf, nil, testGpgPassphrase, opts)
+	Ok(t, err)
+	Equal(t, "API_KEY=abc123\nDB_PASSWORD=\"p@ss word\"\n", buf.String())
+}
+
+func TestParseEnvRef(t *testing.T) {
+	testcases := []struct {
+		in, name, field string
+	}{
+		{"pass://aws/prod/secret#password", "aws/prod/secret", "password"},
+		{"pass://aws/prod/secret", "aws/prod/secret", "password"},
+		{"pass://aws/prod/secret#user", "aws/prod/secret", "user"},
+		{"pass://my%20site/key#api-key", "my site/key", "api-key"},
+	}
+	for _, tc := range testcases {
+		name, field, err := parseEnvRef(tc.in)
+		Ok(t, err)
+		Equal(t, tc.name, name)
+		Equal(t, tc.field, field)
+	}
+	for _, in := range []string{"pass://", "pass://a#", "pass://%zz"} {
+		if _, _, err := parseEnvRef(in); err == nil {
+			t.Errorf("expected error for %s", in)
+		}
+	}
+}
+
+func TestResolveEnvNoRefs(t *testing.T) {
+	environ := []string{"HOME=/root", "URL=https://example.com"}
+	got, err := ResolveEnv(context.Background(), environ, &Options{StoreDir: "/nonexistent"})
+	Ok(t, err)
+	Equal(t, fmt.Sprint(environ), fmt.Sprint(got))
+}
+
+func TestResolveEnv(t *testing.T) {
+	storeDir, err := ioutil.TempDir("", tmpDirPrefix)
+	if err != nil {
+		log.Fatalf("create tmp dir: %s", err)
+	}
+
+	opts := &Options{
+		StoreDir:   storeDir,
+		Passphrase: testGpgPassphrase,
+	}
+	ctx := context.Background()
+	err = Init(ctx, testGpgID, "", opts)
+	Ok(t, err)
+
+	err = Insert(ctx, "aws/prod", []byte("secret_key\naccess_key: AKIA123\n"), false, opts)
+	Ok(t, err)
+
+	got, err := ResolveEnv(ctx, []string{
+		"AWS_SECRET_ACCESS_KEY=pass://aws/prod",
+		"AWS_ACCESS_KEY_ID=pass://aws/prod#access_key",
+		"HOME=/root",
+	}, opts)
+	Ok(t, err)
+	Equal(t, "[AWS_SECRET_ACCESS_KEY=secret_key AWS_ACCESS_KEY_ID=AKIA123 HOME=/root]", fmt.Sprint(got))
+}
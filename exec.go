@@ -0,0 +1,203 @@
+package pass
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// ExecError is the error for a command that failed. The functions of the
+// package return it wrapped, so use errors.As to get it.
+type ExecError struct {
+	Subcommand string        // The pass subcommand, or "gpg" for gpg run directly.
+	Args       []string      // The arguments, with secrets redacted.
+	ExitCode   int           // -1 if the command did not exit normally.
+	Stderr     []byte        // The standard error of the command.
+	Duration   time.Duration // How long the command ran.
+
+	// The cause. Errors reported by gpg are classified, so errors.Is
+	// can be used with, for example, ErrBadPassphrase.
+	Err error
+}
+
+func (e *ExecError) Error() string {
+	msg := withoutStatusLines(e.Stderr)
+	if len(msg) == 0 {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s: %s", e.Err, msg)
+}
+
+func (e *ExecError) Unwrap() error { return e.Err }
+
+// DefaultMaxGPGProcesses is the default of Options.MaxGPGProcesses.
+const DefaultMaxGPGProcesses = 4
+
+// gpgSubcommands are the subcommands for which runCommand runs gpg, either
+// directly or through pass.
+var gpgSubcommands = map[string]bool{
+	"gpg":      true,
+	"show":     true,
+	"insert":   true,
+	"edit":     true,
+	"generate": true,
+	"init":     true,
+	"mv":       true,
+	"cp":       true,
+}
+
+// defaultGPGLimiter limits the gpg processes run by calls without a Store.
+var defaultGPGLimiter = newGPGLimiter(DefaultMaxGPGProcesses)
+
+// runCommand runs the program with Options.Runner, returning its standard
+// output and standard error. The subcommand selects the timeout from
+// Options.Timeouts, and whether the program waits for a gpg process slot
+// (see Options.MaxGPGProcesses), at the priority of ctx. If stderrTee is not nil, the standard
+// error is also written to it as the program runs. If the program fails,
+// the error is an *ExecError. Secrets must be given to the program on
+// stdin: runCommand fails with ErrSecretInArgs if args or env hold one.
+func runCommand(ctx context.Context, subcommand, program string, args, env []string, stdin io.Reader, stderrTee io.Writer, opts *Options) (stdout, stderr []byte, err error) {
+	return runCommandFiles(ctx, subcommand, program, args, env, stdin, nil, stderrTee, opts)
+}
+
+// runCommandFiles is like runCommand, also giving the program the content
+// of each of extra on a pipe inherited as file descriptor 3, 4, and so on.
+func runCommandFiles(ctx context.Context, subcommand, program string, args, env []string, stdin io.Reader, extra []io.Reader, stderrTee io.Writer, opts *Options) (stdout, stderr []byte, err error) {
+	if err := harden(opts); err != nil {
+		return nil, nil, err
+	}
+	if err := checkArgs(args, env, commandPassphrases(stdin, extra, opts)); err != nil {
+		return nil, nil, err
+	}
+
+	var runner Runner = defaultRunner{}
+	if opts != nil && opts.Runner != nil {
+		runner = opts.Runner
+	}
+
+	if gpgSubcommands[subcommand] {
+		l := defaultGPGLimiter
+		if opts != nil && opts.gpgLimiter != nil {
+			l = opts.gpgLimiter
+		}
+		if err := l.acquire(ctx); err != nil {
+			return nil, nil, err
+		}
+		defer l.release()
+	}
+
+	parent := ctx
+	timeout := commandTimeout(ctx, subcommand, opts)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var outBuf, errBuf bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, program, args...)
+	cmd.Env = env
+	cmd.Stdout = &outBuf
+	cmd.Stderr = &errBuf
+	if stderrTee != nil {
+		cmd.Stderr = io.MultiWriter(&errBuf, stderrTee)
+	}
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	for _, r := range extra {
+		pr, pw, err := os.Pipe()
+		if err != nil {
+			return nil, nil, err
+		}
+		// Closing the read end after the program exits makes the write
+		// fail if the program did not read everything.
+		defer pr.Close()
+		go func(r io.Reader) {
+			io.Copy(pw, r)
+			pw.Close()
+		}(r)
+		cmd.ExtraFiles = append(cmd.ExtraFiles, pr)
+	}
+
+	start := time.Now()
+	err = runner.Run(cmd)
+	if err == nil {
+		return outBuf.Bytes(), errBuf.Bytes(), nil
+	}
+
+	xErr := &ExecError{
+		Subcommand: subcommand,
+		Args:       sanitizeArgs(args),
+		ExitCode:   -1,
+		Stderr:     errBuf.Bytes(),
+		Duration:   time.Since(start),
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		xErr.ExitCode = exitErr.ExitCode()
+	}
+	if ctx.Err() == context.DeadlineExceeded && parent.Err() == nil {
+		err = fmt.Errorf("%w: %s after %s", ErrTimeout, subcommand, timeout)
+	}
+	xErr.Err = commandError(err, xErr.Stderr)
+	return outBuf.Bytes(), xErr.Stderr, xErr
+}
+
+// commandTimeout returns the timeout for running the subcommand, or 0 for
+// none. Options.Timeouts only applies if ctx has no deadline.
+func commandTimeout(ctx context.Context, subcommand string, opts *Options) time.Duration {
+	if opts == nil {
+		return 0
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		if d, ok := opts.Timeouts[subcommand]; ok {
+			return d
+		}
+	}
+	return opts.Timeout
+}
+
+// commandError classifies the error of a failed command that wrote
+// stderr. Errors reported by gpg are classified using the status lines in
+// stderr, so that callers can check for them using errors.Is.
+func commandError(err error, stderr []byte) error {
+	if errors.Is(err, ErrTimeout) {
+		return err
+	}
+	if sErr := statusError(stderr); sErr != nil {
+		return sErr
+	}
+	if bytes.Contains(stderr, []byte("terminal prompts disabled")) {
+		return ErrInteractionRequired // git needed credentials
+	}
+	return err
+}
+
+// sanitizeArgs returns a copy of args with passphrases and the passwords
+// in URLs redacted, so that they are safe to log.
+func sanitizeArgs(args []string) []string {
+	ret := make([]string, len(args))
+	for i, a := range args {
+		switch {
+		case i > 0 && args[i-1] == "--passphrase":
+			a = redacted
+		case strings.HasPrefix(a, "--passphrase="):
+			a = "--passphrase=" + redacted
+		case strings.Contains(a, "://"):
+			if u, err := url.Parse(a); err == nil {
+				a = u.Redacted()
+			}
+		}
+		ret[i] = a
+	}
+	return ret
+}
@@ -0,0 +1,105 @@
+package pass
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// GC tidies the store: it removes the empty folders left behind by Remove
+// and Move, and the .gpg-id files of subfolders that no longer contain any
+// entries, along with those subfolders. If gitGC is set and the store is a
+// git repository, it then runs git gc. It returns the removed files and
+// folders, relative to the store. Folders whose names start with a dot,
+// such as the git repository and the trash, are left alone.
+func GC(ctx context.Context, gitGC bool, options ...Option) ([]string, error) {
+	opts := resolveOptions(options)
+	var removed []string
+	err := mutate(ctx, "gc", nil, opts, func() error {
+		if err := checkACL([]string{""}, Write, opts); err != nil {
+			return err
+		}
+		var err error
+		removed, err = gc(ctx, gitGC, opts)
+		return err
+	})
+	return removed, err
+}
+
+func gc(ctx context.Context, gitGC bool, opts *Options) ([]string, error) {
+	storeDir := resolveStoreDir(opts)
+
+	var removed, gpgIDFiles []string
+	if _, err := pruneDir(storeDir, "", &removed, &gpgIDFiles); err != nil {
+		return removed, err
+	}
+
+	if len(gpgIDFiles) > 0 {
+		msg := fmt.Sprintf("Remove %d unused .gpg-id files.", len(gpgIDFiles))
+		if err := commitFiles(ctx, msg, gpgIDFiles, opts); err != nil {
+			return removed, fmt.Errorf("commit: %w", err)
+		}
+	}
+	if gitGC && isGitRepo(opts) {
+		if err := runGit(ctx, []string{"gc", "--quiet"}, opts); err != nil {
+			return removed, fmt.Errorf("git gc: %w", err)
+		}
+	}
+	return removed, nil
+}
+
+// pruneDir removes the empty subfolders of the folder rel, and its
+// .gpg-id file if it has no entries and is not the root of the store. It
+// reports whether the folder is left empty. The removed paths are appended
+// to removed, and the removed .gpg-id files also to gpgIDFiles.
+func pruneDir(storeDir, rel string, removed, gpgIDFiles *[]string) (bool, error) {
+	dir := filepath.Join(storeDir, rel)
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return false, err
+	}
+
+	empty, hasGPGID := true, false
+	for _, info := range infos {
+		child := filepath.Join(rel, info.Name())
+		switch {
+		case strings.HasPrefix(info.Name(), "."):
+			if info.Name() == ".gpg-id" && info.Mode().IsRegular() {
+				hasGPGID = true
+			} else {
+				empty = false
+			}
+		case info.IsDir():
+			childEmpty, err := pruneDir(storeDir, child, removed, gpgIDFiles)
+			if err != nil {
+				return false, err
+			}
+			if !childEmpty {
+				empty = false
+				continue
+			}
+			if err := os.Remove(filepath.Join(storeDir, child)); err != nil {
+				return false, fmt.Errorf("remove: %w", err)
+			}
+			*removed = append(*removed, child)
+		default:
+			empty = false
+		}
+	}
+
+	if rel == "" || !empty {
+		return false, nil
+	}
+	if hasGPGID {
+		p := filepath.Join(rel, ".gpg-id")
+		if err := os.Remove(filepath.Join(storeDir, p)); err != nil {
+			return false, fmt.Errorf("remove: %w", err)
+		}
+		*removed = append(*removed, p)
+		*gpgIDFiles = append(*gpgIDFiles, p)
+	}
+	return true, nil
+}
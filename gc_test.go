@@ -0,0 +1,49 @@
+package pass
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestGC(t *testing.T) {
+	storeDir := makeTestTree([]string{
+		".gpg-id",
+		".trash/empty/.gpg-id",
+		"a.gpg",
+		"keep/.gpg-id",
+		"keep/sub/x.gpg",
+		"orphan/.gpg-id",
+		"orphan/sub/.gpg-id",
+		"other/README",
+	})
+	defer os.RemoveAll(storeDir)
+	Ok(t, os.MkdirAll(filepath.Join(storeDir, "empty", "a", "b"), 0700))
+	Ok(t, os.MkdirAll(filepath.Join(storeDir, "keep", "empty"), 0700))
+
+	removed, err := GC(context.Background(), true, WithStoreDir(storeDir))
+	Ok(t, err)
+	for i := range removed {
+		removed[i] = filepath.ToSlash(removed[i])
+	}
+	sort.Strings(removed)
+	Equal(t, fmt.Sprint([]string{
+		"empty",
+		"empty/a",
+		"empty/a/b",
+		"keep/empty",
+		"orphan",
+		"orphan/.gpg-id",
+		"orphan/sub",
+		"orphan/sub/.gpg-id",
+	}), fmt.Sprint(removed))
+
+	for _, p := range []string{".gpg-id", ".trash/empty/.gpg-id", "keep/.gpg-id", "other/README"} {
+		if _, err := os.Stat(filepath.Join(storeDir, p)); err != nil {
+			t.Errorf("expected %s to be kept: %s", p, err)
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package pass
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// isGitRepo reports whether the store is a git repository.
+func isGitRepo(opts *Options) bool {
+	_, err := os.Stat(filepath.Join(resolveStoreDir(opts), ".git"))
+	return err == nil
+}
+
+// commitFiles stages the paths, which are relative to the root of the
+// store, and commits them with the message, the same way pass commits its
+// changes. It does nothing if the store is not a git repository.
+func commitFiles(ctx context.Context, message string, paths []string, opts *Options) error {
+	if !isGitRepo(opts) {
+		return nil
+	}
+	args := []string{"add", "--all", "--"}
+	args = append(args, paths...)
+	if err := runGit(ctx, args, opts); err != nil {
+		return err
+	}
+	return runGit(ctx, []string{"commit", "-m", message}, opts)
+}
+
+// gitOutput runs git in the store, like Git, and returns its standard
+// output.
+func gitOutput(ctx context.Context, args []string, opts *Options) ([]byte, error) {
+	stdout, _, err := execCommand(ctx, "git", args, nil, "", nil, nil, opts)
+	if err != nil {
+		return nil, fmt.Errorf("exec git: %w", err)
+	}
+	return stdout, nil
+}
+
+// runGit runs git in the store, like Git, but without an audit record.
+func runGit(ctx context.Context, args []string, opts *Options) error {
+	_, err := gitOutput(ctx, args, opts)
+	return err
+}
+
+// gitHead returns the commit hash of HEAD.
+func gitHead(ctx context.Context, opts *Options) (string, error) {
+	out, err := gitOutput(ctx, []string{"rev-parse", "HEAD"}, opts)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// CommitMessageData is the data available to Options.CommitMessageTemplate.
+type CommitMessageData struct {
+	Operation string   // For example "insert", "rm", "mv" or "batch".
+	Names     []string // The entries affected by the operation, if any.
+	Message   string   // The commit message that would be used otherwise.
+}
+
+// mutate runs fn, which changes the store as the named operation on the
+// named entries. It is used by all functions that change the store, so that
+// the options that apply to changes are handled in one place. Operations
+// with no names, such as Batch, are not checked against Options.ACL
+// themselves; the changes they are made of are.
+func mutate(ctx context.Context, op string, names []string, opts *Options, fn func() error) error {
+	return mutateWriting(ctx, op, names, names, opts, fn)
+}
+
+// mutateWriting is mutate for operations that write only some of the
+// named entries, such as Alias, which reads its target. Options.ACL is
+// checked for Write on the entries written; fn checks the others.
+func mutateWriting(ctx context.Context, op string, names, written []string, opts *Options, fn func() error) error {
+	defer lockNames(names, true, opts)()
+	rewrite := opts != nil && opts.CommitMessageTemplate != "" && isGitRepo(opts)
+
+	var head string
+	if rewrite {
+		// An error means there are no commits yet, which is the same
+		// as HEAD changing if fn commits.
+		head, _ = gitHead(ctx, opts)
+	}
+
+	err := checkACL(written, Write, opts)
+	if err == nil {
+		err = fn()
+		invalidateCache(names, opts)
+	}
+	if err == nil && rewrite {
+		if rErr := rewriteCommitMessage(ctx, head, op, names, opts); rErr != nil {
+			err = fmt.Errorf("rewrite commit message: %w", rErr)
+		}
+	}
+	if aErr := audit(ctx, op, names, err, opts); aErr != nil && err == nil {
+		err = fmt.Errorf("write audit log: %w", aErr)
+	}
+	if err == nil && opts != nil && opts.AutoPush && isGitRepo(opts) {
+		// A failed push leaves the commits pending.
+		runGit(ctx, []string{"push"}, opts)
+	}
+	if err == nil && opts != nil && opts.Notifier != nil {
+		opts.Notifier.notify(ctx, op, names, opts)
+	}
+	return err
+}
+
+// rewriteCommitMessage amends the message of the HEAD commit using
+// Options.CommitMessageTemplate, if HEAD is no longer the commit oldHead.
+func rewriteCommitMessage(ctx context.Context, oldHead, op string, names []string, opts *Options) error {
+	head, err := gitHead(ctx, opts)
+	if err != nil || head == oldHead {
+		return nil // no new commit
+	}
+
+	msg, err := gitOutput(ctx, []string{"log", "-1", "--format=%B"}, opts)
+	if err != nil {
+		return err
+	}
+
+	tmpl, err := template.New("commit").Parse(opts.CommitMessageTemplate)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	data := CommitMessageData{
+		Operation: op,
+		Names:     names,
+		Message:   strings.TrimSpace(string(msg)),
+	}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return err
+	}
+
+	return runGit(ctx, []string{"commit", "--amend", "--allow-empty", "-m", buf.String()}, opts)
+}
+
+// PendingPushes returns the hashes of the commits in the store that are not
+// on any remote yet, oldest first, such as those made while the remote was
+// unreachable with Options.AutoPush set.
+func PendingPushes(ctx context.Context, options ...Option) ([]string, error) {
+	opts := resolveOptions(options)
+	out, err := gitOutput(ctx, []string{"rev-list", "--reverse", "HEAD", "--not", "--remotes"}, opts)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(out)), nil
+}
+
+// Flush pushes the commits returned by PendingPushes, if any.
+func Flush(ctx context.Context, options ...Option) error {
+	opts := resolveOptions(options)
+	defer lockNames(nil, true, opts)()
+	err := checkACL([]string{""}, Write, opts)
+	if err == nil {
+		var pending []string
+		pending, err = PendingPushes(ctx, opts)
+		if err == nil && len(pending) > 0 {
+			err = runGit(ctx, []string{"push"}, opts)
+		}
+	}
+	if aErr := audit(ctx, "flush", nil, err, opts); aErr != nil && err == nil {
+		return fmt.Errorf("write audit log: %w", aErr)
+	}
+	return err
+}
+
+// GitInit makes the store a git repository, as "pass git init" does,
+// committing its current contents. If remoteURL is not empty, it is added
+// as the remote "origin", or replaces its URL, and the commits are pushed
+// to it, which checks that the remote accepts pushes. A store that is
+// already a git repository is not initialized again.
+func GitInit(ctx context.Context, remoteURL string, options ...Option) error {
+	opts := resolveOptions(options)
+	defer lockNames(nil, true, opts)()
+	err := checkACL([]string{""}, Write, opts)
+	if err == nil {
+		err = gitInit(ctx, remoteURL, opts)
+	}
+	if aErr := audit(ctx, "git-init", nil, err, opts); aErr != nil && err == nil {
+		return fmt.Errorf("write audit log: %w", aErr)
+	}
+	return err
+}
+
+func gitInit(ctx context.Context, remoteURL string, opts *Options) error {
+	if !isGitRepo(opts) {
+		if err := runGit(ctx, []string{"init"}, opts); err != nil {
+			return err
+		}
+	}
+	if remoteURL == "" {
+		return nil
+	}
+	remotes, err := gitOutput(ctx, []string{"remote"}, opts)
+	if err != nil {
+		return err
+	}
+	args := []string{"remote", "add", "origin", remoteURL}
+	for _, r := range strings.Fields(string(remotes)) {
+		if r == "origin" {
+			args = []string{"remote", "set-url", "origin", remoteURL}
+		}
+	}
+	if err := runGit(ctx, args, opts); err != nil {
+		return err
+	}
+	if err := runGit(ctx, []string{"push", "--set-upstream", "origin", "HEAD"}, opts); err != nil {
+		return fmt.Errorf("push to %s: %w", remoteURL, err)
+	}
+	return nil
+}
+
+// ErrUnsignedCommit is returned by Sync when Options.RequireSignedCommits
+// is set and an incoming commit is not signed by an allowed key.
+var ErrUnsignedCommit = errors.New("commit is not signed by an allowed key")
+
+// gitConfigEnv returns environment variables that set the git
+// configuration keys to the values, given as key, value pairs.
+func gitConfigEnv(kv ...string) []string {
+	env := []string{fmt.Sprintf("GIT_CONFIG_COUNT=%d", len(kv)/2)}
+	for i := 0; i+1 < len(kv); i += 2 {
+		env = append(env,
+			fmt.Sprintf("GIT_CONFIG_KEY_%d=%s", i/2, kv[i]),
+			fmt.Sprintf("GIT_CONFIG_VALUE_%d=%s", i/2, kv[i+1]),
+		)
+	}
+	return env
+}
+
+// Sync fetches the upstream branch of the store, merges it, and pushes the
+// result. With Options.RequireSignedCommits set, the incoming commits are
+// verified first, and nothing is merged if any of them is not signed by
+// one of Options.AllowedSigners; the error then wraps ErrUnsignedCommit.
+func Sync(ctx context.Context, options ...Option) error {
+	opts := resolveOptions(options)
+	defer lockNames(nil, true, opts)()
+	return mutate(ctx, "sync", nil, opts, func() error {
+		if err := checkACL([]string{""}, Write, opts); err != nil {
+			return err
+		}
+		if err := runGit(ctx, []string{"fetch", "--quiet"}, opts); err != nil {
+			return err
+		}
+		if opts.RequireSignedCommits {
+			if err := verifyIncoming(ctx, opts); err != nil {
+				return err
+			}
+		}
+		if err := runGit(ctx, []string{"merge", "--quiet", "--no-edit", "@{upstream}"}, opts); err != nil {
+			return err
+		}
+		return runGit(ctx, []string{"push", "--quiet"}, opts)
+	})
+}
+
+// verifyIncoming checks that the commits in the upstream branch that are
+// not in HEAD are signed by an allowed key.
+func verifyIncoming(ctx context.Context, opts *Options) error {
+	out, err := gitOutput(ctx, []string{"log", "--format=%H %G? %GF %GP", "HEAD..@{upstream}"}, opts)
+	if err != nil {
+		return err
+	}
+	allowed := make(map[string]bool)
+	for _, fpr := range opts.AllowedSigners {
+		allowed[strings.ToUpper(strings.ReplaceAll(fpr, " ", ""))] = true
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		f := strings.Fields(line)
+		if len(f) == 0 {
+			continue
+		}
+		hash, status := f[0], f[1]
+		var ok bool
+		switch {
+		case len(allowed) == 0:
+			ok = status == "G"
+		case status == "G" || status == "U":
+			// The fingerprint of the signing key, and of its primary
+			// key if it is a subkey.
+			for _, fpr := range f[2:] {
+				ok = ok || allowed[fpr]
+			}
+		}
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnsignedCommit, hash)
+		}
+	}
+	return nil
+}
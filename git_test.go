@@ -0,0 +1,186 @@
+package pass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCommitMessageTemplate(t *testing.T) {
+	storeDir, err := ioutil.TempDir("", tmpDirPrefix)
+	if err != nil {
+		log.Fatalf("create tmp dir: %s", err)
+	}
+
+	opts := &Options{
+		StoreDir:              storeDir,
+		CommitMessageTemplate: "[TICKET-1] {{.Operation}} {{index .Names 0}}\n\n{{.Message}}",
+		GitAuthor:             "CI Bot <ci@example.com>",
+	}
+	ctx := context.Background()
+	err = Init(ctx, testGpgID, "", opts)
+	Ok(t, err)
+	err = Git(ctx, []string{"init"}, opts)
+	Ok(t, err)
+
+	err = Insert(ctx, "bar", []byte("my_password"), false, opts)
+	Ok(t, err)
+
+	out, err := gitOutput(ctx, []string{"log", "-1", "--format=%an <%ae>%n%B"}, opts)
+	if err != nil {
+		t.Fatalf("git log: %s", err)
+	}
+	lines := strings.Split(string(out), "\n")
+	if len(lines) < 2 {
+		t.Fatalf("unexpected git log output: %q", out)
+	}
+	Equal(t, "CI Bot <ci@example.com>", lines[0])
+	Equal(t, "[TICKET-1] insert bar", lines[1])
+}
+
+func TestAutoPush(t *testing.T) {
+	defer fakeCommand("pass", `shift; exec git -C "$PASSWORD_STORE_DIR" "$@"`)()
+
+	dir, err := ioutil.TempDir("", tmpDirPrefix)
+	if err != nil {
+		log.Fatalf("create tmp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	remote := filepath.Join(dir, "remote.git")
+
+	opts := &Options{
+		StoreDir: makeTestTree([]string{"a.gpg"}),
+		AutoPush: true,
+		Env: []string{
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+		},
+	}
+	defer os.RemoveAll(opts.StoreDir)
+	ctx := context.Background()
+	for _, args := range [][]string{
+		{"init"},
+		{"remote", "add", "origin", remote},
+		{"config", "remote.pushDefault", "origin"},
+		{"config", "push.default", "current"},
+	} {
+		Ok(t, runGit(ctx, args, opts))
+	}
+
+	// The remote does not exist yet, so the push fails.
+	Ok(t, Alias(ctx, "a", "b", opts))
+	pending, err := PendingPushes(ctx, opts)
+	Ok(t, err)
+	Equal(t, "1", fmt.Sprint(len(pending)))
+
+	Ok(t, exec.Command("git", "init", "--bare", remote).Run())
+	Ok(t, Flush(ctx, opts))
+	pending, err = PendingPushes(ctx, opts)
+	Ok(t, err)
+	Equal(t, "0", fmt.Sprint(len(pending)))
+
+	Ok(t, Alias(ctx, "a", "c", opts))
+	pending, err = PendingPushes(ctx, opts)
+	Ok(t, err)
+	Equal(t, "0", fmt.Sprint(len(pending)))
+}
+
+func TestGitInit(t *testing.T) {
+	defer fakeCommand("pass", `shift
+if [ "$1" = init ]; then
+	git -C "$PASSWORD_STORE_DIR" init -q && git -C "$PASSWORD_STORE_DIR" add -A &&
+		exec git -C "$PASSWORD_STORE_DIR" commit -q -m "Add current contents of password store."
+fi
+exec git -C "$PASSWORD_STORE_DIR" "$@"`)()
+
+	dir, err := ioutil.TempDir("", tmpDirPrefix)
+	if err != nil {
+		log.Fatalf("create tmp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	remote := filepath.Join(dir, "remote.git")
+	opts := &Options{
+		StoreDir:  makeTestTree([]string{".gpg-id", "a.gpg"}),
+		GitAuthor: "test <test@example.com>",
+	}
+	defer os.RemoveAll(opts.StoreDir)
+	ctx := context.Background()
+
+	// The remote does not exist yet, so the push fails.
+	err = GitInit(ctx, remote, opts)
+	Equal(t, "true", fmt.Sprint(err != nil && strings.HasPrefix(err.Error(), "push to "+remote)))
+
+	Ok(t, exec.Command("git", "init", "--bare", remote).Run())
+	Ok(t, GitInit(ctx, remote, opts))
+	pending, err := PendingPushes(ctx, opts)
+	Ok(t, err)
+	Equal(t, "0", fmt.Sprint(len(pending)))
+	out, err := exec.Command("git", "-C", remote, "log", "--format=%s").Output()
+	Ok(t, err)
+	Equal(t, "Add current contents of password store.\n", string(out))
+}
+
+func TestSyncSignedCommits(t *testing.T) {
+	defer fakeCommand("pass", `shift; exec git -C "$PASSWORD_STORE_DIR" "$@"`)()
+
+	dir, err := ioutil.TempDir("", tmpDirPrefix)
+	if err != nil {
+		log.Fatalf("create tmp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	env, fprs := testKeyring(t, filepath.Join(dir, "gnupg"), "Signer <signer@example.com>")
+	signer := fprs[0]
+
+	remote := filepath.Join(dir, "remote.git")
+	Ok(t, exec.Command("git", "init", "--quiet", "--bare", remote).Run())
+	ctx := context.Background()
+	newStore := func(name string, signed bool) *Options {
+		opts := &Options{
+			StoreDir:             filepath.Join(dir, name),
+			GitAuthor:            "test <test@example.com>",
+			Env:                  env,
+			RequireSignedCommits: signed,
+			CommitSigningKey:     signer,
+			AllowedSigners:       []string{signer},
+		}
+		Ok(t, exec.Command("git", "clone", "--quiet", remote, opts.StoreDir).Run())
+		return opts
+	}
+
+	a := newStore("a", true)
+	writeTestFile(t, a.StoreDir, ".gpg-id", "")
+	Ok(t, commitFiles(ctx, "init", []string{".gpg-id"}, a))
+	Ok(t, runGit(ctx, []string{"push", "--quiet", "--set-upstream", "origin", "HEAD"}, a))
+	b := newStore("b", true)
+	unsigned := newStore("unsigned", false)
+
+	writeTestFile(t, a.StoreDir, "x.gpg", "")
+	Ok(t, commitFiles(ctx, "add x", []string{"x.gpg"}, a))
+	Ok(t, runGit(ctx, []string{"push", "--quiet"}, a))
+	Ok(t, Sync(ctx, b))
+	_, err = os.Stat(filepath.Join(b.StoreDir, "x.gpg"))
+	Ok(t, err)
+
+	Ok(t, runGit(ctx, []string{"pull", "--quiet"}, unsigned))
+	writeTestFile(t, unsigned.StoreDir, "y.gpg", "")
+	Ok(t, commitFiles(ctx, "add y", []string{"y.gpg"}, unsigned))
+	Ok(t, runGit(ctx, []string{"push", "--quiet"}, unsigned))
+
+	before, err := gitHead(ctx, b)
+	Ok(t, err)
+	err = Sync(ctx, b)
+	if !errors.Is(err, ErrUnsignedCommit) {
+		t.Fatalf("expected ErrUnsignedCommit, got: %v", err)
+	}
+	after, err := gitHead(ctx, b)
+	Ok(t, err)
+	Equal(t, before, after)
+}
@@ -0,0 +1,230 @@
+package pass
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// gpgProgram returns the gpg program to use. Like pass, it prefers gpg2
+// when it is available.
+func gpgProgram() string {
+	if _, err := exec.LookPath("gpg2"); err == nil {
+		return "gpg2"
+	}
+	return "gpg"
+}
+
+// execGPG runs gpg with the args, using Options.Runner and Options.Env.
+func execGPG(ctx context.Context, args []string, stdin io.Reader, opts *Options) (stdout, stderr []byte, err error) {
+	env := append(baseEnv(opts), "LC_ALL=C")
+	return runCommand(ctx, "gpg", gpgProgram(), args, env, stdin, nil, opts)
+}
+
+type statusLine struct {
+	keyword string
+	args    []string
+}
+
+// statusLines parses the lines written by gpg to its --status-fd. Other
+// lines in b are ignored.
+func statusLines(b []byte) []statusLine {
+	const prefix = "[GNUPG:] "
+
+	var ret []statusLine
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	for sc.Scan() {
+		line := sc.Text()
+		if !strings.HasPrefix(line, prefix) {
+			continue
+		}
+		fields := strings.Fields(strings.TrimPrefix(line, prefix))
+		if len(fields) == 0 {
+			continue
+		}
+		ret = append(ret, statusLine{keyword: fields[0], args: fields[1:]})
+	}
+	return ret
+}
+
+// colonRecords splits gpg --with-colons output into records of fields.
+func colonRecords(b []byte) [][]string {
+	var ret [][]string
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	for sc.Scan() {
+		if line := sc.Text(); line != "" {
+			ret = append(ret, strings.Split(line, ":"))
+		}
+	}
+	return ret
+}
+
+// secretKeyLocations returns the long key IDs of the secret keys (and
+// subkeys) in the keyring, split into those stored on a smartcard and
+// those stored on disk.
+func secretKeyLocations(ctx context.Context, opts *Options) (card, disk map[string]bool, err error) {
+	stdout, _, err := execGPG(ctx, []string{"--batch", "--with-colons", "--list-secret-keys"}, nil, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	card = make(map[string]bool)
+	disk = make(map[string]bool)
+	for _, fields := range colonRecords(stdout) {
+		if (fields[0] != "sec" && fields[0] != "ssb") || len(fields) < 15 {
+			continue
+		}
+		// Field 15 holds the serial number of the card for keys stored on
+		// a card, and "#" for stubs whose secret key is not available.
+		switch keyID, sn := fields[4], fields[14]; sn {
+		case "", "+":
+			disk[keyID] = true
+		case "#":
+		default:
+			card[keyID] = true
+		}
+	}
+	return card, disk, nil
+}
+
+// cardWatcher inspects the gpg status lines written during decryption and
+// calls onWait if the decryption is going to use a key stored on a
+// smartcard.
+type cardWatcher struct {
+	card, disk map[string]bool
+	onWait     func()
+
+	decided bool // the key used for decryption is known
+	waiting bool // decryption is waiting on the smartcard
+}
+
+func (w *cardWatcher) line(s statusLine) {
+	switch s.keyword {
+	case "ENC_TO":
+		if w.decided || len(s.args) == 0 {
+			return
+		}
+		switch keyID := s.args[0]; {
+		case w.disk[keyID]:
+			w.decided = true
+		case w.card[keyID]:
+			w.decided = true
+			w.waiting = true
+			w.onWait()
+		}
+	case "BEGIN_DECRYPTION", "DECRYPTION_OKAY", "DECRYPTION_FAILED":
+		w.decided = true
+		w.waiting = false
+	}
+}
+
+// statusWriter is an io.Writer that calls fn for each gpg status line
+// written to it.
+type statusWriter struct {
+	fn  func(statusLine)
+	buf []byte
+}
+
+func (w *statusWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i == -1 {
+			break
+		}
+		for _, s := range statusLines(w.buf[:i+1]) {
+			w.fn(s)
+		}
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// withoutStatusLines returns b with the gpg status lines removed, for use
+// in error messages.
+func withoutStatusLines(b []byte) []byte {
+	var ret []byte
+	for _, line := range bytes.SplitAfter(b, []byte("\n")) {
+		if !bytes.HasPrefix(line, []byte("[GNUPG:] ")) {
+			ret = append(ret, line...)
+		}
+	}
+	return bytes.TrimSpace(ret)
+}
+
+// Errors reported by gpg, as classified using its status lines.
+var (
+	ErrBadPassphrase    = errors.New("bad passphrase")
+	ErrNoSecretKey      = errors.New("no secret key")
+	ErrDecryptionFailed = errors.New("decryption failed")
+	ErrInvalidRecipient = errors.New("invalid recipient")
+)
+
+// ErrInteractionRequired is returned when a command needed input from a
+// user, such as a pinentry or credential prompt, and was not allowed to ask
+// for it. See Options.NonInteractive.
+var ErrInteractionRequired = errors.New("interaction required")
+
+// gpg-error codes, from libgpg-error's err-codes.h.
+const (
+	gpgErrNoPublicKey   = 9
+	gpgErrBadPassphrase = 11
+	gpgErrNoSecretKey   = 17
+	gpgErrNoPinentry    = 85
+)
+
+// statusError returns the error described by the gpg status lines in b, or
+// nil if they don't describe a known error.
+func statusError(b []byte) error {
+	var decryptionFailed, noSecretKey, badPassphrase, invalidRecipient, noPinentry bool
+
+	for _, s := range statusLines(b) {
+		switch s.keyword {
+		case "BAD_PASSPHRASE":
+			badPassphrase = true
+		case "NO_SECKEY":
+			noSecretKey = true
+		case "INV_RECP", "INV_SGNR":
+			invalidRecipient = true
+		case "DECRYPTION_FAILED":
+			decryptionFailed = true
+		case "ERROR":
+			if len(s.args) < 2 {
+				continue
+			}
+			code, err := strconv.ParseUint(s.args[1], 10, 32)
+			if err != nil {
+				continue
+			}
+			// The low 16 bits hold the error code; the high bits
+			// identify the component reporting it.
+			switch code & 0xffff {
+			case gpgErrBadPassphrase:
+				badPassphrase = true
+			case gpgErrNoSecretKey:
+				noSecretKey = true
+			case gpgErrNoPinentry:
+				noPinentry = true
+			}
+		}
+	}
+
+	switch {
+	case noPinentry:
+		return ErrInteractionRequired
+	case badPassphrase:
+		return ErrBadPassphrase
+	case invalidRecipient:
+		return ErrInvalidRecipient
+	case decryptionFailed && noSecretKey:
+		return ErrNoSecretKey
+	case decryptionFailed:
+		return ErrDecryptionFailed
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package pass
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/exec"
+	"testing"
+)
+
+func TestCardWatcher(t *testing.T) {
+	calls := 0
+	w := &cardWatcher{
+		card:   map[string]bool{"CCCCCCCCCCCCCCCC": true},
+		disk:   map[string]bool{"DDDDDDDDDDDDDDDD": true},
+		onWait: func() { calls++ },
+	}
+	sw := &statusWriter{fn: w.line}
+
+	sw.Write([]byte("[GNUPG:] ENC_TO AAAAAAAAAAAAAAAA 1 0\n[GNUPG:] ENC_TO CCCC"))
+	sw.Write([]byte("CCCCCCCCCCCC 1 0\ngpg: some message\n"))
+	if calls != 1 || !w.waiting {
+		t.Errorf("expected to be waiting on card after 1 call, got %d calls", calls)
+		return
+	}
+
+	sw.Write([]byte("[GNUPG:] BEGIN_DECRYPTION\n"))
+	if w.waiting {
+		t.Errorf("expected to not be waiting on card after decryption began")
+	}
+
+	w = &cardWatcher{
+		card:   map[string]bool{"CCCCCCCCCCCCCCCC": true},
+		disk:   map[string]bool{"DDDDDDDDDDDDDDDD": true},
+		onWait: func() { calls++ },
+	}
+	w.line(statusLine{keyword: "ENC_TO", args: []string{"DDDDDDDDDDDDDDDD"}})
+	w.line(statusLine{keyword: "ENC_TO", args: []string{"CCCCCCCCCCCCCCCC"}})
+	if calls != 1 || w.waiting {
+		t.Errorf("expected on-disk key to be preferred")
+	}
+}
+
+func TestWithoutStatusLines(t *testing.T) {
+	b := []byte("[GNUPG:] ENC_TO AAAAAAAAAAAAAAAA 1 0\ngpg: decryption failed: No secret key\n[GNUPG:] DECRYPTION_FAILED\n")
+	Equal(t, "gpg: decryption failed: No secret key", string(withoutStatusLines(b)))
+}
+
+func TestStatusError(t *testing.T) {
+	testcases := []struct {
+		stderr   string
+		expected error
+	}{
+		{
+			stderr: `[GNUPG:] ENC_TO D1BC414B0E928F8B 1 0
+gpg: public key decryption failed: Bad passphrase
+[GNUPG:] ERROR pkdecrypt_failed 67108875
+[GNUPG:] BEGIN_DECRYPTION
+[GNUPG:] DECRYPTION_FAILED
+gpg: decryption failed: No secret key
+[GNUPG:] END_DECRYPTION
+`,
+			expected: ErrBadPassphrase,
+		},
+		{
+			stderr: `[GNUPG:] ENC_TO D1BC414B0E928F8B 1 0
+[GNUPG:] NO_SECKEY D1BC414B0E928F8B
+[GNUPG:] BEGIN_DECRYPTION
+[GNUPG:] DECRYPTION_FAILED
+gpg: decryption failed: No secret key
+[GNUPG:] END_DECRYPTION
+`,
+			expected: ErrNoSecretKey,
+		},
+		{
+			stderr: `[GNUPG:] ENC_TO D1BC414B0E928F8B 1 0
+gpg: public key decryption failed: No pinentry
+[GNUPG:] ERROR pkdecrypt_failed 67108949
+[GNUPG:] BEGIN_DECRYPTION
+[GNUPG:] DECRYPTION_FAILED
+gpg: decryption failed: No secret key
+[GNUPG:] END_DECRYPTION
+`,
+			expected: ErrInteractionRequired,
+		},
+		{
+			stderr: `gpg: nobody@example.com: skipped: No name
+[GNUPG:] INV_RECP 0 nobody@example.com
+[GNUPG:] FAILURE encrypt 167772380
+`,
+			expected: ErrInvalidRecipient,
+		},
+		{
+			stderr:   "Error: foo is not in the password store.\n",
+			expected: nil,
+		},
+	}
+
+	for _, tc := range testcases {
+		if got := statusError([]byte(tc.stderr)); got != tc.expected {
+			t.Errorf("expected: %v, got: %v", tc.expected, got)
+		}
+	}
+}
+
+// testKeyring creates a gpg home directory with a key without a passphrase
+// for each of the user IDs. It returns the environment for using it and the
+// fingerprints of the keys.
+func testKeyring(t *testing.T, gnupgHome string, uids ...string) (env, fprs []string) {
+	t.Helper()
+	if err := os.MkdirAll(gnupgHome, 0700); err != nil {
+		log.Fatalf("mkdir: %s", err)
+	}
+	env = []string{"GNUPGHOME=" + gnupgHome}
+	t.Cleanup(func() { exec.Command("gpgconf", "--homedir", gnupgHome, "--kill", "all").Run() })
+
+	for _, uid := range uids {
+		cmd := exec.Command("gpg", "--batch", "--passphrase", "", "--quick-gen-key", uid, "future-default", "default", "never")
+		cmd.Env = append(os.Environ(), env...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("generate key: %s: %s", err, out)
+		}
+	}
+
+	out, _, err := execGPG(context.Background(), []string{"--with-colons", "--list-keys"}, nil, &Options{Env: env})
+	Ok(t, err)
+	primary := false
+	for _, fields := range colonRecords(out) {
+		switch fields[0] {
+		case "pub":
+			primary = true
+		case "sub":
+			primary = false
+		case "fpr":
+			if primary {
+				fprs = append(fprs, fields[9])
+			}
+		}
+	}
+	return env, fprs
+}
@@ -0,0 +1,111 @@
+package pass
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// ErrSecretInArgs is returned when a command would be run with a secret
+// in its arguments or environment, which other users can read through
+// /proc or ps on some systems. Secrets are only given to commands on
+// their standard input.
+var ErrSecretInArgs = errors.New("secret in command arguments or environment")
+
+// HardenProcess hardens the current process and the processes it starts
+// afterwards. It disables core dumps, so that decrypted entries are not
+// written to disk when a process crashes, and sets the umask to 077, so
+// that the files created are only readable by the user. On Linux it also
+// marks the process as not dumpable, so that processes of the same user
+// cannot attach to it or read its memory. On systems without these
+// controls, it does what it can.
+func HardenProcess() error {
+	return hardenProcess()
+}
+
+var (
+	hardenOnce sync.Once
+	hardenErr  error
+)
+
+// harden applies Options.Harden before running a command. See the Harden
+// field for the threat model.
+func harden(opts *Options) error {
+	if opts == nil || !opts.Harden {
+		return nil
+	}
+	hardenOnce.Do(func() { hardenErr = hardenProcess() })
+	if hardenErr != nil {
+		return fmt.Errorf("harden process: %w", hardenErr)
+	}
+	return nil
+}
+
+// checkArgs returns ErrSecretInArgs if args pass a passphrase to gpg, hold
+// a URL with a password, or hold one of the passphrases as the value of a
+// flag, such as "--passphrase-file=x" for the passphrase x, or if env
+// holds one as a value. Other args equal to a passphrase, such as an entry
+// name that happens to match it, are allowed.
+func checkArgs(args, env, passphrases []string) error {
+	for _, a := range args {
+		if a == "--passphrase" || strings.HasPrefix(a, "--passphrase=") {
+			return fmt.Errorf("%w: %s", ErrSecretInArgs, "--passphrase")
+		}
+		if strings.HasPrefix(a, "-") {
+			for _, p := range passphrases {
+				if p != "" && strings.HasSuffix(a, "="+p) {
+					return fmt.Errorf("%w: passphrase", ErrSecretInArgs)
+				}
+			}
+		}
+		if strings.Contains(a, "://") {
+			if u, err := url.Parse(a); err == nil && u.User != nil {
+				if _, ok := u.User.Password(); ok {
+					return fmt.Errorf("%w: password in URL %s", ErrSecretInArgs, u.Redacted())
+				}
+			}
+		}
+	}
+	for _, e := range env {
+		i := strings.IndexByte(e, '=')
+		if i == -1 {
+			continue
+		}
+		for _, p := range passphrases {
+			if p != "" && e[i+1:] == p {
+				return fmt.Errorf("%w: %s", ErrSecretInArgs, e[:i])
+			}
+		}
+	}
+	return nil
+}
+
+// passphraseInput is the input of a command that is a passphrase, so that
+// runCommandFiles can check that the passphrase is not also in the
+// arguments or environment of the command.
+type passphraseInput struct {
+	*strings.Reader
+	passphrase string
+}
+
+func passphraseReader(p string) io.Reader {
+	return &passphraseInput{strings.NewReader(p), p}
+}
+
+// commandPassphrases returns Options.Passphrase and the passphrases given
+// to a command as input.
+func commandPassphrases(stdin io.Reader, extra []io.Reader, opts *Options) []string {
+	var ret []string
+	if opts != nil && opts.Passphrase != "" {
+		ret = append(ret, string(opts.Passphrase))
+	}
+	for _, r := range append([]io.Reader{stdin}, extra...) {
+		if in, ok := r.(*passphraseInput); ok && in.passphrase != "" {
+			ret = append(ret, in.passphrase)
+		}
+	}
+	return ret
+}
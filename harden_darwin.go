@@ -0,0 +1,11 @@
+package pass
+
+import "syscall"
+
+func hardenProcess() error {
+	if err := syscall.Setrlimit(syscall.RLIMIT_CORE, &syscall.Rlimit{}); err != nil {
+		return err
+	}
+	syscall.Umask(0077)
+	return nil
+}
@@ -0,0 +1,16 @@
+package pass
+
+import "syscall"
+
+func hardenProcess() error {
+	if err := syscall.Setrlimit(syscall.RLIMIT_CORE, &syscall.Rlimit{}); err != nil {
+		return err
+	}
+	// PR_SET_DUMPABLE is reset by execve, so it only protects this
+	// process; gpg-agent disables it for itself.
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, syscall.PR_SET_DUMPABLE, 0, 0); errno != 0 {
+		return errno
+	}
+	syscall.Umask(0077)
+	return nil
+}
@@ -0,0 +1,8 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package pass
+
+func hardenProcess() error {
+	return nil
+}
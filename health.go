@@ -0,0 +1,127 @@
+package pass
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// HealthCheck is the result of one of the checks of Healthz.
+type HealthCheck struct {
+	Name     string        `json:"name"`
+	Error    string        `json:"error,omitempty"` // Empty if the check passed.
+	Duration time.Duration `json:"duration"`
+}
+
+// HealthReport is the result of Healthz.
+type HealthReport struct {
+	OK     bool          `json:"ok"` // Whether all checks passed.
+	Checks []HealthCheck `json:"checks"`
+}
+
+// Healthz checks that the store can be used, for the liveness and
+// readiness probes of servers that embed the package. The checks are:
+//
+//	pass       pass can be run
+//	gpg        gpg can be run
+//	gpg-agent  gpg-agent responds
+//	store      the store can be read and is initialized
+//	git-remote each git remote of the store can be reached, if any
+//
+// Use a ctx with a deadline, as an unreachable remote may take long to
+// time out. Git is not allowed to prompt for credentials.
+func Healthz(ctx context.Context, options ...Option) *HealthReport {
+	opts := resolveOptions(options)
+	report := &HealthReport{OK: true}
+	check := func(name string, fn func() error) {
+		start := time.Now()
+		c := HealthCheck{Name: name}
+		if err := fn(); err != nil {
+			c.Error = err.Error()
+			report.OK = false
+		}
+		c.Duration = time.Since(start)
+		report.Checks = append(report.Checks, c)
+	}
+
+	check("pass", func() error {
+		_, _, err := runCommand(ctx, "version", "pass", []string{"version"}, baseEnv(opts), nil, nil, opts)
+		return err
+	})
+	check("gpg", func() error {
+		_, _, err := execGPG(ctx, []string{"--version"}, nil, opts)
+		return err
+	})
+	check("gpg-agent", func() error {
+		stdout, _, err := runCommand(ctx, "gpg-connect-agent", "gpg-connect-agent", []string{"GETINFO version", "/bye"}, baseEnv(opts), nil, nil, opts)
+		if err != nil {
+			return err
+		}
+		for _, line := range strings.Split(string(stdout), "\n") {
+			if strings.HasPrefix(line, "ERR ") {
+				return errors.New(strings.TrimPrefix(line, "ERR "))
+			}
+		}
+		return nil
+	})
+	check("store", func() error {
+		storeDir := resolveStoreDir(opts)
+		if _, err := ioutil.ReadDir(storeDir); err != nil {
+			return err
+		}
+		if _, err := os.Stat(filepath.Join(storeDir, ".gpg-id")); err != nil {
+			return errors.New("store is not initialized")
+		}
+		return nil
+	})
+
+	if !isGitRepo(opts) {
+		return report
+	}
+	gitOpts := *opts
+	gitOpts.NonInteractive = true
+	remotes, err := gitOutput(ctx, []string{"remote"}, &gitOpts)
+	if err != nil {
+		check("git-remote", func() error { return err })
+		return report
+	}
+	for _, remote := range strings.Fields(string(remotes)) {
+		check("git-remote "+remote, func() error {
+			_, err := gitOutput(ctx, []string{"ls-remote", "--heads", remote}, &gitOpts)
+			return err
+		})
+	}
+	return report
+}
+
+// HealthHandler returns an http.Handler that serves the report of Healthz
+// as JSON, with the status 503 if a check failed. Each request runs the
+// checks for at most timeout, if it is positive.
+func HealthHandler(timeout time.Duration, options ...Option) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		report := Healthz(ctx, options...)
+		b, err := json.Marshal(report)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("marshal report: %s", err), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if !report.OK {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		w.Write(b)
+	})
+}
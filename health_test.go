@@ -0,0 +1,66 @@
+package pass
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHealthz(t *testing.T) {
+	storeDir := makeTestTree([]string{".git/HEAD"})
+	defer os.RemoveAll(storeDir)
+
+	var unreachable bool
+	runner := RunnerFunc(func(cmd *exec.Cmd) error {
+		args := strings.Join(cmd.Args[1:], " ")
+		switch {
+		case strings.HasSuffix(cmd.Args[0], "gpg-connect-agent"):
+			io.WriteString(cmd.Stdout, "D 2.4.0\nOK\n")
+		case args == "git remote":
+			io.WriteString(cmd.Stdout, "origin\nbackup\n")
+		case args == "git ls-remote --heads backup" && unreachable:
+			return errors.New("exit status 128")
+		}
+		return nil
+	})
+	opts := &Options{StoreDir: storeDir, Runner: runner}
+	ctx := context.Background()
+
+	report := Healthz(ctx, opts)
+	Equal(t, "false", fmt.Sprint(report.OK))
+	var names, failed []string
+	for _, c := range report.Checks {
+		names = append(names, c.Name)
+		if c.Error != "" {
+			failed = append(failed, c.Name+": "+c.Error)
+		}
+	}
+	Equal(t, "[pass gpg gpg-agent store git-remote origin git-remote backup]", fmt.Sprint(names))
+	Equal(t, "[store: store is not initialized]", fmt.Sprint(failed))
+
+	writeTestFile(t, storeDir, ".gpg-id", "alice@example.com\n")
+	srv := httptest.NewServer(HealthHandler(time.Second, opts))
+	defer srv.Close()
+	resp, err := srv.Client().Get(srv.URL)
+	Ok(t, err)
+	resp.Body.Close()
+	Equal(t, "200", fmt.Sprint(resp.StatusCode))
+
+	unreachable = true
+	resp, err = srv.Client().Get(srv.URL)
+	Ok(t, err)
+	defer resp.Body.Close()
+	Equal(t, "503", fmt.Sprint(resp.StatusCode))
+	var got HealthReport
+	Ok(t, json.NewDecoder(resp.Body).Decode(&got))
+	Equal(t, "git-remote backup", got.Checks[len(got.Checks)-1].Name)
+	Equal(t, "true", fmt.Sprint(got.Checks[len(got.Checks)-1].Error != ""))
+}
@@ -0,0 +1,118 @@
+package pass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ErrPushedHistory is returned by PurgeHistory when the history to be
+// rewritten was pushed to a remote, unless force is set.
+var ErrPushedHistory = errors.New("history was pushed")
+
+// PurgeHistory rewrites the git history of the store to remove every past
+// version of the named entry, for example after it was compromised, and
+// then prunes the removed objects from the repository. The entry must
+// already have been removed, and the store must have no uncommitted
+// changes. It rewrites local branches and tags only.
+//
+// If any version of the entry was pushed, PurgeHistory returns an error
+// wrapping ErrPushedHistory unless force is set. In that case the
+// rewritten branches have to be force pushed, and other clones of the
+// store recloned, or the entry comes back with the next merge; and a copy
+// of the entry should be assumed to exist wherever the store was pushed.
+//
+// The commits of the store get new hashes, so this should not be run while
+// others might change the store.
+func PurgeHistory(ctx context.Context, name string, force bool, options ...Option) error {
+	opts := resolveOptions(options)
+	defer lockNames(nil, true, opts)()
+	name = resolveName(name, opts)
+	err := checkACL([]string{name}, Write, opts)
+	if err == nil {
+		err = purgeHistory(ctx, name, force, opts)
+	}
+	if aErr := audit(ctx, "purge-history", []string{name}, err, opts); aErr != nil && err == nil {
+		return fmt.Errorf("write audit log: %w", aErr)
+	}
+	return err
+}
+
+func purgeHistory(ctx context.Context, name string, force bool, opts *Options) error {
+	if !isGitRepo(opts) {
+		return errors.New("store is not a git repository")
+	}
+	if entryExists(name, opts) {
+		return errors.New("entry exists; remove it first")
+	}
+	status, err := gitOutput(ctx, []string{"status", "--porcelain"}, opts)
+	if err != nil {
+		return err
+	}
+	if len(status) > 0 {
+		return errors.New("store has uncommitted changes")
+	}
+
+	p := filepath.ToSlash(filepath.Clean(name)) + ".gpg"
+	touched, err := gitOutput(ctx, []string{"log", "--all", "--format=%H", "--", p}, opts)
+	if err != nil {
+		return err
+	}
+	if len(strings.TrimSpace(string(touched))) == 0 {
+		return errors.New("entry is not in the history")
+	}
+	pushed, err := gitOutput(ctx, []string{"log", "--remotes", "--format=%H", "--", p}, opts)
+	if err != nil {
+		return err
+	}
+	if len(strings.TrimSpace(string(pushed))) > 0 && !force {
+		return fmt.Errorf("%s: %w", name, ErrPushedHistory)
+	}
+
+	return dropFromHistory(ctx, p, true, opts)
+}
+
+// dropFromHistory rewrites the local branches and tags of the store to
+// remove the file or folder p, a slash separated path relative to the
+// store, from every commit, and prunes the removed objects. Commits left
+// empty are dropped if pruneEmpty is set. The store must have no
+// uncommitted changes.
+func dropFromHistory(ctx context.Context, p string, pruneEmpty bool, opts *Options) error {
+	// The path is passed in the environment, so that it does not need to
+	// be quoted for the shell that runs the filter.
+	var filterOpts Options
+	if opts != nil {
+		filterOpts = *opts
+	}
+	filterOpts.Env = append(filterOpts.Env[:len(filterOpts.Env):len(filterOpts.Env)],
+		"GO_PASS_PURGE_PATH="+p, "FILTER_BRANCH_SQUELCH_WARNING=1")
+	args := []string{
+		"filter-branch", "--force",
+		"--index-filter", `git rm -q -r --cached --ignore-unmatch -- "$GO_PASS_PURGE_PATH"`,
+	}
+	if pruneEmpty {
+		args = append(args, "--prune-empty")
+	}
+	args = append(args, "--", "--branches", "--tags")
+	if err := runGit(ctx, args, &filterOpts); err != nil {
+		return fmt.Errorf("filter-branch: %w", err)
+	}
+
+	// Drop the backup refs and reflogs that still reference the old
+	// commits, so that the objects can be pruned.
+	refs, err := gitOutput(ctx, []string{"for-each-ref", "--format=%(refname)", "refs/original/"}, opts)
+	if err != nil {
+		return err
+	}
+	for _, ref := range strings.Fields(string(refs)) {
+		if err := runGit(ctx, []string{"update-ref", "-d", ref}, opts); err != nil {
+			return err
+		}
+	}
+	if err := runGit(ctx, []string{"reflog", "expire", "--expire=now", "--all"}, opts); err != nil {
+		return err
+	}
+	return runGit(ctx, []string{"gc", "--quiet", "--prune=now"}, opts)
+}
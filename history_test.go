@@ -0,0 +1,75 @@
+package pass
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPurgeHistory(t *testing.T) {
+	defer fakeCommand("pass", `shift; exec git -C "$PASSWORD_STORE_DIR" "$@"`)()
+
+	dir := makeTestTree(nil)
+	defer os.RemoveAll(dir)
+	opts := &Options{
+		StoreDir: filepath.Join(dir, "store"),
+		Env: []string{
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+		},
+	}
+	ctx := context.Background()
+	git := func(args ...string) string {
+		out, err := gitOutput(ctx, args, opts)
+		Ok(t, err)
+		return strings.TrimSpace(string(out))
+	}
+
+	writeTestFile(t, opts.StoreDir, "a.gpg", "leaked")
+	writeTestFile(t, opts.StoreDir, "b.gpg", "b")
+	git("init", "-q")
+	git("add", "-A")
+	git("commit", "-q", "-m", "Add a and b.")
+	blob := git("rev-parse", "HEAD:a.gpg")
+	writeTestFile(t, opts.StoreDir, "a.gpg", "leaked again")
+	git("commit", "-q", "-a", "-m", "Edit a.")
+
+	if err := PurgeHistory(ctx, "a", false, opts); err == nil {
+		t.Errorf("expected error for existing entry")
+	}
+	git("rm", "-q", "a.gpg")
+	git("commit", "-q", "-m", "Remove a.")
+
+	Ok(t, PurgeHistory(ctx, "a", false, opts))
+	Equal(t, "", git("log", "--all", "--format=%H", "--", "a.gpg"))
+	Equal(t, "Add a and b.", git("log", "--format=%s"))
+	if exec.Command("git", "-C", opts.StoreDir, "cat-file", "-e", blob).Run() == nil {
+		t.Errorf("expected blob of a to be pruned")
+	}
+	Equal(t, "b", git("show", "HEAD:b.gpg"))
+
+	// Pushed history.
+	writeTestFile(t, opts.StoreDir, "c.gpg", "c")
+	git("add", "c.gpg")
+	git("commit", "-q", "-m", "Add c.")
+	remote := filepath.Join(dir, "remote.git")
+	Ok(t, exec.Command("git", "init", "-q", "--bare", remote).Run())
+	git("remote", "add", "origin", remote)
+	git("push", "-q", "origin", "HEAD")
+	git("fetch", "-q", "origin")
+	if err := PurgeHistory(ctx, "b", false, opts); err == nil {
+		t.Errorf("expected error for existing entry")
+	}
+	git("rm", "-q", "b.gpg")
+	git("commit", "-q", "-m", "Remove b.")
+	if err := PurgeHistory(ctx, "b", false, opts); !errors.Is(err, ErrPushedHistory) {
+		t.Errorf("expected ErrPushedHistory, got: %v", err)
+	}
+	Ok(t, PurgeHistory(ctx, "b", true, opts))
+	Equal(t, "", git("log", "--branches", "--format=%H", "--", "b.gpg"))
+	Equal(t, "Add c.", git("log", "--format=%s"))
+}
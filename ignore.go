@@ -0,0 +1,150 @@
+package pass
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// DefaultIgnoreFile is the name of the ignore file used when
+// Options.IgnoreFile is empty.
+const DefaultIgnoreFile = ".passignore"
+
+// ignoreMatcher matches paths against the patterns of an ignore file. A nil
+// *ignoreMatcher matches nothing.
+type ignoreMatcher struct {
+	patterns []ignorePattern
+}
+
+type ignorePattern struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// loadIgnoreFile reads the ignore file in the root of the store. It returns
+// a nil matcher if the file does not exist.
+func loadIgnoreFile(opts *Options) (*ignoreMatcher, error) {
+	name := DefaultIgnoreFile
+	if opts != nil && opts.IgnoreFile != "" {
+		name = opts.IgnoreFile
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(resolveStoreDir(opts), name))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read ignore file: %w", err)
+	}
+	return parseIgnoreFile(b)
+}
+
+// parseIgnoreFile parses ignore file content, which uses the syntax of
+// .gitignore files.
+func parseIgnoreFile(b []byte) (*ignoreMatcher, error) {
+	m := &ignoreMatcher{}
+
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), " \t")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var p ignorePattern
+		if strings.HasPrefix(line, "!") {
+			p.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\`) {
+			line = line[1:] // escaped leading "#" or "!"
+		}
+		if strings.HasSuffix(line, "/") {
+			p.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+
+		// A pattern containing a slash is relative to the root of the
+		// store. Otherwise it matches a name at any level.
+		anchored := strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+
+		expr := globToRegexp(line)
+		if anchored {
+			expr = "^" + expr + "$"
+		} else {
+			expr = "^(.*/)?" + expr + "$"
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("bad ignore pattern %q: %s", sc.Text(), err)
+		}
+		p.re = re
+		m.patterns = append(m.patterns, p)
+	}
+	return m, nil
+}
+
+// globToRegexp converts a .gitignore style glob to a regular expression.
+func globToRegexp(glob string) string {
+	var sb strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; {
+		case strings.HasPrefix(glob[i:], "**/"):
+			sb.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end == -1 {
+				sb.WriteString(regexp.QuoteMeta(string(c)))
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + class + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			sb.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return sb.String()
+}
+
+// ignored reports whether the slash separated path rel, relative to the
+// root of the store, is ignored. As in .gitignore files, the last matching
+// pattern wins.
+func (m *ignoreMatcher) ignored(rel string, isDir bool) bool {
+	if m == nil {
+		return false
+	}
+	ret := false
+	for _, p := range m.patterns {
+		if p.dirOnly && !isDir {
+			continue
+		}
+		if p.re.MatchString(rel) {
+			ret = !p.negate
+		}
+	}
+	return ret
+}
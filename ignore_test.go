@@ -0,0 +1,80 @@
+package pass
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"testing"
+)
+
+func TestIgnoreMatcher(t *testing.T) {
+	m, err := parseIgnoreFile([]byte(`# scratch entries
+scratch/
+*.old.gpg
+/archive/2019
+!important.old.gpg
+docs/**/draft.gpg
+`))
+	Ok(t, err)
+
+	testcases := []struct {
+		rel      string
+		isDir    bool
+		expected bool
+	}{
+		{"scratch", true, true},
+		{"work/scratch", true, true},
+		{"scratch.gpg", false, false},
+		{"a.old.gpg", false, true},
+		{"work/b.old.gpg", false, true},
+		{"important.old.gpg", false, false},
+		{"archive/2019", true, true},
+		{"work/archive/2019", true, false},
+		{"docs/draft.gpg", false, true},
+		{"docs/a/b/draft.gpg", false, true},
+		{"a.gpg", false, false},
+	}
+	for _, tc := range testcases {
+		if got := m.ignored(tc.rel, tc.isDir); got != tc.expected {
+			t.Errorf("%s: expected: %t, got: %t", tc.rel, tc.expected, got)
+		}
+	}
+
+	var nilMatcher *ignoreMatcher
+	if nilMatcher.ignored("a.gpg", false) {
+		t.Errorf("expected nil matcher to match nothing")
+	}
+}
+
+func TestListIgnore(t *testing.T) {
+	storeDir := makeTestTree([]string{
+		"a.gpg",
+		"scratch/b.gpg",
+		"work/c.gpg",
+		"work/c.old.gpg",
+	})
+	err := ioutil.WriteFile(filepath.Join(storeDir, ".passignore"), []byte("scratch/\n*.old.gpg\n"), 0600)
+	if err != nil {
+		log.Fatalf("write ignore file: %s", err)
+	}
+
+	ctx := context.Background()
+	opts := &Options{StoreDir: storeDir}
+
+	ls, err := List(ctx, "", opts)
+	Ok(t, err)
+	Equal(t, "[a work/c]", fmt.Sprint(ls))
+
+	ls, err = ListFast(ctx, "", opts)
+	Ok(t, err)
+	Equal(t, "[a work/c]", fmt.Sprint(ls))
+
+	opts.IgnoreFile = "missing"
+	ls, err = List(ctx, "", opts)
+	Ok(t, err)
+	if len(ls) != 4 {
+		t.Errorf("expected 4 items, got %d", len(ls))
+	}
+}
@@ -0,0 +1,101 @@
+package pass
+
+import (
+	"encoding/json"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// The result types of the package encode to JSON with stable, lowercase
+// field names, so that programs built on the package can print them as
+// machine-readable output. Errors encode as their messages. Secrets, such
+// as ContentMatch.Text, encode as "[REDACTED]" like everywhere else.
+
+// MarshalText encodes the ChangeType as its String.
+func (t ChangeType) MarshalText() ([]byte, error) { return []byte(t.String()), nil }
+
+// MarshalJSON implements json.Marshaler.
+func (r FolderReport) MarshalJSON() ([]byte, error) {
+	type report FolderReport
+	return json.Marshal(struct {
+		report
+		Failed map[string]string `json:"failed,omitempty"`
+	}{report(r), errorStrings(r.Failed)})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (r ReencryptReport) MarshalJSON() ([]byte, error) {
+	type report ReencryptReport
+	return json.Marshal(struct {
+		report
+		Failed map[string]string `json:"failed,omitempty"`
+	}{report(r), errorStrings(r.Failed)})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (i LintIssue) MarshalJSON() ([]byte, error) {
+	var msg string
+	if i.Err != nil {
+		msg = i.Err.Error()
+	}
+	return json.Marshal(struct {
+		Name  string `json:"name"`
+		Error string `json:"error"`
+	}{i.Name, msg})
+}
+
+func errorStrings(m map[string]error) map[string]string {
+	if m == nil {
+		return nil
+	}
+	ret := make(map[string]string, len(m))
+	for k, err := range m {
+		ret[k] = err.Error()
+	}
+	return ret
+}
+
+// Tree is a folder of entries, such as the store, as a tree. Build it from
+// the names returned by List with NewTree.
+type Tree struct {
+	Name    string   `json:"name"`              // The base name of the folder; "" for the root.
+	Entries []string `json:"entries,omitempty"` // The base names of the entries in the folder.
+	Folders []*Tree  `json:"folders,omitempty"`
+}
+
+// NewTree returns the tree of the named entries. Entries and folders are
+// sorted by name.
+func NewTree(names []string) *Tree {
+	root := &Tree{}
+	for _, name := range names {
+		parts := strings.Split(path.Clean(filepath.ToSlash(name)), "/")
+		t := root
+		for _, dir := range parts[:len(parts)-1] {
+			t = t.folder(dir)
+		}
+		t.Entries = append(t.Entries, parts[len(parts)-1])
+	}
+	root.sort()
+	return root
+}
+
+func (t *Tree) folder(name string) *Tree {
+	for _, f := range t.Folders {
+		if f.Name == name {
+			return f
+		}
+	}
+	f := &Tree{Name: name}
+	t.Folders = append(t.Folders, f)
+	return f
+}
+
+func (t *Tree) sort() {
+	sort.Strings(t.Entries)
+	sort.Slice(t.Folders, func(i, j int) bool { return t.Folders[i].Name < t.Folders[j].Name })
+	for _, f := range t.Folders {
+		f.sort()
+	}
+}
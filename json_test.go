@@ -0,0 +1,70 @@
+package pass
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestJSON(t *testing.T) {
+	for _, tt := range []struct {
+		v        interface{}
+		expected string
+	}{
+		{
+			&FolderReport{Done: []string{"a"}, Failed: map[string]error{"b": errors.New("no key")}},
+			`{"done":["a"],"failed":{"b":"no key"}}`,
+		},
+		{
+			ReencryptReport{Reencrypted: []string{"a"}},
+			`{"reencrypted":["a"],"up_to_date":null}`,
+		},
+		{
+			[]LintIssue{{Name: "a", Err: errors.New("bad")}},
+			`[{"name":"a","error":"bad"}]`,
+		},
+		{
+			Change{Name: "a", Type: Removed},
+			`{"name":"a","type":"removed"}`,
+		},
+		{
+			ContentMatch{Name: "a", Line: 2, Text: Secret("user: me")},
+			`{"name":"a","line":2,"text":"[REDACTED]"}`,
+		},
+	} {
+		b, err := json.Marshal(tt.v)
+		Ok(t, err)
+		Equal(t, tt.expected, string(b))
+	}
+}
+
+func ExampleNewTree() {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(NewTree([]string{"web/github", "email", "web/gitlab", "web/aws/root"}))
+	// Output:
+	// {
+	//   "name": "",
+	//   "entries": [
+	//     "email"
+	//   ],
+	//   "folders": [
+	//     {
+	//       "name": "web",
+	//       "entries": [
+	//         "github",
+	//         "gitlab"
+	//       ],
+	//       "folders": [
+	//         {
+	//           "name": "aws",
+	//           "entries": [
+	//             "root"
+	//           ]
+	//         }
+	//       ]
+	//     }
+	//   ]
+	// }
+}
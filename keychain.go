@@ -0,0 +1,41 @@
+package pass
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+)
+
+// KeychainProvider is a PassphraseProvider that reads the passphrase from
+// a generic password item in the macOS Keychain, using the security
+// program. The item can be added with, for example:
+//
+//	security add-generic-password -s go-pass -a gpg -w
+type KeychainProvider struct {
+	Service string // The service name of the item.
+	Account string // Optional. The account name of the item.
+
+	// Optional. The keychain to search instead of the default search
+	// list.
+	Keychain string
+
+	// Optional. Runs the security program.
+	Runner Runner
+}
+
+// Passphrase implements PassphraseProvider.
+func (k KeychainProvider) Passphrase(ctx context.Context) (Passphrase, error) {
+	args := []string{"find-generic-password", "-s", k.Service}
+	if k.Account != "" {
+		args = append(args, "-a", k.Account)
+	}
+	args = append(args, "-w")
+	if k.Keychain != "" {
+		args = append(args, k.Keychain)
+	}
+	stdout, _, err := runCommand(ctx, "security", "security", args, nil, nil, nil, &Options{Runner: k.Runner})
+	if err != nil {
+		return "", fmt.Errorf("exec security: %w", err)
+	}
+	return Passphrase(bytes.TrimSuffix(stdout, []byte("\n"))), nil
+}
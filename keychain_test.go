@@ -0,0 +1,63 @@
+package pass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"testing"
+)
+
+func TestKeychainProvider(t *testing.T) {
+	defer fakeCommand("security", `
+[ "$*" = "find-generic-password -s go-pass -a gpg -w" ] || exit 44
+echo hunter2`)()
+
+	ctx := context.Background()
+	p, err := KeychainProvider{Service: "go-pass", Account: "gpg"}.Passphrase(ctx)
+	Ok(t, err)
+	Equal(t, "hunter2", string(p))
+
+	_, err = KeychainProvider{Service: "other"}.Passphrase(ctx)
+	if err == nil {
+		t.Errorf("expected error for missing item")
+	}
+}
+
+func TestPassphraseProvider(t *testing.T) {
+	storeDir := makeTestTree([]string{"a.gpg"})
+	defer os.RemoveAll(storeDir)
+
+	var stdin string
+	runner := RunnerFunc(func(cmd *exec.Cmd) error {
+		b, err := ioutil.ReadAll(cmd.Stdin)
+		stdin = string(b)
+		return err
+	})
+	calls := 0
+	provider := PassphraseFunc(func(ctx context.Context) (Passphrase, error) {
+		calls++
+		return "hunter2", nil
+	})
+	opts := &Options{StoreDir: storeDir, Runner: runner, PassphraseProvider: provider}
+	ctx := context.Background()
+
+	_, err := Show(ctx, "a", "", opts)
+	Ok(t, err)
+	Equal(t, "hunter2", stdin)
+
+	// An explicit passphrase is used as is.
+	_, err = Show(ctx, "a", "given", opts)
+	Ok(t, err)
+	Equal(t, "given", stdin)
+	Equal(t, "1", fmt.Sprint(calls))
+
+	errNoItem := errors.New("no item")
+	opts.PassphraseProvider = PassphraseFunc(func(ctx context.Context) (Passphrase, error) { return "", errNoItem })
+	_, err = Show(ctx, "a", "", opts)
+	if !errors.Is(err, errNoItem) {
+		t.Errorf("expected: %s, got: %v", errNoItem, err)
+	}
+}
@@ -0,0 +1,76 @@
+package pass
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// SecretServiceProvider is a PassphraseProvider that reads the passphrase
+// from the Secret Service of the desktop session, such as GNOME Keyring,
+// over D-Bus using the secret-tool program of libsecret. The item is the
+// one with all of the attributes; it can be added with, for example:
+//
+//	secret-tool store --label='go-pass' service go-pass account gpg
+type SecretServiceProvider struct {
+	Attributes map[string]string
+
+	// Optional. Runs the secret-tool program.
+	Runner Runner
+}
+
+// Passphrase implements PassphraseProvider.
+func (s SecretServiceProvider) Passphrase(ctx context.Context) (Passphrase, error) {
+	if len(s.Attributes) == 0 {
+		return "", errors.New("no attributes")
+	}
+	keys := make([]string, 0, len(s.Attributes))
+	for k := range s.Attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	args := []string{"lookup"}
+	for _, k := range keys {
+		args = append(args, k, s.Attributes[k])
+	}
+	stdout, _, err := runCommand(ctx, "secret-tool", "secret-tool", args, nil, nil, nil, &Options{Runner: s.Runner})
+	if err != nil {
+		// secret-tool fails without output if there is no such item.
+		return "", fmt.Errorf("exec secret-tool: %w", err)
+	}
+	return Passphrase(bytes.TrimSuffix(stdout, []byte("\n"))), nil
+}
+
+// KWalletProvider is a PassphraseProvider that reads the passphrase from
+// a password entry in KWallet, over D-Bus using the kwallet-query program.
+type KWalletProvider struct {
+	Wallet string // Optional. Defaults to "kdewallet".
+	Folder string // Optional. Defaults to "Passwords".
+	Entry  string
+
+	// Optional. Runs the kwallet-query program.
+	Runner Runner
+}
+
+// Passphrase implements PassphraseProvider.
+func (k KWalletProvider) Passphrase(ctx context.Context) (Passphrase, error) {
+	wallet, folder := k.Wallet, k.Folder
+	if wallet == "" {
+		wallet = "kdewallet"
+	}
+	if folder == "" {
+		folder = "Passwords"
+	}
+	args := []string{"--folder", folder, "--read-password", k.Entry, wallet}
+	stdout, _, err := runCommand(ctx, "kwallet-query", "kwallet-query", args, nil, nil, nil, &Options{Runner: k.Runner})
+	if err != nil {
+		return "", fmt.Errorf("exec kwallet-query: %w", err)
+	}
+	// kwallet-query exits successfully when the entry does not exist.
+	if bytes.HasPrefix(stdout, []byte("Failed to read entry")) {
+		return "", fmt.Errorf("entry %s not found", k.Entry)
+	}
+	return Passphrase(bytes.TrimSuffix(stdout, []byte("\n"))), nil
+}
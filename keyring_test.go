@@ -0,0 +1,38 @@
+package pass
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSecretServiceProvider(t *testing.T) {
+	defer fakeCommand("secret-tool", `
+[ "$*" = "lookup account gpg service go-pass" ] || exit 1
+printf hunter2`)()
+
+	ctx := context.Background()
+	p, err := SecretServiceProvider{Attributes: map[string]string{"service": "go-pass", "account": "gpg"}}.Passphrase(ctx)
+	Ok(t, err)
+	Equal(t, "hunter2", string(p))
+
+	_, err = SecretServiceProvider{Attributes: map[string]string{"service": "other"}}.Passphrase(ctx)
+	if err == nil {
+		t.Errorf("expected error for missing item")
+	}
+}
+
+func TestKWalletProvider(t *testing.T) {
+	defer fakeCommand("kwallet-query", `
+[ "$*" = "--folder Passwords --read-password go-pass kdewallet" ] || { echo "Failed to read entry $4 value from the $5 wallet."; exit 0; }
+echo hunter2`)()
+
+	ctx := context.Background()
+	p, err := KWalletProvider{Entry: "go-pass"}.Passphrase(ctx)
+	Ok(t, err)
+	Equal(t, "hunter2", string(p))
+
+	_, err = KWalletProvider{Entry: "other"}.Passphrase(ctx)
+	if err == nil {
+		t.Errorf("expected error for missing entry")
+	}
+}
@@ -0,0 +1,172 @@
+package pass
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExpiringSoonPeriod is the period within which a key's expiry causes
+// CheckKeys to report it as expiring soon.
+const ExpiringSoonPeriod = 30 * 24 * time.Hour
+
+// KeyStatus describes the health of a key that the store encrypts to.
+type KeyStatus struct {
+	GPGID        string    `json:"gpg_id"`                // The ID as listed in a .gpg-id file.
+	Fingerprint  string    `json:"fingerprint,omitempty"` // Empty if the key is missing.
+	Expires      time.Time `json:"expires"`               // Zero if the key does not expire.
+	Missing      bool      `json:"missing"`               // The public key is not in the keyring.
+	Expired      bool      `json:"expired"`
+	ExpiringSoon bool      `json:"expiring_soon"` // The key expires within ExpiringSoonPeriod.
+	Revoked      bool      `json:"revoked"`
+	HasSecretKey bool      `json:"has_secret_key"` // The secret key is in the keyring.
+}
+
+// CheckKeys reports the status of each key listed in the .gpg-id files of
+// the store. If a GPG ID matches more than one key, a status is reported
+// for each matching key.
+func CheckKeys(ctx context.Context, options ...Option) ([]KeyStatus, error) {
+	opts := resolveOptions(options)
+	gpgIDs, err := allGPGIDs(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var ret []KeyStatus
+
+	for _, id := range gpgIDs {
+		stdout, stderr, err := execGPG(ctx, []string{"--batch", "--status-fd=2", "--with-colons", "--list-keys", "--", id}, nil, opts)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if !noPublicKey(stderr) {
+				return nil, fmt.Errorf("list keys %s: %w", id, err)
+			}
+			ret = append(ret, KeyStatus{GPGID: id, Missing: true})
+			continue
+		}
+
+		secret, err := secretKeyFingerprints(ctx, id, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, s := range parsePublicKeys(stdout) {
+			s.GPGID = id
+			s.ExpiringSoon = !s.Expired && !s.Expires.IsZero() && s.Expires.Sub(now) < ExpiringSoonPeriod
+			s.HasSecretKey = secret[s.Fingerprint]
+			ret = append(ret, s)
+		}
+	}
+
+	return ret, nil
+}
+
+// noPublicKey reports whether the status lines in stderr of gpg
+// --list-keys report that no key matches.
+func noPublicKey(stderr []byte) bool {
+	for _, s := range statusLines(stderr) {
+		if s.keyword != "ERROR" || len(s.args) < 2 {
+			continue
+		}
+		code, err := strconv.ParseUint(s.args[1], 10, 32)
+		if err == nil && code&0xffff == gpgErrNoPublicKey {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePublicKeys parses the output of gpg --with-colons --list-keys into
+// a status per primary key. Only the key related fields are set.
+func parsePublicKeys(b []byte) []KeyStatus {
+	var ret []KeyStatus
+	var cur *KeyStatus
+
+	for _, fields := range colonRecords(b) {
+		switch fields[0] {
+		case "pub":
+			if len(fields) < 7 {
+				continue
+			}
+			ret = append(ret, KeyStatus{})
+			cur = &ret[len(ret)-1]
+			cur.Expired = strings.Contains(fields[1], "e")
+			cur.Revoked = strings.Contains(fields[1], "r")
+			if sec, err := strconv.ParseInt(fields[6], 10, 64); err == nil {
+				cur.Expires = time.Unix(sec, 0)
+			}
+		case "fpr":
+			// The first fpr record after a pub record belongs to the
+			// primary key; later ones belong to subkeys.
+			if cur != nil && cur.Fingerprint == "" && len(fields) > 9 {
+				cur.Fingerprint = fields[9]
+			}
+		}
+	}
+	return ret
+}
+
+// secretKeyFingerprints returns the fingerprints of the secret keys in the
+// keyring matching gpgID.
+func secretKeyFingerprints(ctx context.Context, gpgID string, opts *Options) (map[string]bool, error) {
+	ret := make(map[string]bool)
+
+	stdout, _, err := execGPG(ctx, []string{"--batch", "--with-colons", "--list-secret-keys", "--", gpgID}, nil, opts)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return ret, nil // no matching secret keys
+	}
+
+	inPrimary := false
+	for _, fields := range colonRecords(stdout) {
+		switch fields[0] {
+		case "sec":
+			inPrimary = true
+		case "ssb":
+			inPrimary = false
+		case "fpr":
+			if inPrimary && len(fields) > 9 {
+				ret[fields[9]] = true
+				inPrimary = false
+			}
+		}
+	}
+	return ret, nil
+}
+
+// allGPGIDs returns the GPG IDs listed in all the .gpg-id files of the
+// store, without duplicates.
+func allGPGIDs(opts *Options) ([]string, error) {
+	var ret []string
+
+	err := filepath.Walk(resolveStoreDir(opts), func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if info.IsDir() || info.Name() != ".gpg-id" {
+			return nil
+		}
+		ids, err := readGPGIDFile(p)
+		if err != nil {
+			return err
+		}
+		ret = append(ret, ids...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("find .gpg-id files: %w", err)
+	}
+	return uniqueSorted(ret), nil
+}
@@ -0,0 +1,74 @@
+package pass
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestParsePublicKeys(t *testing.T) {
+	output := `tru::1:1792142127:0:3:1:5
+pub:u:3072:1:9D29253656A0BA11:1792142123:1823678123::u:::scESC::::::23::0:
+fpr:::::::::B42E886A936611FAD92CD96D9D29253656A0BA11:
+uid:u::::1792142123::03DC22E9F897D3AE136C29CF64B7901137DAC1A2::Test <t@example.com>::::::::::0:
+sub:u:3072:1:D1BC414B0E928F8B:1792142123::::::e::::::23:
+fpr:::::::::053E15EE7C4FBA2F8C4C7562D1BC414B0E928F8B:
+pub:r:3072:1:1111111111111111:1592142123:::u:::sc::::::23::0:
+fpr:::::::::AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA:
+`
+	keys := parsePublicKeys([]byte(output))
+	if len(keys) != 2 {
+		t.Errorf("expected 2 keys, got %d", len(keys))
+		return
+	}
+
+	Equal(t, "B42E886A936611FAD92CD96D9D29253656A0BA11", keys[0].Fingerprint)
+	if !keys[0].Expires.Equal(time.Unix(1823678123, 0)) {
+		t.Errorf("wrong expiry: %s", keys[0].Expires)
+	}
+	if keys[0].Revoked || keys[0].Expired {
+		t.Errorf("expected first key to be valid")
+	}
+
+	Equal(t, "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA", keys[1].Fingerprint)
+	if !keys[1].Revoked {
+		t.Errorf("expected second key to be revoked")
+	}
+	if !keys[1].Expires.IsZero() {
+		t.Errorf("expected no expiry, got %s", keys[1].Expires)
+	}
+}
+
+func TestCheckKeysMissing(t *testing.T) {
+	storeDir := makeTestTree(nil)
+	defer os.RemoveAll(storeDir)
+	writeTestFile(t, storeDir, ".gpg-id", "missing@example.com\n")
+
+	var stderr string
+	runner := RunnerFunc(func(cmd *exec.Cmd) error {
+		io.WriteString(cmd.Stderr, stderr)
+		return errors.New("exit status 2")
+	})
+	opts := &Options{StoreDir: storeDir, Runner: runner}
+
+	stderr = "gpg: error reading key: No public key\n[GNUPG:] ERROR keylist.getkey 9\n"
+	keys, err := CheckKeys(context.Background(), opts)
+	Ok(t, err)
+	if len(keys) != 1 {
+		t.Fatalf("expected 1 key, got %d", len(keys))
+	}
+	Equal(t, "missing@example.com", keys[0].GPGID)
+	if !keys[0].Missing {
+		t.Errorf("expected key to be missing")
+	}
+
+	// Other gpg failures are not reported as a missing key.
+	stderr = "gpg: keydb_search failed: Permission denied\n"
+	if _, err := CheckKeys(context.Background(), opts); err == nil {
+		t.Errorf("expected error")
+	}
+}
@@ -0,0 +1,199 @@
+package pass
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// KMSCrypto is a Crypto that envelope encrypts entries with cloud KMS
+// keys, so that machines can read entries through their cloud identity
+// instead of holding gpg secret keys. Each entry is encrypted with a new
+// AES-256-GCM data key, which is encrypted with each of the KMS keys
+// listed in the .gpg-id file, one per line:
+//
+//	arn:aws:kms:us-east-1:111122223333:key/1234abcd-12ab-34cd-56ef-1234567890ab
+//	projects/p/locations/global/keyRings/r/cryptoKeys/k
+//
+// AWS keys (ARNs, or aliases like alias/go-pass) are used through the aws
+// program, and Google Cloud keys (resource names starting with
+// projects/) through the gcloud program, with their usual credentials.
+// Decrypting an entry needs access to one of its keys; the passphrase is
+// not used.
+type KMSCrypto struct {
+	// Optional. Runs the aws and gcloud programs.
+	Runner Runner
+}
+
+// kmsEnvelope is the content of a password file encrypted by KMSCrypto.
+type kmsEnvelope struct {
+	Keys       []kmsDataKey `json:"keys"`
+	Nonce      []byte       `json:"nonce"`
+	Ciphertext []byte       `json:"ciphertext"`
+}
+
+// kmsDataKey is the data key of an entry encrypted with a KMS key.
+type kmsDataKey struct {
+	Key     string `json:"key"`
+	DataKey []byte `json:"data_key"`
+}
+
+// Encrypt implements Crypto.
+func (k KMSCrypto) Encrypt(ctx context.Context, content []byte, recipients []string) ([]byte, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("no KMS keys")
+	}
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+	defer wipe(dataKey)
+
+	var env kmsEnvelope
+	for _, key := range recipients {
+		wrapped, err := k.wrap(ctx, key, dataKey)
+		if err != nil {
+			return nil, fmt.Errorf("encrypt data key with %s: %w", key, err)
+		}
+		env.Keys = append(env.Keys, kmsDataKey{Key: key, DataKey: wrapped})
+	}
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	env.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(env.Nonce); err != nil {
+		return nil, err
+	}
+	env.Ciphertext = aead.Seal(nil, env.Nonce, content, nil)
+	return json.Marshal(env)
+}
+
+// Decrypt implements Crypto. The data key is decrypted with the first of
+// the KMS keys of the entry that can decrypt it.
+func (k KMSCrypto) Decrypt(ctx context.Context, data []byte, passphrase string) ([]byte, error) {
+	var env kmsEnvelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("parse entry: %w", err)
+	}
+	var errs []string
+	for _, dk := range env.Keys {
+		dataKey, err := k.unwrap(ctx, dk.Key, dk.DataKey)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", dk.Key, err))
+			continue
+		}
+		defer wipe(dataKey)
+		aead, err := newGCM(dataKey)
+		if err != nil {
+			return nil, err
+		}
+		return aead.Open(nil, env.Nonce, env.Ciphertext, nil)
+	}
+	if len(errs) == 0 {
+		return nil, errors.New("no KMS keys")
+	}
+	return nil, fmt.Errorf("decrypt data key: %s", strings.Join(errs, "; "))
+}
+
+// RecipientKeys implements Crypto. The keys are the recipients.
+func (KMSCrypto) RecipientKeys(ctx context.Context, recipients []string) ([]string, error) {
+	for _, key := range recipients {
+		if kmsProvider(key) == "" {
+			return nil, fmt.Errorf("%w: %s", ErrMissingKey, key)
+		}
+	}
+	return recipients, nil
+}
+
+// EncryptedTo implements Crypto.
+func (KMSCrypto) EncryptedTo(ctx context.Context, data []byte) ([]string, error) {
+	var env kmsEnvelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("parse entry: %w", err)
+	}
+	var ret []string
+	for _, dk := range env.Keys {
+		ret = append(ret, dk.Key)
+	}
+	return ret, nil
+}
+
+// kmsProvider returns "aws" or "gcp" for the KMS key, or "" if it is not
+// a KMS key.
+func kmsProvider(key string) string {
+	switch {
+	case strings.HasPrefix(key, "arn:aws:kms:"), strings.HasPrefix(key, "alias/"):
+		return "aws"
+	case strings.HasPrefix(key, "projects/"):
+		return "gcp"
+	}
+	return ""
+}
+
+func (k KMSCrypto) wrap(ctx context.Context, key string, dataKey []byte) ([]byte, error) {
+	switch kmsProvider(key) {
+	case "aws":
+		args := append(awsRegionArgs(key), "kms", "encrypt", "--key-id", key,
+			"--plaintext", "fileb:///dev/stdin", "--query", "CiphertextBlob", "--output", "text")
+		stdout, err := k.run(ctx, "aws", args, dataKey)
+		if err != nil {
+			return nil, err
+		}
+		return base64.StdEncoding.DecodeString(strings.TrimSpace(string(stdout)))
+	case "gcp":
+		args := []string{"kms", "encrypt", "--key", key, "--plaintext-file", "-", "--ciphertext-file", "-"}
+		return k.run(ctx, "gcloud", args, dataKey)
+	}
+	return nil, fmt.Errorf("not a KMS key: %s", key)
+}
+
+func (k KMSCrypto) unwrap(ctx context.Context, key string, wrapped []byte) ([]byte, error) {
+	switch kmsProvider(key) {
+	case "aws":
+		args := append(awsRegionArgs(key), "kms", "decrypt", "--key-id", key,
+			"--ciphertext-blob", "fileb:///dev/stdin", "--query", "Plaintext", "--output", "text")
+		stdout, err := k.run(ctx, "aws", args, wrapped)
+		if err != nil {
+			return nil, err
+		}
+		defer wipe(stdout)
+		return base64.StdEncoding.DecodeString(strings.TrimSpace(string(stdout)))
+	case "gcp":
+		args := []string{"kms", "decrypt", "--key", key, "--ciphertext-file", "-", "--plaintext-file", "-"}
+		return k.run(ctx, "gcloud", args, wrapped)
+	}
+	return nil, fmt.Errorf("not a KMS key: %s", key)
+}
+
+func (k KMSCrypto) run(ctx context.Context, program string, args []string, stdin []byte) ([]byte, error) {
+	stdout, _, err := runCommand(ctx, program, program, args, nil, bytes.NewReader(stdin), nil, &Options{Runner: k.Runner})
+	if err != nil {
+		return nil, fmt.Errorf("exec %s: %w", program, err)
+	}
+	return stdout, nil
+}
+
+// awsRegionArgs returns the arguments that select the region of the key,
+// if it is an ARN. Otherwise the configured region is used.
+func awsRegionArgs(key string) []string {
+	if fields := strings.Split(key, ":"); len(fields) >= 4 && fields[0] == "arn" && fields[3] != "" {
+		return []string{"--region", fields[3]}
+	}
+	return nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
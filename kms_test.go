@@ -0,0 +1,54 @@
+package pass
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestKMSCrypto(t *testing.T) {
+	// The fake programs "encrypt" by adding a prefix, as aws and gcloud
+	// would output the ciphertext.
+	defer fakeCommand("aws", `
+case "$*" in
+"--region us-east-1 kms encrypt --key-id arn:aws:kms:us-east-1:1:key/a --plaintext fileb:///dev/stdin --query CiphertextBlob --output text")
+	{ printf aws; cat; } | base64 -w0 ;;
+"--region us-east-1 kms decrypt --key-id arn:aws:kms:us-east-1:1:key/a --ciphertext-blob fileb:///dev/stdin --query Plaintext --output text")
+	[ "$AWS_DENY" = 1 ] && exit 254
+	tail -c +4 | base64 -w0 ;;
+*) exit 2 ;;
+esac`)()
+	defer fakeCommand("gcloud", `
+case "$*" in
+"kms encrypt --key projects/p/cryptoKeys/k --plaintext-file - --ciphertext-file -") printf gcp; cat ;;
+"kms decrypt --key projects/p/cryptoKeys/k --ciphertext-file - --plaintext-file -") tail -c +4 ;;
+*) exit 2 ;;
+esac`)()
+
+	ctx := context.Background()
+	keys := []string{"arn:aws:kms:us-east-1:1:key/a", "projects/p/cryptoKeys/k"}
+	var k KMSCrypto
+	data, err := k.Encrypt(ctx, []byte("secret\n"), keys)
+	Ok(t, err)
+
+	got, err := k.EncryptedTo(ctx, data)
+	Ok(t, err)
+	Equal(t, fmt.Sprint(keys), fmt.Sprint(got))
+
+	content, err := k.Decrypt(ctx, data, "")
+	Ok(t, err)
+	Equal(t, "secret\n", string(content))
+
+	// Without access to the AWS key, the Google Cloud key is used.
+	os.Setenv("AWS_DENY", "1")
+	defer os.Unsetenv("AWS_DENY")
+	content, err = k.Decrypt(ctx, data, "")
+	Ok(t, err)
+	Equal(t, "secret\n", string(content))
+
+	_, err = k.RecipientKeys(ctx, []string{"ABCD1234"})
+	if err == nil {
+		t.Errorf("expected error for gpg key")
+	}
+}
@@ -0,0 +1,138 @@
+package pass
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// RenewFunc is called by a Lease when its TTL lapses, before the entry is
+// read again. It can, for example, rotate the entry.
+type RenewFunc func(ctx context.Context, name string) error
+
+// Lease is the content of an entry that is read again each time its TTL
+// lapses, so that long-running programs pick up rotated credentials. A
+// Lease is safe for concurrent use.
+type Lease struct {
+	name          string
+	gpgPassphrase string
+	ttl           time.Duration
+	renew         RenewFunc
+	opts          *Options
+
+	mu          sync.Mutex
+	secret      Secret
+	expires     time.Time
+	subscribers []func(Secret, error)
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// ShowLease is like Show, but returns a Lease that reads the entry again
+// every ttl, until ctx is done or the Lease is closed. If renew is not
+// nil, it is called before each read. The ttl must be positive.
+func ShowLease(ctx context.Context, name, gpgPassphrase string, ttl time.Duration, renew RenewFunc, options ...Option) (*Lease, error) {
+	if ttl <= 0 {
+		return nil, errors.New("ttl must be positive")
+	}
+	opts := resolveOptions(options)
+	content, err := Show(ctx, name, gpgPassphrase, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	l := &Lease{
+		name:          name,
+		gpgPassphrase: gpgPassphrase,
+		ttl:           ttl,
+		renew:         renew,
+		opts:          opts,
+		secret:        content,
+		expires:       time.Now().Add(ttl),
+		cancel:        cancel,
+		done:          make(chan struct{}),
+	}
+	go l.run(ctx)
+	return l, nil
+}
+
+// Secret returns the current content of the entry.
+func (l *Lease) Secret() Secret {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.secret
+}
+
+// Expires returns when the lease is next renewed.
+func (l *Lease) Expires() time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.expires
+}
+
+// Subscribe registers fn to be called, on the goroutine that renews the
+// lease, with the new content whenever a renewal finds that the content changed, or with
+// the error when a renewal fails. The current content is kept when a
+// renewal fails.
+func (l *Lease) Subscribe(fn func(Secret, error)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.subscribers = append(l.subscribers, fn)
+}
+
+// Close stops renewing the lease and waits for a renewal in progress, if
+// any, to finish.
+func (l *Lease) Close() error {
+	l.cancel()
+	<-l.done
+	return nil
+}
+
+func (l *Lease) run(ctx context.Context) {
+	defer close(l.done)
+
+	t := time.NewTimer(l.ttl)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+
+		content, err := l.read(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		l.mu.Lock()
+		l.expires = time.Now().Add(l.ttl)
+		changed := err == nil && !bytes.Equal(content, l.secret)
+		if changed {
+			l.secret = content
+		}
+		subscribers := make([]func(Secret, error), len(l.subscribers))
+		copy(subscribers, l.subscribers)
+		l.mu.Unlock()
+
+		if changed || err != nil {
+			for _, fn := range subscribers {
+				fn(content, err)
+			}
+		}
+		t.Reset(l.ttl)
+	}
+}
+
+func (l *Lease) read(ctx context.Context) (Secret, error) {
+	if l.renew != nil {
+		if err := l.renew(ctx, l.name); err != nil {
+			return nil, err
+		}
+	}
+	return Show(ctx, l.name, l.gpgPassphrase, l.opts)
+}
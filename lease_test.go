@@ -0,0 +1,77 @@
+package pass
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os/exec"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLease(t *testing.T) {
+	storeDir := makeTestTree([]string{"a.gpg"})
+
+	var mu sync.Mutex
+	content, renewals := "v1", 0
+	runner := RunnerFunc(func(cmd *exec.Cmd) error {
+		mu.Lock()
+		defer mu.Unlock()
+		io.WriteString(cmd.Stdout, content)
+		return nil
+	})
+	renew := func(ctx context.Context, name string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		renewals++
+		if renewals == 2 {
+			content = "v2"
+		}
+		if renewals == 3 {
+			return errors.New("rotate failed")
+		}
+		return nil
+	}
+
+	ctx := context.Background()
+	l, err := ShowLease(ctx, "a", "", 10*time.Millisecond, renew, WithStoreDir(storeDir), WithRunner(runner))
+	Ok(t, err)
+	defer l.Close()
+	Equal(t, "v1", string(l.Secret()))
+
+	type event struct {
+		content string
+		err     error
+	}
+	events := make(chan event, 10)
+	l.Subscribe(func(s Secret, err error) {
+		events <- event{string(s), err}
+	})
+
+	// The first renewal does not change the content, so the first event
+	// is from the second.
+	e := <-events
+	Equal(t, "v2", e.content)
+	Ok(t, e.err)
+	Equal(t, "v2", string(l.Secret()))
+
+	e = <-events
+	if e.err == nil {
+		t.Errorf("expected renewal error")
+	}
+	Equal(t, "v2", string(l.Secret()))
+
+	Ok(t, l.Close())
+}
+
+func TestLeaseBadTTL(t *testing.T) {
+	storeDir := makeTestTree([]string{"a.gpg"})
+	runner := RunnerFunc(func(cmd *exec.Cmd) error { return nil })
+
+	for _, ttl := range []time.Duration{0, -time.Second} {
+		if _, err := ShowLease(context.Background(), "a", "", ttl, nil, WithStoreDir(storeDir), WithRunner(runner)); err == nil {
+			t.Errorf("expected error for ttl %s", ttl)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package pass
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ListFast is like List, but is faster on large stores and on slow
+// filesystems. It avoids a stat call per file, and if
+// Options.ListConcurrency is greater than 1, it reads that many directories
+// in parallel. The result is the same as the result of List.
+func ListFast(ctx context.Context, subfolder string, options ...Option) ([]string, error) {
+	opts := resolveOptions(options)
+	storeDir := resolveStoreDir(opts)
+
+	targetDir := storeDir
+	if subfolder != "" {
+		targetDir = filepath.Join(storeDir, subfolder)
+	}
+	// The slash separated path of targetDir relative to storeDir.
+	targetRel := filepath.ToSlash(filepath.Clean(subfolder))
+	if subfolder == "" {
+		targetRel = "."
+	}
+
+	// Like filepath.Walk, fail if the target directory is missing.
+	if _, err := os.Lstat(targetDir); err != nil {
+		return nil, err
+	}
+
+	ignore, err := loadIgnoreFile(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	concurrency := 1
+	if opts != nil && opts.ListConcurrency > 1 {
+		concurrency = opts.ListConcurrency
+	}
+
+	var files []string // slash separated, relative to storeDir, with .gpg suffix
+	if concurrency == 1 {
+		err = readDirSequential(ctx, targetDir, targetRel, ignore, &files)
+	} else {
+		files, err = readDirParallel(ctx, targetDir, targetRel, ignore, concurrency)
+		sort.Slice(files, func(i, j int) bool {
+			return walkOrderLess(files[i], files[j])
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]string, len(files))
+	for i, f := range files {
+		ret[i] = filepath.FromSlash(strings.TrimSuffix(f, ".gpg"))
+	}
+	if opts != nil {
+		if err := sortEntries(ret, opts.SortMode, storeDir); err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+// readDirSequential appends the password files in dir, whose path relative
+// to the store is rel, to files. Like filepath.Walk, it visits directory
+// entries in lexical order.
+func readDirSequential(ctx context.Context, dir, rel string, ignore *ignoreMatcher, files *[]string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		switch {
+		case e.IsDir() && (e.Name() == ".git" || path.Join(rel, e.Name()) == trashDir):
+		case ignore.ignored(path.Join(rel, e.Name()), e.IsDir()):
+		case e.IsDir():
+			err := readDirSequential(ctx, filepath.Join(dir, e.Name()), path.Join(rel, e.Name()), ignore, files)
+			if err != nil {
+				return err
+			}
+		case strings.HasSuffix(e.Name(), ".gpg"):
+			*files = append(*files, path.Join(rel, e.Name()))
+		}
+	}
+	return nil
+}
+
+// readDirParallel returns the password files in dir and its subdirectories,
+// reading up to concurrency directories at once. The order of the returned
+// files is unspecified.
+func readDirParallel(ctx context.Context, dir, rel string, ignore *ignoreMatcher, concurrency int) ([]string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		files    []string
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	sem := make(chan struct{}, concurrency)
+
+	var visit func(dir, rel string)
+	visit = func(dir, rel string) {
+		defer wg.Done()
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+		entries, err := os.ReadDir(dir)
+		<-sem
+
+		if err != nil {
+			mu.Lock()
+			if firstErr == nil {
+				firstErr = err
+				cancel()
+			}
+			mu.Unlock()
+			return
+		}
+
+		for _, e := range entries {
+			switch {
+			case e.IsDir() && (e.Name() == ".git" || path.Join(rel, e.Name()) == trashDir):
+			case ignore.ignored(path.Join(rel, e.Name()), e.IsDir()):
+			case e.IsDir():
+				wg.Add(1)
+				go visit(filepath.Join(dir, e.Name()), path.Join(rel, e.Name()))
+			case strings.HasSuffix(e.Name(), ".gpg"):
+				mu.Lock()
+				files = append(files, path.Join(rel, e.Name()))
+				mu.Unlock()
+			}
+		}
+	}
+
+	wg.Add(1)
+	visit(dir, rel)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// walkOrderLess reports whether the slash separated path a is visited
+// before b by filepath.Walk.
+func walkOrderLess(a, b string) bool {
+	as, bs := strings.Split(a, "/"), strings.Split(b, "/")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] != bs[i] {
+			return as[i] < bs[i]
+		}
+	}
+	return len(as) < len(bs)
+}
@@ -0,0 +1,113 @@
+package pass
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// makeTestTree creates empty files at the slash separated paths in files,
+// under a new directory, and returns the directory.
+func makeTestTree(files []string) string {
+	dir, err := ioutil.TempDir("", tmpDirPrefix)
+	if err != nil {
+		log.Fatalf("create tmp dir: %s", err)
+	}
+	for _, f := range files {
+		p := filepath.Join(dir, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+			log.Fatalf("mkdir: %s", err)
+		}
+		if err := ioutil.WriteFile(p, nil, 0600); err != nil {
+			log.Fatalf("write file: %s", err)
+		}
+	}
+	return dir
+}
+
+func TestListFast(t *testing.T) {
+	storeDir := makeTestTree([]string{
+		".gpg-id",
+		".git/config",
+		".git/x.gpg",
+		"a.gpg",
+		"a/x.gpg",
+		"a-b.gpg",
+		"b/c/d.gpg",
+		"b/c/notes.txt",
+		"b/e.gpg",
+	})
+
+	ctx := context.Background()
+	for _, subfolder := range []string{"", "b"} {
+		expected, err := List(ctx, subfolder, &Options{StoreDir: storeDir})
+		Ok(t, err)
+
+		for _, concurrency := range []int{0, 4} {
+			got, err := ListFast(ctx, subfolder, &Options{StoreDir: storeDir, ListConcurrency: concurrency})
+			Ok(t, err)
+			Equal(t, fmt.Sprint(expected), fmt.Sprint(got))
+		}
+	}
+
+	_, err := ListFast(ctx, "missing", &Options{StoreDir: storeDir})
+	if err == nil {
+		t.Errorf("expected error for missing subfolder")
+	}
+}
+
+func benchmarkTree() string {
+	var files []string
+	for i := 0; i < 50; i++ {
+		for j := 0; j < 40; j++ {
+			files = append(files, fmt.Sprintf("site%d.com/sub/user%d.gpg", i, j))
+		}
+	}
+	return makeTestTree(files)
+}
+
+func BenchmarkList(b *testing.B) {
+	storeDir := benchmarkTree()
+	defer os.RemoveAll(storeDir)
+	opts := &Options{StoreDir: storeDir}
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := List(ctx, "", opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkListFast(b *testing.B) {
+	storeDir := benchmarkTree()
+	defer os.RemoveAll(storeDir)
+	opts := &Options{StoreDir: storeDir}
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ListFast(ctx, "", opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkListFastParallel(b *testing.B) {
+	storeDir := benchmarkTree()
+	defer os.RemoveAll(storeDir)
+	opts := &Options{StoreDir: storeDir, ListConcurrency: 8}
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ListFast(ctx, "", opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
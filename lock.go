@@ -0,0 +1,102 @@
+package pass
+
+import "sync"
+
+// storeLocks serializes the operations on a Store. Changes to an entry
+// exclude other changes to it and reads of it, but not operations on
+// other entries, except that changes to a git repository are serialized
+// because each of them commits. Operations on the store as a whole, such
+// as Batch and Git, exclude all others.
+type storeLocks struct {
+	store  sync.RWMutex // held for writing by store-wide operations
+	commit sync.Mutex   // held by changes to a git repository
+
+	mu      sync.Mutex
+	entries map[string]*entryLock
+}
+
+type entryLock struct {
+	sync.RWMutex
+	refs int
+}
+
+func newStoreLocks() *storeLocks {
+	return &storeLocks{entries: make(map[string]*entryLock)}
+}
+
+// lockNames locks the named entries for reading, or for writing if write is
+// set, or the whole store if write is set and names is nil, until the
+// returned function is called. It does nothing unless the options come from
+// a Store. Calls made with the same opts before the returned function is
+// called do not lock again, so that operations can be built from others.
+func lockNames(names []string, write bool, opts *Options) func() {
+	if opts == nil || opts.locks == nil {
+		return func() {}
+	}
+	l := opts.locks
+	opts.locks = nil
+
+	if write && names == nil {
+		l.store.Lock()
+		return func() {
+			l.store.Unlock()
+			opts.locks = l
+		}
+	}
+
+	l.store.RLock()
+	git := write && isGitRepo(opts)
+	if git {
+		l.commit.Lock()
+	}
+
+	// Lock in a fixed order, so that operations on several entries do not
+	// deadlock.
+	sorted := uniqueSorted(append([]string(nil), names...))
+	locks := make([]*entryLock, len(sorted))
+	for i, name := range sorted {
+		locks[i] = l.acquire(name)
+		if write {
+			locks[i].Lock()
+		} else {
+			locks[i].RLock()
+		}
+	}
+
+	return func() {
+		for i := len(locks) - 1; i >= 0; i-- {
+			if write {
+				locks[i].Unlock()
+			} else {
+				locks[i].RUnlock()
+			}
+			l.release(sorted[i])
+		}
+		if git {
+			l.commit.Unlock()
+		}
+		l.store.RUnlock()
+		opts.locks = l
+	}
+}
+
+func (l *storeLocks) acquire(name string) *entryLock {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e, ok := l.entries[name]
+	if !ok {
+		e = &entryLock{}
+		l.entries[name] = e
+	}
+	e.refs++
+	return e
+}
+
+func (l *storeLocks) release(name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e := l.entries[name]
+	if e.refs--; e.refs == 0 {
+		delete(l.entries, name)
+	}
+}
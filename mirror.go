@@ -0,0 +1,263 @@
+package pass
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrObjectNotFound is returned by an ObjectStore for keys that do not
+// exist.
+var ErrObjectNotFound = errors.New("object not found")
+
+// ObjectStore is a bucket of an object storage service, such as S3 or GCS,
+// that Mirror copies the store to. Keys are slash separated.
+type ObjectStore interface {
+	Put(ctx context.Context, key string, data []byte) error
+	// Get returns an error wrapping ErrObjectNotFound if there is no
+	// object with the key.
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// DirObjectStore is an ObjectStore that keeps objects as files in a
+// directory, for example on a mounted network filesystem.
+type DirObjectStore string
+
+func (d DirObjectStore) Put(ctx context.Context, key string, data []byte) error {
+	p := filepath.Join(string(d), filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(p, data, 0600)
+}
+
+func (d DirObjectStore) Get(ctx context.Context, key string) ([]byte, error) {
+	b, err := ioutil.ReadFile(filepath.Join(string(d), filepath.FromSlash(key)))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrObjectNotFound, key)
+	}
+	return b, err
+}
+
+func (d DirObjectStore) Delete(ctx context.Context, key string) error {
+	err := os.Remove(filepath.Join(string(d), filepath.FromSlash(key)))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// mirrorManifestKey is the key of the MirrorManifest in an ObjectStore.
+const mirrorManifestKey = "manifest.json"
+
+// mirrorFilesPrefix is the prefix of the keys of the files of the store.
+const mirrorFilesPrefix = "files/"
+
+// MirrorManifest lists the files of a store mirrored to an ObjectStore.
+type MirrorManifest struct {
+	Time     time.Time            `json:"time"`
+	Files    map[string]string    `json:"files"`            // slash separated path -> hex SHA-256
+	ModTimes map[string]time.Time `json:"mtimes,omitempty"` // slash separated path -> modification time
+}
+
+// Mirror copies the password files and .gpg-id files of the store, which
+// are encrypted or public, to dst, along with a MirrorManifest. Files that
+// are unchanged since the last Mirror are not copied again, and files
+// that were removed from the store are deleted from dst. The git
+// repository and the trash are not copied. Use Restore to restore the
+// store from dst.
+func Mirror(ctx context.Context, dst ObjectStore, options ...Option) error {
+	opts := resolveOptions(options)
+	err := mirror(ctx, dst, opts)
+	if aErr := audit(ctx, "mirror", nil, err, opts); aErr != nil && err == nil {
+		return fmt.Errorf("write audit log: %w", aErr)
+	}
+	return err
+}
+
+func mirror(ctx context.Context, dst ObjectStore, opts *Options) error {
+	storeDir := resolveStoreDir(opts)
+
+	old, err := readMirrorManifest(ctx, dst)
+	if errors.Is(err, ErrObjectNotFound) {
+		old = &MirrorManifest{}
+	} else if err != nil {
+		return err
+	}
+
+	m := &MirrorManifest{
+		Time:     time.Now().UTC(),
+		Files:    make(map[string]string),
+		ModTimes: make(map[string]time.Time),
+	}
+	err = walkStoreFiles(ctx, storeDir, opts, func(rel string, info os.FileInfo) error {
+		b, err := ioutil.ReadFile(filepath.Join(storeDir, filepath.FromSlash(rel)))
+		if err != nil {
+			return err
+		}
+		m.Files[rel] = sha256Hex(b)
+		m.ModTimes[rel] = info.ModTime().UTC()
+		if old.Files[rel] == m.Files[rel] {
+			return nil
+		}
+		if err := dst.Put(ctx, mirrorFilesPrefix+rel, b); err != nil {
+			return fmt.Errorf("put %s: %w", rel, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for rel := range old.Files {
+		if _, ok := m.Files[rel]; ok {
+			continue
+		}
+		if err := dst.Delete(ctx, mirrorFilesPrefix+rel); err != nil {
+			return fmt.Errorf("delete %s: %w", rel, err)
+		}
+	}
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if err := dst.Put(ctx, mirrorManifestKey, b); err != nil {
+		return fmt.Errorf("put manifest: %w", err)
+	}
+	return nil
+}
+
+// walkStoreFiles calls fn for each password file, recipients file, and
+// .gpg-id signature in the store, with its slash separated path relative
+// to storeDir. The git repository, the trash and the sync state are
+// skipped.
+func walkStoreFiles(ctx context.Context, storeDir string, opts *Options, fn func(rel string, info os.FileInfo) error) error {
+	return filepath.Walk(storeDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" || p == filepath.Join(storeDir, trashDir) || p == filepath.Join(storeDir, syncStateDir) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() || !isStoreFile(info.Name(), opts) {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(storeDir, p)
+		if err != nil {
+			panic(err) // should not happen
+		}
+		return fn(filepath.ToSlash(rel), info)
+	})
+}
+
+// isStoreFile reports whether the file name is a password file, a
+// recipients file, or a .gpg-id signature.
+func isStoreFile(name string, opts *Options) bool {
+	if strings.HasSuffix(name, ".gpg") || name == ".gpg-id.sig" {
+		return true
+	}
+	for _, f := range recipientsFiles(opts) {
+		if name == f {
+			return true
+		}
+	}
+	return false
+}
+
+// validManifestPath reports whether rel, a slash separated path read from
+// a manifest, is clean and within the store.
+func validManifestPath(rel string) bool {
+	return rel == path.Clean(rel) && !path.IsAbs(rel) && rel != ".." && !strings.HasPrefix(rel, "../")
+}
+
+// hasGitDir reports whether the slash separated path rel is within a
+// folder named .git, which walkStoreFiles skips.
+func hasGitDir(rel string) bool {
+	for _, elem := range strings.Split(path.Dir(rel), "/") {
+		if elem == ".git" {
+			return true
+		}
+	}
+	return false
+}
+
+func readMirrorManifest(ctx context.Context, src ObjectStore) (*MirrorManifest, error) {
+	b, err := src.Get(ctx, mirrorManifestKey)
+	if err != nil {
+		return nil, fmt.Errorf("get manifest: %w", err)
+	}
+	var m MirrorManifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("parse manifest: %w", err)
+	}
+	return &m, nil
+}
+
+// Restore copies the files mirrored to src by Mirror into the store,
+// checking each against the manifest. Unless force is set, the store must
+// not be initialized yet. The restored files are committed if the store
+// is a git repository.
+func Restore(ctx context.Context, src ObjectStore, force bool, options ...Option) error {
+	opts := resolveOptions(options)
+	return mutate(ctx, "restore", nil, opts, func() error {
+		return restore(ctx, src, force, opts)
+	})
+}
+
+func restore(ctx context.Context, src ObjectStore, force bool, opts *Options) error {
+	storeDir := resolveStoreDir(opts)
+
+	if ok, err := IsInitialized(ctx, opts); err != nil {
+		return err
+	} else if ok && !force {
+		return errors.New("store is already initialized")
+	}
+
+	m, err := readMirrorManifest(ctx, src)
+	if err != nil {
+		return err
+	}
+
+	var paths []string
+	for rel, sum := range m.Files {
+		if !validManifestPath(rel) {
+			return fmt.Errorf("invalid path in manifest: %s", rel)
+		}
+		b, err := src.Get(ctx, mirrorFilesPrefix+rel)
+		if err != nil {
+			return fmt.Errorf("get %s: %w", rel, err)
+		}
+		if sha256Hex(b) != sum {
+			return fmt.Errorf("%s does not match manifest", rel)
+		}
+		p := filepath.Join(storeDir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(p, b, 0600); err != nil {
+			return err
+		}
+		paths = append(paths, filepath.FromSlash(rel))
+	}
+
+	msg := fmt.Sprintf("Restore %d files from mirror.", len(paths))
+	if err := commitFiles(ctx, msg, paths, opts); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
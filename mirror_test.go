@@ -0,0 +1,67 @@
+package pass
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMirror(t *testing.T) {
+	ctx := context.Background()
+	storeDir := makeTestTree([]string{".gpg-id", ".gpg-id.sig", ".git/x.gpg", ".trash/y.gpg", "a.gpg", "b/c.gpg", "kms/.kms-keys", "notes.txt"})
+	defer os.RemoveAll(storeDir)
+	Ok(t, ioutil.WriteFile(filepath.Join(storeDir, "a.gpg"), []byte("encrypted a"), 0600))
+
+	bucket, err := ioutil.TempDir("", tmpDirPrefix)
+	if err != nil {
+		log.Fatalf("create tmp dir: %s", err)
+	}
+	defer os.RemoveAll(bucket)
+	dst := DirObjectStore(bucket)
+	opts := &Options{StoreDir: storeDir, Cryptos: map[string]Crypto{".kms-keys": fakeCrypto{"kms"}}}
+
+	Ok(t, Mirror(ctx, dst, opts))
+	Ok(t, os.Remove(filepath.Join(storeDir, "b", "c.gpg")))
+	Ok(t, Mirror(ctx, dst, opts))
+
+	_, err = dst.Get(ctx, "files/b/c.gpg")
+	if !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("expected removed file to be deleted, got %v", err)
+	}
+	_, err = dst.Get(ctx, "files/.git/x.gpg")
+	if !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("expected .git to be skipped, got %v", err)
+	}
+
+	restoreDir := filepath.Join(bucket, "restored")
+	Ok(t, Restore(ctx, dst, false, WithStoreDir(restoreDir)))
+	b, err := ioutil.ReadFile(filepath.Join(restoreDir, "a.gpg"))
+	Ok(t, err)
+	Equal(t, "encrypted a", string(b))
+	for _, name := range []string{".gpg-id", ".gpg-id.sig", "kms/.kms-keys"} {
+		_, err = os.Stat(filepath.Join(restoreDir, filepath.FromSlash(name)))
+		Ok(t, err)
+	}
+	_, err = os.Stat(filepath.Join(restoreDir, "notes.txt"))
+	if !os.IsNotExist(err) {
+		t.Errorf("expected notes.txt to be skipped, got %v", err)
+	}
+
+	if err := Restore(ctx, dst, false, WithStoreDir(restoreDir)); err == nil {
+		t.Errorf("expected error restoring into initialized store")
+	}
+
+	Ok(t, dst.Put(ctx, "files/a.gpg", []byte("tampered")))
+	if err := Restore(ctx, dst, true, WithStoreDir(restoreDir)); err == nil {
+		t.Errorf("expected error for file not matching manifest")
+	}
+
+	Ok(t, dst.Put(ctx, mirrorManifestKey, []byte(`{"files":{"..":""}}`)))
+	if err := Restore(ctx, dst, true, WithStoreDir(restoreDir)); err == nil {
+		t.Errorf("expected error for path outside of the store")
+	}
+}
@@ -0,0 +1,488 @@
+package pass
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrMissingKey is returned when a GPG ID that an entry must be encrypted
+// to has no usable public key in the keyring.
+var ErrMissingKey = errors.New("no usable public key")
+
+// FolderReport is the result of MoveFolder and CopyFolder.
+type FolderReport struct {
+	Done   []string         `json:"done"`             // Entries moved or copied, by their old name.
+	Failed map[string]error `json:"failed,omitempty"` // Entries that failed, by their old name.
+}
+
+// ProgressFunc is called after each entry is processed, with the number
+// of entries processed so far and the total number of entries.
+type ProgressFunc func(done, total int)
+
+// MoveFolder moves the folder oldPath, with all of its entries and
+// subfolders, to newPath. If newPath is an existing folder, oldPath is
+// moved into it. Entries are re-encrypted as described for Move when the
+// recipients of their new location differ, which can take a while for
+// large folders; progress, if not nil, is called as each entry is done.
+//
+// The .gpg-id files of oldPath are moved along, except where newPath
+// already has one, which is kept so that the recipients of the entries
+// there do not change. Aliases are re-created relative to their new
+// location, pointing at the new location of their target if it was moved
+// along; aliases that point outside the store are not moved.
+//
+// A failure for an individual entry is recorded in the returned report and
+// does not stop the remaining entries from being moved. The entries that
+// failed are left in oldPath. The moved entries are committed together.
+func MoveFolder(ctx context.Context, oldPath, newPath string, force bool, progress ProgressFunc, options ...Option) (*FolderReport, error) {
+	opts := resolveOptions(options)
+	var report *FolderReport
+	err := mutate(ctx, "mv", []string{oldPath, newPath}, opts, func() error {
+		if err := checkSourceApproval(ctx, oldPath, opts); err != nil {
+			return err
+		}
+		var err error
+		report, err = moveFolder(ctx, oldPath, newPath, force, false, progress, opts)
+		return err
+	})
+	return report, err
+}
+
+// CopyFolder is like MoveFolder, but copies the folder.
+func CopyFolder(ctx context.Context, oldPath, newPath string, force bool, progress ProgressFunc, options ...Option) (*FolderReport, error) {
+	opts := resolveOptions(options)
+	var report *FolderReport
+	err := mutate(ctx, "cp", []string{oldPath, newPath}, opts, func() error {
+		if err := checkSourceApproval(ctx, oldPath, opts); err != nil {
+			return err
+		}
+		var err error
+		report, err = moveFolder(ctx, oldPath, newPath, force, true, progress, opts)
+		return err
+	})
+	return report, err
+}
+
+func moveFolder(ctx context.Context, oldPath, newPath string, force, keep bool, progress ProgressFunc, opts *Options) (*FolderReport, error) {
+	ctx = withDefaultPriority(ctx, Bulk)
+	storeDir := resolveStoreDir(opts)
+
+	oldName := filepath.Clean(oldPath)
+	if info, err := os.Stat(filepath.Join(storeDir, oldName)); err != nil || !info.IsDir() {
+		return nil, errors.New("folder does not exist")
+	}
+	newName := filepath.Clean(newPath)
+	if info, err := os.Stat(filepath.Join(storeDir, newName)); err == nil && info.IsDir() {
+		newName = filepath.Join(newName, filepath.Base(oldName))
+	}
+	if newName == oldName || strings.HasPrefix(newName, oldName+string(filepath.Separator)) {
+		return nil, errors.New("cannot move folder into itself")
+	}
+	if _, err := os.Lstat(filepath.Join(storeDir, newName)); err == nil && !force {
+		return nil, errors.New("name already exists")
+	}
+
+	// The entries, the aliases, and the .gpg-id files, relative to the
+	// folder.
+	var entries, aliases, gpgIDFiles []string
+	root := filepath.Join(storeDir, oldName)
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			panic(err) // should not happen
+		}
+		switch {
+		case info.IsDir():
+		case info.Mode()&os.ModeSymlink != 0:
+			if strings.HasSuffix(info.Name(), ".gpg") {
+				aliases = append(aliases, strings.TrimSuffix(rel, ".gpg"))
+			}
+		case info.Name() == ".gpg-id" || info.Name() == ".gpg-id.sig" || (opts != nil && opts.Cryptos[info.Name()] != nil):
+			gpgIDFiles = append(gpgIDFiles, rel)
+		case strings.HasSuffix(info.Name(), ".gpg"):
+			entries = append(entries, strings.TrimSuffix(rel, ".gpg"))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The .gpg-id files go first, so that the entries are encrypted for
+	// their new location. Existing ones are kept, along with their
+	// signatures, as replacing them would change the recipients of the
+	// entries already there.
+	for _, f := range gpgIDFiles {
+		dst := filepath.Join(storeDir, newName, f)
+		if filepath.Base(f) == ".gpg-id.sig" {
+			if _, err := os.Lstat(strings.TrimSuffix(dst, ".sig")); err == nil {
+				continue
+			}
+		} else if _, err := os.Lstat(dst); err == nil {
+			continue
+		}
+		if err := copyFile(filepath.Join(root, f), dst); err != nil {
+			return nil, fmt.Errorf("copy .gpg-id: %w", err)
+		}
+	}
+
+	report := &FolderReport{
+		Failed: make(map[string]error),
+	}
+	m := newEntryMover(opts)
+	total := len(entries) + len(aliases)
+	for i, e := range entries {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		from := filepath.Join(oldName, e)
+		if err := m.move(ctx, from, filepath.Join(newName, e), force, keep); err != nil {
+			report.Failed[from] = err
+		} else {
+			report.Done = append(report.Done, from)
+		}
+		if progress != nil {
+			progress(i+1, total)
+		}
+	}
+	// The aliases go last, so that they can point at the entries moved.
+	for i, a := range aliases {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		from := filepath.Join(oldName, a)
+		if err := moveAlias(storeDir, from, filepath.Join(newName, a), oldName, newName, force, keep); err != nil {
+			report.Failed[from] = err
+		} else {
+			report.Done = append(report.Done, from)
+		}
+		if progress != nil {
+			progress(len(entries)+i+1, total)
+		}
+	}
+
+	if !keep && len(report.Failed) == 0 {
+		if err := os.RemoveAll(root); err != nil {
+			return report, fmt.Errorf("remove: %w", err)
+		}
+	}
+
+	msg := fmt.Sprintf("Copy %s to %s.", oldName, newName)
+	if !keep {
+		msg = fmt.Sprintf("Rename %s to %s.", oldName, newName)
+	}
+	if err := commitFiles(ctx, msg, []string{oldName, newName}, opts); err != nil {
+		return report, fmt.Errorf("commit: %w", err)
+	}
+	return report, nil
+}
+
+// moveAlias moves, or copies if keep is set, the alias oldName to newName
+// as part of moving the folder oldFolder to newFolder. The symbolic link
+// is re-created relative to its new location. If its target was in
+// oldFolder and is now in newFolder, it points at the new location.
+func moveAlias(storeDir, oldName, newName, oldFolder, newFolder string, force, keep bool) error {
+	storeDir = filepath.Clean(storeDir)
+	src := filepath.Join(storeDir, oldName+".gpg")
+	dst := filepath.Join(storeDir, newName+".gpg")
+
+	if _, err := os.Lstat(dst); err == nil && !force {
+		return errors.New("name already exists")
+	}
+	dest, err := os.Readlink(src)
+	if err != nil {
+		return err
+	}
+	if !filepath.IsAbs(dest) {
+		dest = filepath.Join(filepath.Dir(src), dest)
+	}
+	target, err := filepath.Rel(storeDir, filepath.Clean(dest))
+	if err != nil || target == ".." || strings.HasPrefix(target, ".."+string(filepath.Separator)) {
+		return errors.New("alias points outside the store")
+	}
+	if rel, err := filepath.Rel(oldFolder, target); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		moved := filepath.Join(newFolder, rel)
+		if _, err := os.Lstat(filepath.Join(storeDir, moved)); err == nil {
+			target = moved
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+		return fmt.Errorf("make dir: %w", err)
+	}
+	link, err := filepath.Rel(filepath.Dir(dst), filepath.Join(storeDir, target))
+	if err != nil {
+		return fmt.Errorf("relative path: %w", err)
+	}
+	if force {
+		os.Remove(dst)
+	}
+	if err := os.Symlink(link, dst); err != nil {
+		return fmt.Errorf("symlink: %w", err)
+	}
+	if !keep {
+		return os.Remove(src)
+	}
+	return nil
+}
+
+// moveAcrossRecipients moves, or copies if keep is set, the entry at
+// oldPath to newPath, like the mv and cp subcommands, when the entry is not
+// encrypted to the keys of the destination. Instead of leaving the
+// re-encryption to pass, which reads the passphrase from the terminal and
+// ignores failures, the entry is re-encrypted by an entryMover. It reports
+// false, having done nothing, if oldPath is not an entry or does not need
+// to be re-encrypted.
+func moveAcrossRecipients(ctx context.Context, oldPath, newPath string, force, keep bool, opts *Options) (bool, error) {
+	storeDir := resolveStoreDir(opts)
+
+	if strings.HasSuffix(oldPath, "/") {
+		return false, nil
+	}
+	oldName := filepath.Clean(oldPath)
+	if info, err := os.Stat(filepath.Join(storeDir, oldName+".gpg")); err != nil || !info.Mode().IsRegular() {
+		return false, nil
+	}
+
+	newName := filepath.Clean(newPath)
+	info, err := os.Stat(filepath.Join(storeDir, newName))
+	if strings.HasSuffix(newPath, "/") || (err == nil && info.IsDir()) {
+		newName = filepath.Join(newName, filepath.Base(oldName))
+	}
+
+	m := newEntryMover(opts)
+	// With Options.Crypto, pass cannot move the entry, even when it does
+	// not need to be re-encrypted.
+	if !usesCrypto(opts) {
+		ok, err := m.needsReencrypt(ctx, oldName, newName)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	if err := m.move(ctx, oldName, newName, force, keep); err != nil {
+		return true, err
+	}
+
+	msg := fmt.Sprintf("Copy %s to %s.", oldName, newName)
+	if !keep {
+		msg = fmt.Sprintf("Rename %s to %s.", oldName, newName)
+	}
+	if err := commitFiles(ctx, msg, []string{oldName + ".gpg", newName + ".gpg"}, opts); err != nil {
+		return true, fmt.Errorf("commit: %w", err)
+	}
+	return true, nil
+}
+
+// entryMover moves entries, re-encrypting them when the recipients of
+// their new location differ. It caches the keys of the .gpg-id files it
+// reads.
+type entryMover struct {
+	opts *Options
+	keys map[string][]string // .gpg-id path -> key IDs
+}
+
+func newEntryMover(opts *Options) *entryMover {
+	return &entryMover{
+		opts: opts,
+		keys: make(map[string][]string),
+	}
+}
+
+// destination returns the GPG IDs that the named entry must be encrypted
+// to, and their keys.
+func (m *entryMover) destination(ctx context.Context, name string) (gpgIDs, keys []string, err error) {
+	gpgIDFile, err := findGPGIDFile(name, m.opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	keys, ok := m.keys[gpgIDFile]
+	if !ok {
+		if err := verifyGPGIDFile(ctx, gpgIDFile, m.opts); err != nil {
+			return nil, nil, err
+		}
+	}
+	gpgIDs, err = readGPGIDFile(gpgIDFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ok {
+		keys, err = recipientKeys(ctx, gpgIDs, cryptoOptions(gpgIDFile, m.opts))
+		if err != nil {
+			return nil, nil, err
+		}
+		m.keys[gpgIDFile] = keys
+	}
+	return gpgIDs, keys, nil
+}
+
+// needsReencrypt reports whether the entry oldName must be re-encrypted to
+// be moved to newName.
+func (m *entryMover) needsReencrypt(ctx context.Context, oldName, newName string) (bool, error) {
+	_, expected, err := m.destination(ctx, newName)
+	if err != nil {
+		return false, err
+	}
+	current, err := fileRecipients(ctx, filepath.Join(resolveStoreDir(m.opts), oldName+".gpg"), entryOptions(oldName, m.opts))
+	if err != nil {
+		return false, err
+	}
+	return !equalStrings(current, expected), nil
+}
+
+// move moves, or copies if keep is set, the entry oldName to newName,
+// without committing. If the entry has to be re-encrypted, it is decrypted
+// using Options.Passphrase, and checked to be encrypted to the keys of the
+// destination before it is put in place.
+func (m *entryMover) move(ctx context.Context, oldName, newName string, force, keep bool) error {
+	storeDir := resolveStoreDir(m.opts)
+	src := filepath.Join(storeDir, oldName+".gpg")
+	dst := filepath.Join(storeDir, newName+".gpg")
+
+	if _, err := os.Lstat(dst); err == nil && !force {
+		return errors.New("name already exists")
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+		return fmt.Errorf("make dir: %w", err)
+	}
+
+	gpgIDs, expected, err := m.destination(ctx, newName)
+	if err != nil {
+		return err
+	}
+	srcOpts, dstOpts := entryOptions(oldName, m.opts), entryOptions(newName, m.opts)
+	current, err := fileRecipients(ctx, src, srcOpts)
+	if err != nil {
+		return err
+	}
+
+	if equalStrings(current, expected) {
+		if keep {
+			return copyFile(src, dst)
+		}
+		return os.Rename(src, dst)
+	}
+
+	// Re-encrypting reads the content, so it needs the same permissions
+	// as Show. Approval is checked by the callers, for the entries that
+	// do not need re-encrypting too.
+	names, err := accessNames(oldName, m.opts)
+	if err != nil {
+		return err
+	}
+	if err := checkACL(names, Read, m.opts); err != nil {
+		return err
+	}
+	gpgPassphrase, err := passphrase(ctx, "", m.opts)
+	if err != nil {
+		return err
+	}
+	content, err := decryptFile(ctx, src, gpgPassphrase, srcOpts)
+	if err != nil {
+		return fmt.Errorf("decrypt: %w", err)
+	}
+	defer wipe(content)
+	tmp, err := encryptTemp(ctx, filepath.Dir(dst), content, gpgIDs, dstOpts)
+	if err != nil {
+		return fmt.Errorf("encrypt: %w", err)
+	}
+	defer os.Remove(tmp)
+
+	// Check that the recipients of the destination can read the entry
+	// before replacing anything.
+	got, err := fileRecipients(ctx, tmp, dstOpts)
+	if err != nil {
+		return fmt.Errorf("verify: %w", err)
+	}
+	if !equalStrings(got, expected) {
+		return errors.New("verify: entry is not encrypted to the recipients of the destination")
+	}
+
+	if err := os.Rename(tmp, dst); err != nil {
+		return fmt.Errorf("rename: %w", err)
+	}
+	if !keep {
+		if err := os.Remove(src); err != nil {
+			return fmt.Errorf("remove: %w", err)
+		}
+	}
+	return nil
+}
+
+// recipientKeys returns the long key IDs of the usable encryption subkeys
+// for the GPG IDs. It returns an error wrapping ErrMissingKey if a GPG ID
+// has none.
+func recipientKeys(ctx context.Context, gpgIDs []string, opts *Options) ([]string, error) {
+	if opts != nil && opts.Crypto != nil {
+		return encryptionKeyIDs(ctx, gpgIDs, opts)
+	}
+	var ret []string
+	for _, id := range gpgIDs {
+		keys, err := encryptionKeyIDs(ctx, []string{id}, opts)
+		if err != nil || len(keys) == 0 {
+			return nil, fmt.Errorf("%w: %s", ErrMissingKey, id)
+		}
+		ret = append(ret, keys...)
+	}
+	return uniqueSorted(ret), nil
+}
+
+// encryptTemp encrypts content to the GPG IDs, the same way pass does,
+// into a new temporary file in dir. It returns the path of the file.
+func encryptTemp(ctx context.Context, dir string, content []byte, gpgIDs []string, opts *Options) (string, error) {
+	f, err := ioutil.TempFile(dir, ".go-pass-")
+	if err != nil {
+		return "", err
+	}
+	if opts != nil && opts.Crypto != nil {
+		data, err := opts.Crypto.Encrypt(ctx, content, gpgIDs)
+		if err == nil {
+			_, err = f.Write(data)
+		}
+		if cErr := f.Close(); err == nil {
+			err = cErr
+		}
+		if err != nil {
+			os.Remove(f.Name())
+			return "", err
+		}
+		return f.Name(), nil
+	}
+	f.Close()
+
+	args := []string{"--quiet", "--yes", "--compress-algo=none", "--no-encrypt-to", "--batch", "--status-fd=2", "--encrypt"}
+	for _, id := range gpgIDs {
+		args = append(args, "--recipient", id)
+	}
+	args = append(args, "--output", f.Name())
+
+	if _, _, err := execGPG(ctx, args, bytes.NewReader(content), opts); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("exec gpg: %w", err)
+	}
+	return f.Name(), nil
+}
+
+// copyFile copies the file at src to dst, creating the parent directories
+// of dst as needed.
+func copyFile(src, dst string) error {
+	b, err := ioutil.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(dst, b, 0600)
+}
@@ -0,0 +1,140 @@
+package pass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMoveMissingKey(t *testing.T) {
+	storeDir, err := ioutil.TempDir("", tmpDirPrefix)
+	if err != nil {
+		log.Fatalf("create tmp dir: %s", err)
+	}
+
+	opts := &Options{
+		StoreDir:   storeDir,
+		Passphrase: testGpgPassphrase,
+	}
+	ctx := context.Background()
+	err = Init(ctx, testGpgID, "", opts)
+	Ok(t, err)
+
+	err = Insert(ctx, "bar", []byte("my_password"), false, opts)
+	Ok(t, err)
+
+	if err := os.Mkdir(filepath.Join(storeDir, "other"), 0700); err != nil {
+		log.Fatalf("mkdir: %s", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(storeDir, "other", ".gpg-id"), []byte("missing@example.invalid\n"), 0600); err != nil {
+		log.Fatalf("write file: %s", err)
+	}
+
+	err = Move(ctx, "bar", "other/", false, opts)
+	if !errors.Is(err, ErrMissingKey) {
+		t.Errorf("expected: %s, got: %v", ErrMissingKey, err)
+	}
+	_, err = os.Stat(filepath.Join(storeDir, "bar.gpg"))
+	Ok(t, err)
+}
+
+func TestMoveFolder(t *testing.T) {
+	storeDir, err := ioutil.TempDir("", tmpDirPrefix)
+	if err != nil {
+		log.Fatalf("create tmp dir: %s", err)
+	}
+
+	opts := &Options{
+		StoreDir: storeDir,
+	}
+	ctx := context.Background()
+	err = Init(ctx, testGpgID, "", opts)
+	Ok(t, err)
+
+	for _, name := range []string{"f/a", "f/g/b"} {
+		err = Insert(ctx, name, []byte("my_password"), false, opts)
+		Ok(t, err)
+	}
+
+	var progress []string
+	report, err := MoveFolder(ctx, "f", "h", false, func(done, total int) {
+		progress = append(progress, fmt.Sprintf("%d/%d", done, total))
+	}, opts)
+	if err != nil {
+		t.Fatalf("move folder: %s", err)
+	}
+	Equal(t, "[1/2 2/2]", fmt.Sprint(progress))
+	Equal(t, "[f/a f/g/b]", fmt.Sprint(report.Done))
+	Equal(t, "0", fmt.Sprint(len(report.Failed)))
+
+	for _, name := range []string{"h/a.gpg", "h/g/b.gpg"} {
+		_, err = os.Stat(filepath.Join(storeDir, name))
+		Ok(t, err)
+	}
+	if _, err := os.Stat(filepath.Join(storeDir, "f")); !os.IsNotExist(err) {
+		t.Errorf("expected old folder to be removed, got: %v", err)
+	}
+}
+
+func TestMoveReencryptNeedsRead(t *testing.T) {
+	storeDir := makeTestTree(nil)
+	defer os.RemoveAll(storeDir)
+	writeTestFile(t, storeDir, ".gpg-id", "alice\n")
+	writeTestFile(t, storeDir, "team/.gpg-id", "bob\n")
+
+	opts := &Options{StoreDir: storeDir, Crypto: fakeCrypto{"key"}, Passphrase: "hunter2"}
+	ctx := context.Background()
+	Ok(t, Insert(ctx, "a", []byte("secret\n"), false, opts))
+
+	acl := NewACL()
+	Ok(t, acl.Allow("ci-bot", "**", Write))
+	wopts := &Options{StoreDir: storeDir, Crypto: fakeCrypto{"key"}, Passphrase: "hunter2", ACL: acl, Principal: "ci-bot"}
+
+	// Re-encrypting for the recipients of team needs reading a.
+	err := Copy(ctx, "a", "team/a", false, wopts)
+	if !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("expected: %s, got: %v", ErrPermissionDenied, err)
+	}
+	// Moving within the folder does not.
+	Ok(t, Move(ctx, "a", "b", false, wopts))
+}
+
+func TestMoveFolderKeepsGPGIDAndAliases(t *testing.T) {
+	storeDir := makeTestTree(nil)
+	defer os.RemoveAll(storeDir)
+	writeTestFile(t, storeDir, "src/.gpg-id", "A\n")
+	writeTestFile(t, storeDir, "src/.gpg-id.sig", "signature of A")
+	writeTestFile(t, storeDir, "dst/src/.gpg-id", "B\n")
+	writeTestFile(t, storeDir, "shared/a.gpg", "a")
+	Ok(t, os.Symlink(filepath.Join("..", "shared", "a.gpg"), filepath.Join(storeDir, "src", "link.gpg")))
+	Ok(t, os.Symlink("/etc/passwd", filepath.Join(storeDir, "src", "out.gpg")))
+	ctx := context.Background()
+
+	report, err := MoveFolder(ctx, "src", "dst", true, nil, WithStoreDir(storeDir))
+	Ok(t, err)
+	Equal(t, "[src/link]", fmt.Sprint(report.Done))
+	Equal(t, "alias points outside the store", fmt.Sprint(report.Failed["src/out"]))
+
+	// The recipients of dst/src are kept.
+	b, err := ioutil.ReadFile(filepath.Join(storeDir, "dst", "src", ".gpg-id"))
+	Ok(t, err)
+	Equal(t, "B\n", string(b))
+	if _, err := os.Stat(filepath.Join(storeDir, "dst", "src", ".gpg-id.sig")); !os.IsNotExist(err) {
+		t.Errorf("expected the signature of another .gpg-id not to be copied, got: %v", err)
+	}
+
+	dest, err := os.Readlink(filepath.Join(storeDir, "dst", "src", "link.gpg"))
+	Ok(t, err)
+	Equal(t, filepath.Join("..", "..", "shared", "a.gpg"), dest)
+	b, err = ioutil.ReadFile(filepath.Join(storeDir, "dst", "src", "link.gpg"))
+	Ok(t, err)
+	Equal(t, "a", string(b))
+	if _, err := os.Lstat(filepath.Join(storeDir, "dst", "src", "out.gpg")); !os.IsNotExist(err) {
+		t.Errorf("expected the alias outside the store not to be moved, got: %v", err)
+	}
+}
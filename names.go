@@ -0,0 +1,163 @@
+package pass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ErrAmbiguousName is returned by Show with Options.CaseInsensitiveLookup
+// when more than one entry matches the name.
+var ErrAmbiguousName = errors.New("ambiguous name")
+
+// ErrInvalidName is returned for names that are not the canonical name of
+// an entry within the store, such as "a/../b", "/a", or "a//b".
+var ErrInvalidName = errors.New("invalid name")
+
+// FindCollisions returns the groups of entries whose names differ only by
+// case or Unicode normalization, such as "GitHub" and "github", or "café"
+// with a precomposed "é" and with "e" followed by a combining accent. Such
+// entries are distinct in the store, but clash when it is checked out on
+// case-insensitive or normalizing filesystems, as on macOS and Windows.
+func FindCollisions(ctx context.Context, options ...Option) ([][]string, error) {
+	opts := resolveOptions(options)
+	names, err := List(ctx, "", opts)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make(map[string][]string)
+	for _, name := range names {
+		k := foldName(name)
+		groups[k] = append(groups[k], name)
+	}
+
+	var ret [][]string
+	for _, g := range groups {
+		if len(g) > 1 {
+			sort.Strings(g)
+			ret = append(ret, g)
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i][0] < ret[j][0] })
+	return ret, nil
+}
+
+// NormalizationForm is a Unicode normalization form for entry names.
+type NormalizationForm int
+
+const (
+	NoNormalization NormalizationForm = iota // Use names as given.
+	NFC                                      // Composed, as used by most Linux and Windows programs.
+	NFD                                      // Decomposed, as produced by some macOS programs.
+)
+
+// normalizeName returns name in the form set by Options.NameNormalization.
+func normalizeName(name string, opts *Options) string {
+	if opts == nil {
+		return name
+	}
+	switch opts.NameNormalization {
+	case NFC:
+		return nfc(name)
+	case NFD:
+		return nfd(name)
+	}
+	return name
+}
+
+// resolveName returns the name of the entry that name refers to: name in
+// the form set by Options.NameNormalization or, if no such entry exists but
+// one exists under another form, such as an entry made before the option
+// was set, that one.
+func resolveName(name string, opts *Options) string {
+	name = normalizeName(name, opts)
+	if opts == nil || opts.NameNormalization == NoNormalization || entryExists(name, opts) {
+		return name
+	}
+	for _, alt := range []string{nfc(name), nfd(name)} {
+		if entryExists(alt, opts) {
+			return alt
+		}
+	}
+	return name
+}
+
+// checkName returns an error wrapping ErrInvalidName if name is not
+// canonical or leaves the store. Pass rejects such names itself, but the
+// functions that read the password files directly must check them before
+// matching them against Options.ACL, whose patterns match names as given.
+func checkName(name string) error {
+	n := filepath.ToSlash(name)
+	if n == "" || n != path.Clean(n) || path.IsAbs(n) || filepath.VolumeName(name) != "" || n == ".." || strings.HasPrefix(n, "../") {
+		return fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+	return nil
+}
+
+// accessNames returns name and, if its password file is an alias, the
+// name of the entry it refers to, following all symbolic links within the
+// store. Access checks apply to all of them, so that an alias gives no
+// more access than its target. It returns an error if name is invalid.
+func accessNames(name string, opts *Options) ([]string, error) {
+	if err := checkName(name); err != nil {
+		return nil, err
+	}
+	storeDir, err := filepath.EvalSymlinks(resolveStoreDir(opts))
+	if err != nil {
+		return []string{name}, nil
+	}
+	p, err := filepath.EvalSymlinks(filepath.Join(storeDir, name+".gpg"))
+	if err != nil {
+		return []string{name}, nil
+	}
+	rel, err := filepath.Rel(storeDir, p)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || !strings.HasSuffix(rel, ".gpg") {
+		return []string{name}, nil
+	}
+	if target := strings.TrimSuffix(rel, ".gpg"); target != filepath.Clean(name) {
+		return []string{name, target}, nil
+	}
+	return []string{name}, nil
+}
+
+// lookupName is like resolveName, for Show. With
+// Options.CaseInsensitiveLookup set, a name that does not exist otherwise
+// refers to the entry whose name differs only by case or Unicode
+// normalization, if there is exactly one. It returns an error if name is
+// invalid.
+func lookupName(ctx context.Context, name string, opts *Options) (string, error) {
+	if err := checkName(name); err != nil {
+		return "", err
+	}
+	name = resolveName(name, opts)
+	if opts == nil || entryExists(name, opts) {
+		return name, nil
+	}
+	if !opts.CaseInsensitiveLookup {
+		return name, nil
+	}
+	names, err := List(ctx, "", opts)
+	if err != nil {
+		return "", fmt.Errorf("list: %w", err)
+	}
+	k := foldName(name)
+	var matches []string
+	for _, n := range names {
+		if foldName(n) == k {
+			matches = append(matches, n)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return name, nil
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("%w: %s matches %d entries", ErrAmbiguousName, name, len(matches))
+	}
+}
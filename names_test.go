@@ -0,0 +1,108 @@
+package pass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestNormalization(t *testing.T) {
+	testcases := []struct {
+		in, nfd, nfc string
+	}{
+		{"caf\u00e9", "cafe\u0301", "caf\u00e9"},
+		{"cafe\u0301", "cafe\u0301", "caf\u00e9"},
+		{"e\u0302\u0323", "e\u0323\u0302", "\u1ec7"}, // marks reordered
+		{"q\u0307\u0323", "q\u0323\u0307", "q\u0323\u0307"},
+		{"\u0439", "\u0438\u0306", "\u0439"},
+		{"\u0344", "\u0308\u0301", "\u0308\u0301"}, // excluded from composition
+		{"\ud55c", "\u1112\u1161\u11ab", "\ud55c"},
+		{"plain/ascii", "plain/ascii", "plain/ascii"},
+	}
+	for _, tc := range testcases {
+		Equal(t, fmt.Sprintf("%+q", tc.nfd), fmt.Sprintf("%+q", nfd(tc.in)))
+		Equal(t, fmt.Sprintf("%+q", tc.nfc), fmt.Sprintf("%+q", nfc(tc.in)))
+	}
+}
+
+func TestFindCollisions(t *testing.T) {
+	storeDir := makeTestTree([]string{
+		"GitHub.gpg",
+		"github.gpg",
+		"caf\u00e9.gpg",
+		"cafe\u0301.gpg",
+		"other.gpg",
+		"work/Mail.gpg",
+	})
+	defer os.RemoveAll(storeDir)
+
+	got, err := FindCollisions(context.Background(), WithStoreDir(storeDir))
+	Ok(t, err)
+	Equal(t, fmt.Sprint([][]string{{"GitHub", "github"}, {"cafe\u0301", "caf\u00e9"}}), fmt.Sprint(got))
+}
+
+func TestCaseInsensitiveLookup(t *testing.T) {
+	defer fakeCommand("pass", `for arg; do name="$arg"; done; cat "$PASSWORD_STORE_DIR/$name.gpg"`)()
+
+	opts := &Options{
+		StoreDir:              makeTestTree([]string{"a.gpg", "b.gpg", "B.gpg"}),
+		CaseInsensitiveLookup: true,
+	}
+	defer os.RemoveAll(opts.StoreDir)
+	writeTestFile(t, opts.StoreDir, "work/Cafe\u0301.gpg", "x")
+	ctx := context.Background()
+
+	got, err := Show(ctx, "work/cafe\u0301", "", opts)
+	Ok(t, err)
+	Equal(t, "x", string(got))
+
+	_, err = Show(ctx, "A", "", opts)
+	Ok(t, err)
+
+	_, err = Show(ctx, "b", "", opts)
+	Ok(t, err)
+	_, err = Show(ctx, "c", "", opts)
+	if err == nil {
+		t.Errorf("expected error for missing entry")
+	}
+
+	opts.StoreDir = makeTestTree([]string{"x/b.gpg", "x/B.gpg"})
+	defer os.RemoveAll(opts.StoreDir)
+	_, err = Show(ctx, "X/b", "", opts)
+	if !errors.Is(err, ErrAmbiguousName) {
+		t.Errorf("expected ErrAmbiguousName, got: %v", err)
+	}
+}
+
+func TestNameNormalization(t *testing.T) {
+	defer fakeCommand("pass", `
+for arg; do name="$arg"; done
+case "$1" in
+insert) cat > "$PASSWORD_STORE_DIR/$name.gpg" ;;
+show) cat "$PASSWORD_STORE_DIR/$name.gpg" ;;
+esac`)()
+
+	opts := &Options{
+		StoreDir:          makeTestTree(nil),
+		NameNormalization: NFC,
+	}
+	defer os.RemoveAll(opts.StoreDir)
+	writeTestFile(t, opts.StoreDir, "Zu\u0308rich.gpg", "old") // made on macOS
+	ctx := context.Background()
+
+	Ok(t, Insert(ctx, "cafe\u0301", []byte("x"), false, opts))
+	Ok(t, Insert(ctx, "Z\u00fcrich", []byte("new"), true, opts))
+
+	names, err := List(ctx, "", opts)
+	Ok(t, err)
+	Equal(t, fmt.Sprintf("%+q", []string{"Zu\u0308rich", "caf\u00e9"}), fmt.Sprintf("%+q", names))
+
+	got, err := Show(ctx, "cafe\u0301", "", opts)
+	Ok(t, err)
+	Equal(t, "x", string(got))
+	got, err = Show(ctx, "Z\u00fcrich", "", opts)
+	Ok(t, err)
+	Equal(t, "new", string(got))
+}
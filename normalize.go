@@ -0,0 +1,121 @@
+package pass
+
+import (
+	"sort"
+	"strings"
+)
+
+// compositions maps pairs of runes to the rune they compose to in NFC.
+var compositions = make(map[[2]rune]rune)
+
+func init() {
+	for r, d := range decompositions {
+		if len(d) == 2 && !compositionExclusions[r] {
+			compositions[[2]rune{d[0], d[1]}] = r
+		}
+	}
+}
+
+// Hangul syllable constants, from section 3.12 of the Unicode Standard.
+const (
+	hangulSBase  = 0xAC00
+	hangulLBase  = 0x1100
+	hangulVBase  = 0x1161
+	hangulTBase  = 0x11A7
+	hangulLCount = 19
+	hangulVCount = 21
+	hangulTCount = 28
+	hangulNCount = hangulVCount * hangulTCount
+	hangulSCount = hangulLCount * hangulNCount
+)
+
+// nfd returns s in Unicode Normalization Form D, for the characters covered
+// by decompositions and for Hangul.
+func nfd(s string) string {
+	var rs []rune
+	for _, r := range s {
+		rs = decompose(rs, r)
+	}
+	// Put each sequence of combining marks in canonical order.
+	for i := 0; i < len(rs); {
+		if combiningClasses[rs[i]] == 0 {
+			i++
+			continue
+		}
+		j := i
+		for j < len(rs) && combiningClasses[rs[j]] != 0 {
+			j++
+		}
+		marks := rs[i:j]
+		sort.SliceStable(marks, func(a, b int) bool {
+			return combiningClasses[marks[a]] < combiningClasses[marks[b]]
+		})
+		i = j
+	}
+	return string(rs)
+}
+
+func decompose(rs []rune, r rune) []rune {
+	if s := r - hangulSBase; s >= 0 && s < hangulSCount {
+		rs = append(rs, hangulLBase+s/hangulNCount, hangulVBase+(s%hangulNCount)/hangulTCount)
+		if t := s % hangulTCount; t != 0 {
+			rs = append(rs, hangulTBase+t)
+		}
+		return rs
+	}
+	d, ok := decompositions[r]
+	if !ok {
+		return append(rs, r)
+	}
+	for _, r := range d {
+		rs = decompose(rs, r)
+	}
+	return rs
+}
+
+// nfc returns s in Unicode Normalization Form C, for the characters covered
+// by decompositions and for Hangul.
+func nfc(s string) string {
+	rs := []rune(nfd(s))
+	out := rs[:0]
+	starter := -1 // index in out of the last starter
+	lastCC := -1  // combining class of the last rune after the starter
+	for _, r := range rs {
+		cc := int(combiningClasses[r])
+		if starter != -1 && (lastCC == -1 || (lastCC != 0 && lastCC < cc)) {
+			if c, ok := compose(out[starter], r); ok {
+				out[starter] = c
+				continue
+			}
+		}
+		if cc == 0 {
+			starter = len(out)
+			lastCC = -1
+		} else {
+			lastCC = cc
+		}
+		out = append(out, r)
+	}
+	return string(out)
+}
+
+func compose(a, b rune) (rune, bool) {
+	if l := a - hangulLBase; l >= 0 && l < hangulLCount {
+		if v := b - hangulVBase; v >= 0 && v < hangulVCount {
+			return hangulSBase + (l*hangulVCount+v)*hangulTCount, true
+		}
+	}
+	if s := a - hangulSBase; s >= 0 && s < hangulSCount && s%hangulTCount == 0 {
+		if t := b - hangulTBase; t > 0 && t < hangulTCount {
+			return a + t, true
+		}
+	}
+	c, ok := compositions[[2]rune{a, b}]
+	return c, ok
+}
+
+// foldName returns the key that names which differ only by case or Unicode
+// normalization have in common.
+func foldName(name string) string {
+	return strings.ToLower(nfc(name))
+}
@@ -0,0 +1,108 @@
+package pass
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// Option configures a call to a function of the package. *Options is an
+// Option that sets all of the options at once, replacing the options set
+// by the Options before it; the With functions each set a single option.
+// For example, to override only the store directory of a shared Options:
+//
+//	pass.Show(ctx, name, passphrase, opts, pass.WithStoreDir(dir))
+type Option interface {
+	apply(*Options)
+}
+
+func (o *Options) apply(dst *Options) {
+	if o != nil {
+		*dst = *o
+	}
+}
+
+type optionFunc func(*Options)
+
+func (f optionFunc) apply(o *Options) { f(o) }
+
+// WithStoreDir sets Options.StoreDir.
+func WithStoreDir(dir string) Option {
+	return optionFunc(func(o *Options) { o.StoreDir = dir })
+}
+
+// WithEnv adds to Options.Env.
+func WithEnv(env ...string) Option {
+	return optionFunc(func(o *Options) {
+		o.Env = append(o.Env[:len(o.Env):len(o.Env)], env...)
+	})
+}
+
+// WithTimeout sets Options.Timeout.
+func WithTimeout(d time.Duration) Option {
+	return optionFunc(func(o *Options) { o.Timeout = d })
+}
+
+// WithPassphrase sets Options.Passphrase.
+func WithPassphrase(p Passphrase) Option {
+	return optionFunc(func(o *Options) { o.Passphrase = p })
+}
+
+// WithRunner sets Options.Runner.
+func WithRunner(r Runner) Option {
+	return optionFunc(func(o *Options) { o.Runner = r })
+}
+
+// WithoutValidation sets Options.SkipValidation.
+func WithoutValidation() Option {
+	return optionFunc(func(o *Options) { o.SkipValidation = true })
+}
+
+// resolveOptions applies opts, in order, to the zero Options.
+func resolveOptions(opts []Option) *Options {
+	ret := &Options{}
+	for _, o := range opts {
+		if o != nil {
+			o.apply(ret)
+		}
+	}
+	return ret
+}
+
+// Runner runs the external programs used by the package: pass, gpg, and
+// git through pass. It can be used, for example, to run them in a sandbox
+// or to fake them in tests.
+type Runner interface {
+	// Run runs the command and waits for it to complete, like
+	// (*exec.Cmd).Run.
+	Run(cmd *exec.Cmd) error
+}
+
+// RunnerFunc is a function that implements Runner.
+type RunnerFunc func(cmd *exec.Cmd) error
+
+// Run calls f(cmd).
+func (f RunnerFunc) Run(cmd *exec.Cmd) error { return f(cmd) }
+
+type defaultRunner struct{}
+
+func (defaultRunner) Run(cmd *exec.Cmd) error { return cmd.Run() }
+
+// passphrase returns the passphrase to use for a call that was given
+// gpgPassphrase: Options.Passphrase if gpgPassphrase is empty, or else the
+// passphrase from Options.PassphraseProvider, if gpg reads the passphrase
+// from the package.
+func passphrase(ctx context.Context, gpgPassphrase string, opts *Options) (string, error) {
+	if gpgPassphrase != "" || opts == nil {
+		return gpgPassphrase, nil
+	}
+	if opts.Passphrase != "" || opts.PassphraseProvider == nil || pinentryMode(opts) != "loopback" {
+		return string(opts.Passphrase), nil
+	}
+	p, err := opts.PassphraseProvider.Passphrase(ctx)
+	if err != nil {
+		return "", fmt.Errorf("get passphrase: %w", err)
+	}
+	return string(p), nil
+}
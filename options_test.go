@@ -0,0 +1,88 @@
+package pass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestResolveOptions(t *testing.T) {
+	base := &Options{StoreDir: "/base", NoTTY: true, Env: []string{"A=1"}}
+
+	o := resolveOptions([]Option{base, WithStoreDir("/override"), WithEnv("B=2")})
+	Equal(t, "/override", o.StoreDir)
+	if !o.NoTTY {
+		t.Errorf("expected NoTTY from base options")
+	}
+	Equal(t, "[A=1 B=2]", fmt.Sprint(o.Env))
+	Equal(t, "[A=1]", fmt.Sprint(base.Env))
+
+	// *Options replaces the options before it.
+	o = resolveOptions([]Option{WithTimeout(time.Second), base})
+	Equal(t, "0s", o.Timeout.String())
+
+	var nilOpts *Options
+	o = resolveOptions([]Option{nil, nilOpts})
+	Equal(t, fmt.Sprint(Options{}), fmt.Sprint(*o))
+}
+
+func TestWithRunner(t *testing.T) {
+	storeDir := makeTestTree([]string{"a.gpg"})
+
+	var args []string
+	var stdin []byte
+	runner := RunnerFunc(func(cmd *exec.Cmd) error {
+		args = cmd.Args
+		stdin, _ = ioutil.ReadAll(cmd.Stdin)
+		io.WriteString(cmd.Stdout, "content")
+		return nil
+	})
+
+	ctx := context.Background()
+	c, err := Show(ctx, "a", "", WithStoreDir(storeDir), WithRunner(runner), WithPassphrase("secret"))
+	Ok(t, err)
+	Equal(t, "content", string(c))
+	Equal(t, "[pass show a]", fmt.Sprint(args))
+	Equal(t, "secret", string(stdin))
+
+	m, err := ShowMany(ctx, []string{"a"}, "", WithStoreDir(storeDir), WithRunner(runner), WithPassphrase("secret"))
+	Ok(t, err)
+	Equal(t, "content", string(m["a"]))
+	Equal(t, filepath.Join(storeDir, "a.gpg"), args[len(args)-1])
+	Equal(t, gpgProgram(), args[0])
+	Equal(t, "secret", string(stdin))
+}
+
+func TestTimeouts(t *testing.T) {
+	storeDir := makeTestTree([]string{"a.gpg"})
+
+	defer fakeCommand("pass", "exec sleep 10")()
+
+	opts := &Options{
+		StoreDir: storeDir,
+		Timeouts: map[string]time.Duration{"show": 10 * time.Millisecond},
+	}
+
+	ctx := context.Background()
+	_, err := Show(ctx, "a", "", opts)
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("expected: %s, got: %v", ErrTimeout, err)
+	}
+
+	// Timeouts does not apply when the context has a deadline.
+	ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, err = Show(ctx, "a", "", opts)
+	if err == nil || errors.Is(err, ErrTimeout) {
+		t.Errorf("expected error other than %s, got: %v", ErrTimeout, err)
+	}
+	if ctx.Err() == nil {
+		t.Errorf("expected Show to run until the context deadline")
+	}
+}
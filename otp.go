@@ -0,0 +1,203 @@
+package pass
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNoOTP is returned when an entry has no otpauth:// URI.
+var ErrNoOTP = errors.New("entry has no otpauth uri")
+
+// Code is a one-time password and how long it remains valid.
+type Code struct {
+	Value     string
+	Expires   time.Time     // When the code stops being valid.
+	Remaining time.Duration // Validity left when the code was generated.
+}
+
+// steamAlphabet is the alphabet of Steam Guard codes.
+const steamAlphabet = "23456789BCDFGHJKMNPQRTVWXY"
+
+// otpAlgorithms are the hash functions of the otpauth:// algorithm
+// parameter.
+var otpAlgorithms = map[string]func() hash.Hash{
+	"SHA1":   sha1.New,
+	"SHA256": sha256.New,
+	"SHA512": sha512.New,
+}
+
+// otpKey is a TOTP key parsed from an otpauth:// URI.
+type otpKey struct {
+	secret []byte
+	hash   func() hash.Hash
+	period time.Duration
+	digits int
+	steam  bool // Steam Guard codes of digits characters of steamAlphabet
+}
+
+// parseOTP returns the key of the first line of the content that is an
+// otpauth:// URI, as stored by pass-otp.
+func parseOTP(content Secret) (*otpKey, error) {
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "otpauth://") {
+			return parseOTPURI(line)
+		}
+	}
+	return nil, ErrNoOTP
+}
+
+func parseOTPURI(s string) (*otpKey, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("parse otpauth uri: %w", err)
+	}
+	if u.Host != "totp" {
+		return nil, fmt.Errorf("unsupported otp type %q", u.Host)
+	}
+	q := u.Query()
+	k := &otpKey{hash: sha1.New, period: 30 * time.Second, digits: 6}
+
+	if v := q.Get("algorithm"); v != "" {
+		h, ok := otpAlgorithms[strings.ToUpper(v)]
+		if !ok {
+			return nil, fmt.Errorf("unsupported otp algorithm %q", v)
+		}
+		k.hash = h
+	}
+	if v := q.Get("period"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid otp period %q", v)
+		}
+		k.period = time.Duration(n) * time.Second
+	}
+	// Steam Guard codes are marked with encoder=steam, as written by
+	// KeePassXC and others.
+	if strings.EqualFold(q.Get("encoder"), "steam") {
+		k.steam = true
+		k.digits = 5
+	}
+	if v := q.Get("digits"); v != "" && !k.steam {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 6 || n > 8 {
+			return nil, fmt.Errorf("unsupported otp digits %q", v)
+		}
+		k.digits = n
+	}
+
+	secret := strings.ToUpper(strings.ReplaceAll(q.Get("secret"), " ", ""))
+	if secret == "" {
+		return nil, errors.New("otpauth uri has no secret")
+	}
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.TrimRight(secret, "="))
+	if err != nil {
+		return nil, fmt.Errorf("decode otp secret: %w", err)
+	}
+	k.secret = key
+	return k, nil
+}
+
+// code returns the code of k at t.
+func (k *otpKey) code(t time.Time) Code {
+	counter := uint64(t.Unix()) / uint64(k.period/time.Second)
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+	mac := hmac.New(k.hash, k.secret)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation, RFC 4226 section 5.3.
+	off := sum[len(sum)-1] & 0xf
+	v := binary.BigEndian.Uint32(sum[off:]) & 0x7fffffff
+
+	var value string
+	if k.steam {
+		b := make([]byte, k.digits)
+		for i := range b {
+			b[i] = steamAlphabet[v%uint32(len(steamAlphabet))]
+			v /= uint32(len(steamAlphabet))
+		}
+		value = string(b)
+	} else {
+		mod := uint32(1)
+		for i := 0; i < k.digits; i++ {
+			mod *= 10
+		}
+		value = fmt.Sprintf("%0*d", k.digits, v%mod)
+	}
+
+	expires := time.Unix(int64(counter+1)*int64(k.period/time.Second), 0)
+	return Code{
+		Value:     value,
+		Expires:   expires,
+		Remaining: expires.Sub(t),
+	}
+}
+
+// OTP returns the current TOTP code of the named entry, generated from the
+// otpauth:// URI in its content.
+func OTP(ctx context.Context, name, gpgPassphrase string, options ...Option) (Code, error) {
+	opts := resolveOptions(options)
+	k, err := otpKeyOf(ctx, name, gpgPassphrase, opts)
+	if err != nil {
+		return Code{}, err
+	}
+	return k.code(time.Now()), nil
+}
+
+// OTPCodeStream is like OTP, but sends the current code of the named entry
+// on the returned channel once a second, with its remaining validity, until
+// ctx is done, after which the channel is closed. The entry is decrypted
+// once. A code is skipped if the receiver is not ready for it.
+func OTPCodeStream(ctx context.Context, name, gpgPassphrase string, options ...Option) (<-chan Code, error) {
+	opts := resolveOptions(options)
+	k, err := otpKeyOf(ctx, name, gpgPassphrase, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan Code, 1)
+	ch <- k.code(time.Now())
+	go func() {
+		defer close(ch)
+		t := time.NewTicker(time.Second)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-t.C:
+				select {
+				case ch <- k.code(now):
+				default:
+				}
+			}
+		}
+	}()
+	return ch, nil
+}
+
+func otpKeyOf(ctx context.Context, name, gpgPassphrase string, opts *Options) (*otpKey, error) {
+	content, err := Show(ctx, name, gpgPassphrase, opts)
+	if err != nil {
+		return nil, err
+	}
+	k, err := parseOTP(content)
+	if err != nil {
+		return nil, fmt.Errorf("otp %s: %w", name, err)
+	}
+	return k, nil
+}
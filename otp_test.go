@@ -0,0 +1,100 @@
+package pass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestOTPCode(t *testing.T) {
+	// The SHA1 test vectors of RFC 6238, truncated to 6 digits.
+	k, err := parseOTP(Secret("hunter2\notpauth://totp/Example:alice?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&issuer=Example\n"))
+	Ok(t, err)
+
+	testcases := []struct {
+		t    int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tc := range testcases {
+		c := k.code(time.Unix(tc.t, 0))
+		Equal(t, tc.code, c.Value)
+		Equal(t, fmt.Sprint(time.Duration(30-tc.t%30)*time.Second), fmt.Sprint(c.Remaining))
+	}
+
+	// The SHA256 and SHA512 test vectors of RFC 6238, and other parameters.
+	const (
+		key1   = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+		key256 = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZA"
+		key512 = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNA"
+	)
+	paramcases := []struct {
+		uri  string
+		t    int64
+		code string
+	}{
+		{"otpauth://totp/x?secret=" + key256 + "&algorithm=SHA256&digits=8", 59, "46119246"},
+		{"otpauth://totp/x?secret=" + key512 + "&algorithm=sha512&digits=8", 1111111109, "25091201"},
+		{"otpauth://totp/x?secret=" + key1 + "&period=60&digits=7", 1234567890, "5713351"},
+		{"otpauth://totp/Steam:alice?secret=" + key1 + "&encoder=steam", 1234567890, "VHHQY"},
+	}
+	for _, tc := range paramcases {
+		k, err := parseOTP(Secret(tc.uri))
+		Ok(t, err)
+		Equal(t, tc.code, k.code(time.Unix(tc.t, 0)).Value)
+	}
+	k, err = parseOTP(Secret("otpauth://totp/x?secret=" + key1 + "&period=60"))
+	Ok(t, err)
+	Equal(t, "20s", fmt.Sprint(k.code(time.Unix(100, 0)).Remaining))
+
+	for _, uri := range []string{
+		"otpauth://totp/x?secret=" + key1 + "&algorithm=MD5",
+		"otpauth://totp/x?secret=" + key1 + "&digits=9",
+		"otpauth://totp/x?secret=" + key1 + "&period=0",
+	} {
+		if _, err := parseOTP(Secret(uri)); err == nil {
+			t.Errorf("expected error for %s", uri)
+		}
+	}
+
+	_, err = parseOTP(Secret("hunter2\n"))
+	if !errors.Is(err, ErrNoOTP) {
+		t.Errorf("expected ErrNoOTP, got: %v", err)
+	}
+	_, err = parseOTP(Secret("otpauth://hotp/x?secret=GEZDGNBV&counter=1"))
+	if err == nil {
+		t.Errorf("expected error for hotp")
+	}
+}
+
+func TestOTPCodeStream(t *testing.T) {
+	storeDir := makeTestTree([]string{"a.gpg"})
+	runner := RunnerFunc(func(cmd *exec.Cmd) error {
+		io.WriteString(cmd.Stdout, "hunter2\notpauth://totp/x?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ\n")
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := OTPCodeStream(ctx, "a", "", WithStoreDir(storeDir), WithRunner(runner))
+	Ok(t, err)
+
+	c1 := <-ch
+	c2 := <-ch
+	Equal(t, "6", fmt.Sprint(len(c1.Value)))
+	if c2.Expires.Equal(c1.Expires) && c2.Remaining >= c1.Remaining {
+		t.Errorf("remaining validity did not decrease: %v, %v", c1.Remaining, c2.Remaining)
+	}
+
+	cancel()
+	for range ch {
+	}
+}
@@ -13,68 +13,375 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/mail"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
+// ErrCardTimeout is returned by Show when the context is done while
+// decryption is waiting on a smartcard, for example for the user to touch
+// a hardware key.
+var ErrCardTimeout = errors.New("timed out waiting for smartcard")
+
+// ErrTimeout is returned when a command is stopped because it took longer
+// than Options.Timeout or its entry in Options.Timeouts. The error it is
+// wrapped in includes the output of the command up to that point.
+var ErrTimeout = errors.New("timed out")
+
 type Options struct {
 	StoreDir string //  Optional. The value of PASSWORD_STORE_DIR.
+
+	// Optional. The gpg --pinentry-mode used by Show. Defaults to
+	// "loopback", in which case the passphrase given to Show is used.
+	// Set to "ask" to use the pinentry program configured for gpg-agent
+	// instead.
+	PinentryMode string
+
+	// Optional. The value of GPG_TTY. If empty, the GPG_TTY of the current
+	// process is used, if any.
+	GPGTTY string
+
+	// Run gpg with --no-tty, so that it never tries to use the terminal.
+	// Use this when running without a controlling terminal, such as from
+	// daemons, cron jobs, and CI.
+	NoTTY bool
+
+	// Optional. The name of a file in the root of the store, in the
+	// format of .gitignore files, listing files and folders that List and
+	// ListFast skip. Defaults to DefaultIgnoreFile.
+	IgnoreFile string
+
+	// Move removed entries to a trash folder in the store instead of
+	// deleting them. The trash is not included in List results.
+	Trash bool
+
+	// Overwrite the password files of removed entries with random data
+	// before deleting them, as Destroy does, including when purging the
+	// trash. Remove also drops entries that were never pushed from the
+	// git history, which needs the store to have no uncommitted changes;
+	// past versions of other entries remain in it; see PurgeHistory.
+	Shred bool
+
+	// Optional. A text/template for the messages of the git commits made
+	// by functions that change the store. The template is executed with a
+	// CommitMessageData.
+	CommitMessageTemplate string
+
+	// Optional. The author and committer of the git commits made in the
+	// store, in the form "Name <email>".
+	GitAuthor string
+
+	// Optional. The path of a file that a record of each operation that
+	// reads or changes entries is appended to. The records never contain
+	// the content of entries. See AuditRecord and ReadAuditLog.
+	AuditLog string
+
+	// Optional. The user recorded in the audit log. Defaults to the name
+	// of the current user.
+	AuditUser string
+
+	// Optional. The gpg key used to sign each record of the audit log.
+	// The key must be usable without a passphrase prompt. ReadAuditLog
+	// only accepts records signed by this key.
+	AuditSigningKey string
+
+	// Optional. The rules for which entries Principal can read and
+	// change. If nil, everything is allowed.
+	ACL *ACL
+
+	// Optional. The principal whose permissions are checked in ACL.
+	Principal string
+
+	// Optional. Notifies webhooks of changes to the store.
+	Notifier *Notifier
+
+	// Guarantee that no command ever waits for input from a user. gpg
+	// is run with --no-tty and, unless PinentryMode is "loopback", with
+	// --pinentry-mode=error; git and ssh are not allowed to prompt for
+	// credentials; editors fail to start; and changes that pass would ask
+	// to confirm, such as overwriting an entry without force, fail with
+	// ErrNotConfirmed. Commands that needed input fail with an error
+	// wrapping ErrInteractionRequired. Use this in CI pipelines.
+	NonInteractive bool
+
+	// Sign the git commits made by changes to the store, and have Sync
+	// refuse incoming commits that are not signed by one of
+	// AllowedSigners.
+	RequireSignedCommits bool
+
+	// Optional. The gpg key used to sign commits when
+	// RequireSignedCommits is set. Defaults to the user.signingKey of git.
+	CommitSigningKey string
+
+	// Optional. The fingerprints of the keys that must sign the .gpg-id
+	// files of the store; the value of PASSWORD_STORE_SIGNING_KEY. Entries
+	// are then only encrypted to .gpg-id files with a valid signature by
+	// one of the keys, and Init signs the .gpg-id file it writes. With the
+	// loopback pinentry mode, the signing key is unlocked using
+	// Options.Passphrase.
+	StoreSigningKeys []string
+
+	// Optional. The fingerprints of the keys that Sync accepts signatures
+	// of when RequireSignedCommits is set. If empty, any good signature
+	// by a key that gpg trusts is accepted.
+	AllowedSigners []string
+
+	// Push the git repository of the store after each change. If the push
+	// fails, for example because the remote is unreachable, the change
+	// still succeeds and its commits are pushed by a later change or by
+	// Flush. See PendingPushes.
+	AutoPush bool
+
+	// Let Show find entries by names that differ only by case or Unicode
+	// normalization, when no entry has the exact name. See also
+	// FindCollisions.
+	CaseInsensitiveLookup bool
+
+	// Optional. The Unicode normalization form of entry names. Names of
+	// new entries are converted to it, and existing entries are found by
+	// names in any form, so that stores shared between macOS and Linux
+	// don't end up with distinct entries whose names look the same.
+	NameNormalization NormalizationForm
+
+	// Optional. Requires approval by a second person for Show and
+	// ShowMany to show some entries. See ApprovalPolicy.
+	Approvals *ApprovalPolicy
+
+	// Optional. Limits how often Show and ShowMany can show entries.
+	RateLimiter *RateLimiter
+
+	// Optional. Keeps the content shown by Show and ShowMany, so that
+	// showing it again does not decrypt it. See Prefetch.
+	Cache *Cache
+
+	// Optional. A file, outside of the store, in which Show records the
+	// names of the entries it shows, for Recent. It is encrypted to the
+	// GPG IDs of the root of the store.
+	RecentFile string
+
+	// Optional. The order of the entries returned by List and ListFast.
+	SortMode SortMode
+
+	// Optional. The number of directories ListFast reads in parallel.
+	// Values less than 2 mean that directories are read one at a time.
+	ListConcurrency int
+
+	// Optional. Called by Show when decrypting name is going to use a key
+	// stored on a smartcard, so that the user can be told to touch or
+	// unlock their hardware key. It is called on a separate goroutine
+	// while Show is waiting.
+	OnCardWait func(name string)
+
+	// Optional. Environment variables, in the form "key=value", added
+	// to the environment of the commands that are run.
+	Env []string
+
+	// Optional. The maximum time each command that is run may take.
+	Timeout time.Duration
+
+	// Optional. The maximum time each command may take when the context
+	// given to the function has no deadline, keyed by the pass
+	// subcommand, such as "show" or "git", or "gpg" for gpg run directly.
+	// An entry overrides Timeout for the subcommand. This avoids blocking
+	// forever, for example on a pinentry prompt that nobody answers.
+	Timeouts map[string]time.Duration
+
+	// Optional. The passphrase used by functions that decrypt entries
+	// when they are given an empty passphrase.
+	Passphrase Passphrase
+
+	// Optional. Supplies the passphrase used by functions that decrypt
+	// entries when they are given an empty passphrase and
+	// Options.Passphrase is empty, for example KeychainProvider. It is
+	// only used with the loopback pinentry mode.
+	PassphraseProvider PassphraseProvider
+
+	// Optional. Locks a Store, as with (*Store).Lock, after it was not
+	// used for this long. Only used by a Store.
+	AutoLock time.Duration
+
+	// Optional. Called after a Store is locked, for example to show that
+	// it is locked. Only used by a Store.
+	OnLock func()
+
+	// Optional. Limits how long gpg-agent caches the passphrases used by
+	// the package. See AgentCachePolicy.
+	AgentCache *AgentCachePolicy
+
+	// Optional. Hardens the process and the commands it runs, as with
+	// HardenProcess, before the first command is run.
+	//
+	// Together with secrets never being given to commands in their
+	// arguments or environment (see ErrSecretInArgs), this protects
+	// decrypted entries and passphrases from other users of the system,
+	// and from core dumps and files that are readable by others. It does not
+	// protect them from root, from a compromised gpg or gpg-agent, or
+	// from other processes of the user on systems where the process
+	// cannot be made not dumpable.
+	Harden bool
+
+	// Optional. Encrypts and decrypts entries instead of gpg run by
+	// pass. See Crypto.
+	Crypto Crypto
+
+	// Optional. The Crypto for the folders that list their recipients in
+	// another file than .gpg-id, keyed by the name of the file, such as
+	// ".kms-keys". The closest such file, or .gpg-id, above an entry
+	// selects how it is encrypted, so that a store can be migrated folder
+	// by folder. Move and Copy re-encrypt entries moved between folders
+	// of different Cryptos.
+	Cryptos map[string]Crypto
+
+	// Optional. Runs the commands. Defaults to running them using
+	// (*exec.Cmd).Run.
+	Runner Runner
+
+	// Optional. Checks the content of entries before Insert stores them,
+	// for example DefaultValidator. Insert fails with an error wrapping
+	// ErrInvalidContent if it rejects the content.
+	Validator Validator
+
+	// Skip Validator, for content that is known to be as intended.
+	SkipValidation bool
+
+	// Optional. The format of entries for Get and Set. Defaults to
+	// LineCodec.
+	Codec Codec
+
+	// Make all functions that change the store, including Git, fail with
+	// ErrReadOnly before running anything.
+	ReadOnly bool
+
+	// Optional. Templates for NewFromTemplate, keyed by name. They take
+	// precedence over the templates in the store.
+	Templates map[string]string
+
+	// The maximum number of gpg processes, including those run by pass,
+	// that the functions called with a Store run at once; further calls
+	// wait, in order of Priority. Too many at once overwhelm gpg-agent.
+	// It is read by NewStore, and defaults to DefaultMaxGPGProcesses.
+	// Calls without a Store share a limit of DefaultMaxGPGProcesses.
+	MaxGPGProcesses int
+
+	locks      *storeLocks // set by Store
+	gpgLimiter *gpgLimiter // set by Store
 }
 
 // Init is equivalent to the "init" subcommand.
-func Init(ctx context.Context, gpgID, subfolder string, opts *Options) error {
+func Init(ctx context.Context, gpgID, subfolder string, options ...Option) error {
+	opts := resolveOptions(options)
+	return initStore(ctx, []string{gpgID}, subfolder, opts)
+}
+
+func initStore(ctx context.Context, gpgIDs []string, subfolder string, opts *Options) error {
 	var args []string
 	if subfolder != "" {
 		args = append(args, subfolder)
 	}
-	args = append(args, gpgID)
+	args = append(args, gpgIDs...)
 
-	_, err := execCommand(ctx, "init", args, nil, nil, opts)
+	return mutate(ctx, "init", []string{subfolder}, opts, func() error {
+		if usesCrypto(opts) {
+			return initWithCrypto(ctx, gpgIDs, subfolder, opts)
+		}
+		_, _, err := execCommand(ctx, "init", args, nil, fdPassphrase(opts), nil, nil, opts)
+		if err != nil {
+			return fmt.Errorf("exec init: %w", err)
+		}
+		return nil
+	})
+}
+
+// IsInitialized reports whether the store has been initialized, that is,
+// whether there is a .gpg-id file in the root of the store.
+func IsInitialized(ctx context.Context, options ...Option) (bool, error) {
+	opts := resolveOptions(options)
+	_, err := os.Stat(filepath.Join(resolveStoreDir(opts), ".gpg-id"))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
 	if err != nil {
-		return fmt.Errorf("exec init: %s", err)
+		return false, fmt.Errorf("stat: %w", err)
 	}
-	return nil
+	return true, nil
+}
+
+// EnsureInit initializes the store for the GPG IDs, like Init, unless it
+// is already initialized. An initialized store is left as is, even if it
+// uses other GPG IDs.
+func EnsureInit(ctx context.Context, gpgIDs []string, options ...Option) error {
+	opts := resolveOptions(options)
+	if len(gpgIDs) == 0 {
+		return errors.New("no gpg ids")
+	}
+	ok, err := IsInitialized(ctx, opts)
+	if err != nil {
+		return err
+	}
+	if ok {
+		return nil
+	}
+	return initStore(ctx, gpgIDs, "", opts)
 }
 
 // List is equivalent to the "ls" subcommand.
 //
 // Unlike the original subcommand, this function does not follow and
-// list the contents of symbolic links.
-func List(ctx context.Context, subfolder string, opts *Options) ([]string, error) {
-	storeDir := filepath.Join(os.Getenv("HOME"), ".password-store")
-	if opts != nil && opts.StoreDir != "" {
-		storeDir = opts.StoreDir
-	}
+// list the contents of symbolic links. Files and folders matched by the
+// ignore file in the root of the store (see Options.IgnoreFile) are
+// skipped.
+func List(ctx context.Context, subfolder string, options ...Option) ([]string, error) {
+	opts := resolveOptions(options)
+	storeDir := resolveStoreDir(opts)
 
 	targetDir := storeDir
 	if subfolder != "" {
 		targetDir = filepath.Join(storeDir, subfolder)
 	}
 
+	ignore, err := loadIgnoreFile(opts)
+	if err != nil {
+		return nil, err
+	}
+
 	var ret []string
 
-	err := filepath.Walk(targetDir, func(p string, info os.FileInfo, err error) error {
+	err = filepath.Walk(targetDir, func(p string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
-		if info.IsDir() && info.Name() == ".git" {
+		if info.IsDir() && (info.Name() == ".git" || p == filepath.Join(storeDir, trashDir)) {
 			return filepath.SkipDir
 		}
-		if info.IsDir() || !strings.HasSuffix(info.Name(), ".gpg") {
+		if !info.IsDir() && !strings.HasSuffix(info.Name(), ".gpg") {
 			return nil
 		}
 		rel, err := filepath.Rel(storeDir, p)
 		if err != nil {
 			panic(err) // should not happen
 		}
+		if p != targetDir && ignore.ignored(filepath.ToSlash(rel), info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
 		ret = append(ret, strings.TrimSuffix(rel, ".gpg"))
 		return nil
 	})
 	if err != nil {
 		return nil, err
 	}
+	if opts != nil {
+		if err := sortEntries(ret, opts.SortMode, storeDir); err != nil {
+			return nil, err
+		}
+	}
 	return ret, nil
 }
 
@@ -82,37 +389,135 @@ func List(ctx context.Context, subfolder string, opts *Options) ([]string, error
 // Show only works for showing the content of password files (ending in .gpg) and
 // not for listing the content of directories. Use List to list the content of
 // directories.
-func Show(ctx context.Context, name, gpgPassphrase string, opts *Options) ([]byte, error) {
-	storeDir := filepath.Join(os.Getenv("HOME"), ".password-store")
-	if opts != nil && opts.StoreDir != "" {
-		storeDir = opts.StoreDir
+//
+// The returned Secret is redacted when printed; convert it to a string or
+// []byte to use the content.
+func Show(ctx context.Context, name, gpgPassphrase string, options ...Option) (Secret, error) {
+	opts := resolveOptions(options)
+	content, err := show(ctx, name, gpgPassphrase, opts)
+	if aErr := audit(ctx, "show", []string{name}, err, opts); aErr != nil && err == nil {
+		return nil, fmt.Errorf("write audit log: %w", aErr)
 	}
+	if err == nil && opts.RecentFile != "" {
+		if p, err := passphrase(ctx, gpgPassphrase, opts); err == nil {
+			recordRecent(ctx, resolveName(name, opts), p, opts)
+		}
+	}
+	return content, err
+}
+
+// checkRead returns an error if the content of the named entry must not
+// be read: Options.ACL and Options.Approvals must allow reading the entry
+// and, if it is an alias, its target, and Options.RateLimiter must allow
+// reading the target.
+func checkRead(ctx context.Context, name string, opts *Options) error {
+	names, err := accessNames(name, opts)
+	if err != nil {
+		return err
+	}
+	if err := checkACL(names, Read, opts); err != nil {
+		return err
+	}
+	for _, n := range names {
+		if err := checkApproval(ctx, n, opts); err != nil {
+			return err
+		}
+	}
+	if opts != nil && opts.RateLimiter != nil && !opts.RateLimiter.allow(names[len(names)-1]) {
+		return ErrRateLimited
+	}
+	return nil
+}
+
+func show(ctx context.Context, name, gpgPassphrase string, opts *Options) (Secret, error) {
+	gpgPassphrase, err := passphrase(ctx, gpgPassphrase, opts)
+	if err != nil {
+		return nil, err
+	}
+	name, err = lookupName(ctx, name, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRead(ctx, name, opts); err != nil {
+		return nil, err
+	}
+	defer lockNames([]string{name}, false, opts)()
+
+	storeDir := resolveStoreDir(opts)
 
 	info, err := os.Stat(filepath.Join(storeDir, name+".gpg"))
 	if os.IsNotExist(err) {
 		return nil, errors.New("name does not exist")
 	}
 	if err != nil {
-		return nil, fmt.Errorf("stat: %s", err)
+		return nil, fmt.Errorf("stat: %w", err)
 	}
 	if info.IsDir() {
 		return nil, errors.New("name is not a file")
 	}
+	p := filepath.Join(storeDir, name+".gpg")
+	if opts != nil && opts.Cache != nil {
+		if content, ok := opts.Cache.get(p, info); ok {
+			return content, nil
+		}
+	}
+
+	if eopts := entryOptions(name, opts); eopts != nil && eopts.Crypto != nil {
+		content, err := decryptFile(ctx, p, gpgPassphrase, eopts)
+		if err != nil {
+			return nil, err
+		}
+		if opts.Cache != nil {
+			opts.Cache.put(p, info, content)
+		}
+		return content, nil
+	}
+
+	var gpgOpts []string
+	if mode := pinentryMode(opts); mode == "loopback" {
+		gpgOpts = append(gpgOpts, "--passphrase-fd=0", "--pinentry-mode=loopback")
+	} else {
+		gpgOpts = append(gpgOpts, "--pinentry-mode="+mode)
+	}
+	gpgOpts = append(gpgOpts, "--batch")
+	gpgOpts = append(gpgOpts, agentCacheArgs(opts)...)
 
-	extraEnv := []string{
-		`PASSWORD_STORE_GPG_OPTS=--passphrase-fd=0 --pinentry-mode=loopback --batch`,
+	var stderrTee io.Writer
+	var watcher *cardWatcher
+	if opts != nil && opts.OnCardWait != nil {
+		card, disk, err := secretKeyLocations(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("list secret keys: %w", err)
+		}
+		watcher = &cardWatcher{
+			card:   card,
+			disk:   disk,
+			onWait: func() { opts.OnCardWait(name) },
+		}
+		stderrTee = &statusWriter{fn: watcher.line}
 	}
 
-	output, err := execCommand(ctx, "show", []string{name}, strings.NewReader(gpgPassphrase), extraEnv, opts)
+	stdout, _, err := execCommand(ctx, "show", []string{name}, passphraseReader(gpgPassphrase), "", gpgOpts, stderrTee, opts)
 	if err != nil {
-		return nil, fmt.Errorf("exec show: %s: %s", err, output)
+		if watcher != nil && watcher.waiting && (ctx.Err() != nil || errors.Is(err, ErrTimeout)) {
+			return nil, fmt.Errorf("exec show: %w", ErrCardTimeout)
+		}
+		return nil, fmt.Errorf("exec show: %w", err)
+	}
+	if err := afterDecrypt(ctx, opts); err != nil {
+		return nil, err
 	}
 
-	return output, nil
+	if opts != nil && opts.Cache != nil {
+		opts.Cache.put(p, info, stdout)
+	}
+	return stdout, nil
 }
 
 // Insert is equivalent to the "insert" subcommand.
-func Insert(ctx context.Context, name string, content []byte, force bool, opts *Options) error {
+func Insert(ctx context.Context, name string, content []byte, force bool, options ...Option) error {
+	opts := resolveOptions(options)
+	name = resolveName(name, opts)
 	var args []string
 	if force {
 		args = append(args, "--force")
@@ -120,15 +525,34 @@ func Insert(ctx context.Context, name string, content []byte, force bool, opts *
 	args = append(args, "--multiline") // always use so we can set stdin
 	args = append(args, name)
 
-	_, err := execCommand(ctx, "insert", args, bytes.NewReader(content), nil, opts)
-	if err != nil {
-		return fmt.Errorf("exec insert: %s", err)
-	}
-	return nil
+	return mutate(ctx, "insert", []string{name}, opts, func() error {
+		if err := validate(name, content, opts); err != nil {
+			return err
+		}
+		if !force && entryExists(name, opts) {
+			if err := declineConfirmation("overwrite "+name, opts); err != nil {
+				return err
+			}
+		}
+		if eopts := entryOptions(name, opts); eopts != nil && eopts.Crypto != nil {
+			return writeEntry(ctx, name, content, eopts)
+		}
+		_, _, err := execCommand(ctx, "insert", args, bytes.NewReader(content), fdPassphrase(opts), nil, nil, opts)
+		if err != nil {
+			return fmt.Errorf("exec insert: %w", err)
+		}
+		return nil
+	})
 }
 
 // Remove is equivalent to the "rm" subcommand.
-func Remove(ctx context.Context, name string, recursive, force bool, opts *Options) error {
+//
+// If Options.Trash is set, the entry (or folder, if recursive is set) is
+// moved to the trash instead of being deleted. See ListTrash, Recover and
+// PurgeTrash.
+func Remove(ctx context.Context, name string, recursive, force bool, options ...Option) error {
+	opts := resolveOptions(options)
+	name = resolveName(name, opts)
 	var args []string
 	if recursive {
 		args = append(args, "--recursive")
@@ -138,15 +562,37 @@ func Remove(ctx context.Context, name string, recursive, force bool, opts *Optio
 	}
 	args = append(args, name)
 
-	_, err := execCommand(ctx, "rm", args, nil, nil, opts)
-	if err != nil {
-		return fmt.Errorf("exec rm: %s", err)
-	}
-	return nil
+	return mutate(ctx, "rm", []string{name}, opts, func() error {
+		if !force {
+			if err := declineConfirmation("remove "+name, opts); err != nil {
+				return err
+			}
+		}
+		if opts != nil && opts.Trash {
+			return moveToTrash(ctx, name, recursive, opts)
+		}
+		if opts != nil && opts.Shred {
+			return shredEntry(ctx, name, recursive, opts)
+		}
+		_, _, err := execCommand(ctx, "rm", args, nil, "", nil, nil, opts)
+		if err != nil {
+			return fmt.Errorf("exec rm: %w", err)
+		}
+		return nil
+	})
 }
 
 // Move is equivalent to the "mv" subcommand.
-func Move(ctx context.Context, oldPath, newPath string, force bool, opts *Options) error {
+//
+// When an entry is moved to a folder whose .gpg-id lists other keys, the
+// entry is re-encrypted by the package instead of by pass: it is
+// decrypted using Options.Passphrase, and checked to be encrypted to the
+// keys of the destination before it is put in place. The error wraps
+// ErrMissingKey if a GPG ID of the destination has no usable public key.
+func Move(ctx context.Context, oldPath, newPath string, force bool, options ...Option) error {
+	opts := resolveOptions(options)
+	oldPath = resolveName(oldPath, opts)
+	newPath = normalizeName(newPath, opts)
 	var args []string
 	if force {
 		args = append(args, "--force")
@@ -154,15 +600,35 @@ func Move(ctx context.Context, oldPath, newPath string, force bool, opts *Option
 	args = append(args, oldPath)
 	args = append(args, newPath)
 
-	_, err := execCommand(ctx, "mv", args, nil, nil, opts)
-	if err != nil {
-		return fmt.Errorf("exec mv: %s", err)
-	}
-	return nil
+	return mutate(ctx, "mv", []string{oldPath, newPath}, opts, func() error {
+		if err := checkSourceApproval(ctx, oldPath, opts); err != nil {
+			return err
+		}
+		if !force {
+			if err := declineOverwrite(oldPath, newPath, opts); err != nil {
+				return err
+			}
+		}
+		if ok, err := moveAcrossRecipients(ctx, oldPath, newPath, force, false, opts); ok || err != nil {
+			return err
+		}
+		if usesCrypto(opts) {
+			return moveFolderWithCrypto(ctx, oldPath, newPath, force, false, opts)
+		}
+		_, _, err := execCommand(ctx, "mv", args, nil, fdPassphrase(opts), nil, nil, opts)
+		if err != nil {
+			return fmt.Errorf("exec mv: %w", err)
+		}
+		return nil
+	})
 }
 
-// Copy is equivalent to the "cp" subcommand.
-func Copy(ctx context.Context, oldPath, newPath string, force bool, opts *Options) error {
+// Copy is equivalent to the "cp" subcommand. Entries copied to a folder
+// whose .gpg-id lists other keys are re-encrypted as described for Move.
+func Copy(ctx context.Context, oldPath, newPath string, force bool, options ...Option) error {
+	opts := resolveOptions(options)
+	oldPath = resolveName(oldPath, opts)
+	newPath = normalizeName(newPath, opts)
 	var args []string
 	if force {
 		args = append(args, "--force")
@@ -170,37 +636,240 @@ func Copy(ctx context.Context, oldPath, newPath string, force bool, opts *Option
 	args = append(args, oldPath)
 	args = append(args, newPath)
 
-	_, err := execCommand(ctx, "cp", args, nil, nil, opts)
-	if err != nil {
-		return fmt.Errorf("exec cp: %s", err)
-	}
-	return nil
+	return mutate(ctx, "cp", []string{oldPath, newPath}, opts, func() error {
+		if err := checkSourceApproval(ctx, oldPath, opts); err != nil {
+			return err
+		}
+		if !force {
+			if err := declineOverwrite(oldPath, newPath, opts); err != nil {
+				return err
+			}
+		}
+		if ok, err := moveAcrossRecipients(ctx, oldPath, newPath, force, true, opts); ok || err != nil {
+			return err
+		}
+		if usesCrypto(opts) {
+			return moveFolderWithCrypto(ctx, oldPath, newPath, force, true, opts)
+		}
+		_, _, err := execCommand(ctx, "cp", args, nil, fdPassphrase(opts), nil, nil, opts)
+		if err != nil {
+			return fmt.Errorf("exec cp: %w", err)
+		}
+		return nil
+	})
 }
 
 // Git is equivalent to the "git" subcommand.
-func Git(ctx context.Context, gitArgs []string, opts *Options) error {
-	_, err := execCommand(ctx, "git", gitArgs, nil, nil, opts)
-	if err != nil {
-		return fmt.Errorf("exec git: %s", err)
+//
+// If Options.ACL is set, Options.Principal must have Write permission on
+// every entry (for example, using the pattern "**"), since git can change
+// any entry.
+func Git(ctx context.Context, gitArgs []string, options ...Option) error {
+	opts := resolveOptions(options)
+	defer lockNames(nil, true, opts)()
+	notify := opts.Notifier != nil && isGitRepo(opts)
+	var head string
+	if notify {
+		head, _ = gitHead(ctx, opts)
+	}
+
+	err := checkACL([]string{""}, Write, opts)
+	if err == nil {
+		_, _, err = execCommand(ctx, "git", gitArgs, nil, "", nil, nil, opts)
+		if err != nil {
+			err = fmt.Errorf("exec git: %w", err)
+		}
+	}
+	if aErr := audit(ctx, "git", nil, err, opts); aErr != nil && err == nil {
+		return fmt.Errorf("write audit log: %w", aErr)
+	}
+	if err == nil && notify {
+		if newHead, _ := gitHead(ctx, opts); newHead != head {
+			opts.Notifier.notify(ctx, "git", nil, opts)
+		}
+	}
+	return err
+}
+
+// resolveStoreDir returns the password store directory to use for opts:
+// Options.StoreDir, or else $PASSWORD_STORE_DIR, or else the first of
+// ~/.password-store and $XDG_DATA_HOME/password-store that exists, as
+// some distributions package pass with the latter. It defaults to
+// ~/.password-store.
+func resolveStoreDir(opts *Options) string {
+	if opts != nil && opts.StoreDir != "" {
+		return opts.StoreDir
+	}
+	if dir := os.Getenv("PASSWORD_STORE_DIR"); dir != "" {
+		return dir
+	}
+	home := os.Getenv("HOME")
+	def := filepath.Join(home, ".password-store")
+	if _, err := os.Stat(def); err == nil {
+		return def
+	}
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	dir := filepath.Join(dataHome, "password-store")
+	if info, err := os.Stat(dir); err == nil && info.IsDir() {
+		return dir
+	}
+	return def
+}
+
+// commandEnv returns the environment for running pass. It is the
+// environment of the current process with the settings from gpgOpts and
+// opts applied.
+func commandEnv(gpgOpts []string, opts *Options) ([]string, error) {
+	// Status lines on stderr are used to classify gpg errors.
+	gpgOpts = append(gpgOpts, "--status-fd=2")
+	if noTTY(opts) {
+		gpgOpts = append(gpgOpts, "--no-tty")
+	}
+
+	env := baseEnv(opts)
+	env = append(env, fmt.Sprintf("PASSWORD_STORE_DIR=%s", resolveStoreDir(opts)))
+	env = append(env, fmt.Sprintf("PASSWORD_STORE_GPG_OPTS=%s", strings.Join(gpgOpts, " ")))
+	if opts != nil && opts.GPGTTY != "" {
+		env = append(env, fmt.Sprintf("GPG_TTY=%s", opts.GPGTTY))
+	}
+	if opts != nil && len(opts.StoreSigningKeys) > 0 {
+		env = append(env, fmt.Sprintf("PASSWORD_STORE_SIGNING_KEY=%s", strings.Join(opts.StoreSigningKeys, " ")))
+	}
+	if opts != nil && opts.GitAuthor != "" {
+		addr, err := mail.ParseAddress(opts.GitAuthor)
+		if err != nil {
+			return nil, fmt.Errorf("parse git author: %w", err)
+		}
+		env = append(env,
+			fmt.Sprintf("GIT_AUTHOR_NAME=%s", addr.Name),
+			fmt.Sprintf("GIT_AUTHOR_EMAIL=%s", addr.Address),
+			fmt.Sprintf("GIT_COMMITTER_NAME=%s", addr.Name),
+			fmt.Sprintf("GIT_COMMITTER_EMAIL=%s", addr.Address),
+		)
+	}
+	if opts != nil && opts.RequireSignedCommits {
+		cfg := []string{"commit.gpgSign", "true"}
+		if opts.CommitSigningKey != "" {
+			cfg = append(cfg, "user.signingKey", opts.CommitSigningKey)
+		}
+		env = append(env, gitConfigEnv(cfg...)...)
+	}
+	// Output is parsed in places, so it must not be localized.
+	env = append(env, "LC_ALL=C")
+	return env, nil
+}
+
+// baseEnv returns the environment of the current process with
+// Options.Env added.
+func baseEnv(opts *Options) []string {
+	env := os.Environ()
+	if opts != nil {
+		env = append(env, opts.Env...)
+	}
+	if opts != nil && opts.NonInteractive {
+		env = append(env,
+			"GIT_TERMINAL_PROMPT=0",
+			"GIT_EDITOR=false",
+			"EDITOR=false",
+			"VISUAL=false",
+			"SSH_ASKPASS_REQUIRE=never",
+		)
+		if !hasEnv(env, "GIT_SSH_COMMAND") {
+			env = append(env, "GIT_SSH_COMMAND=ssh -o BatchMode=yes")
+		}
+	}
+	return env
+}
+
+// hasEnv reports whether the environment variable key is set in env.
+func hasEnv(env []string, key string) bool {
+	for _, kv := range env {
+		if strings.HasPrefix(kv, key+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+func pinentryMode(opts *Options) string {
+	mode := "loopback"
+	if opts != nil && opts.PinentryMode != "" {
+		mode = opts.PinentryMode
+	}
+	if opts != nil && opts.NonInteractive && mode != "loopback" {
+		return "error"
+	}
+	return mode
+}
+
+func noTTY(opts *Options) bool {
+	return opts != nil && (opts.NoTTY || opts.NonInteractive)
+}
+
+// declineConfirmation returns ErrNotConfirmed if Options.NonInteractive is
+// set, for changes that pass would ask the user to confirm. pass itself
+// assumes yes when its standard input is not a terminal, as it never is
+// here.
+func declineConfirmation(question string, opts *Options) error {
+	if opts != nil && opts.NonInteractive {
+		return fmt.Errorf("%w: %s", ErrNotConfirmed, question)
 	}
 	return nil
 }
 
-func execCommand(ctx context.Context, subcommand string, args []string, stdin io.Reader, extraEnv []string, opts *Options) (stdout []byte, err error) {
+// entryExists reports whether the named password file exists.
+func entryExists(name string, opts *Options) bool {
+	_, err := os.Stat(filepath.Join(resolveStoreDir(opts), name+".gpg"))
+	return err == nil
+}
+
+// declineOverwrite is like declineConfirmation, for copying or moving
+// oldPath to newPath without force when the destination exists.
+func declineOverwrite(oldPath, newPath string, opts *Options) error {
+	dst := newPath
+	if info, err := os.Stat(filepath.Join(resolveStoreDir(opts), newPath)); err == nil && info.IsDir() {
+		dst = filepath.Join(newPath, filepath.Base(oldPath))
+	}
+	if !entryExists(dst, opts) {
+		return nil
+	}
+	return declineConfirmation("overwrite "+dst, opts)
+}
+
+// execCommand runs the pass subcommand. The gpgOpts are passed to gpg
+// using PASSWORD_STORE_GPG_OPTS, in addition to the ones implied by opts.
+// If gpgPassphrase is not empty, it is given to gpg on file descriptor 3,
+// with the loopback pinentry mode, so that stdin remains free for the
+// content of the entry. Only the first gpg run by pass can read it; the
+// later ones rely on gpg-agent having cached it. If stderrTee is not nil,
+// the standard error of the command is also written to it as the command
+// runs.
+func execCommand(ctx context.Context, subcommand string, args []string, stdin io.Reader, gpgPassphrase string, gpgOpts []string, stderrTee io.Writer, opts *Options) (stdout, stderr []byte, err error) {
 	allArgs := []string{subcommand}
 	allArgs = append(allArgs, args...)
 
-	var env []string
-	if opts != nil && opts.StoreDir != "" {
-		env = append(env, fmt.Sprintf("PASSWORD_STORE_DIR=%s", opts.StoreDir))
+	var extra []io.Reader
+	if gpgPassphrase != "" {
+		gpgOpts = append(gpgOpts, "--passphrase-fd=3", "--pinentry-mode=loopback")
+		extra = append(extra, passphraseReader(gpgPassphrase))
 	}
-	env = append(env, extraEnv...)
-
-	cmd := exec.CommandContext(ctx, "pass", allArgs...)
-	cmd.Env = env
-	if stdin != nil {
-		cmd.Stdin = stdin
+	env, err := commandEnv(gpgOpts, opts)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return cmd.CombinedOutput()
+	return runCommandFiles(ctx, subcommand, "pass", allArgs, env, stdin, extra, stderrTee, opts)
+}
+
+// fdPassphrase returns the passphrase that execCommand gives to gpg for
+// subcommands that do not decrypt an entry given by the caller:
+// Options.Passphrase with the loopback pinentry mode, or else "".
+func fdPassphrase(opts *Options) string {
+	if opts == nil || pinentryMode(opts) != "loopback" {
+		return ""
+	}
+	return string(opts.Passphrase)
 }
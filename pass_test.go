@@ -3,10 +3,14 @@ package pass
 import (
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -43,6 +47,40 @@ func TestInit(t *testing.T) {
 	}
 }
 
+func TestEnsureInit(t *testing.T) {
+	// A pass that only supports init.
+	defer fakeCommand("pass", `shift; printf '%s\n' "$@" > "$PASSWORD_STORE_DIR/.gpg-id"`)()
+
+	storeDir, err := ioutil.TempDir("", tmpDirPrefix)
+	if err != nil {
+		log.Fatalf("create tmp dir: %s", err)
+	}
+	opts := &Options{
+		StoreDir: storeDir,
+	}
+	ctx := context.Background()
+
+	ok, err := IsInitialized(ctx, opts)
+	Ok(t, err)
+	if ok {
+		t.Errorf("expected store to not be initialized")
+	}
+
+	err = EnsureInit(ctx, []string{"a@example.com", "b@example.com"}, opts)
+	Ok(t, err)
+	ok, err = IsInitialized(ctx, opts)
+	Ok(t, err)
+	if !ok {
+		t.Errorf("expected store to be initialized")
+	}
+
+	err = EnsureInit(ctx, []string{"c@example.com"}, opts)
+	Ok(t, err)
+	got, err := ioutil.ReadFile(filepath.Join(storeDir, ".gpg-id"))
+	Ok(t, err)
+	Equal(t, "a@example.com\nb@example.com\n", string(got))
+}
+
 func TestInsert(t *testing.T) {
 	storeDir, err := ioutil.TempDir("", tmpDirPrefix)
 	if err != nil {
@@ -63,6 +101,44 @@ func TestInsert(t *testing.T) {
 	Ok(t, err)
 }
 
+func TestInsertPassphraseFd(t *testing.T) {
+	storeDir := makeTestTree(nil)
+	defer os.RemoveAll(storeDir)
+
+	var stdin, fd3, gpgOpts string
+	runner := RunnerFunc(func(cmd *exec.Cmd) error {
+		b, err := ioutil.ReadAll(cmd.Stdin)
+		if err != nil {
+			return err
+		}
+		stdin = string(b)
+		if len(cmd.ExtraFiles) > 0 {
+			b, err := ioutil.ReadAll(cmd.ExtraFiles[0])
+			if err != nil {
+				return err
+			}
+			fd3 = string(b)
+		}
+		for _, kv := range cmd.Env {
+			if strings.HasPrefix(kv, "PASSWORD_STORE_GPG_OPTS=") {
+				gpgOpts = kv
+			}
+		}
+		return nil
+	})
+	ctx := context.Background()
+	err := Insert(ctx, "a", []byte("content\n"), true, WithStoreDir(storeDir), WithRunner(runner), WithPassphrase("secret"))
+	Ok(t, err)
+	Equal(t, "content\n", stdin)
+	Equal(t, "secret", fd3)
+	Equal(t, "true", fmt.Sprint(strings.Contains(gpgOpts, "--passphrase-fd=3 --pinentry-mode=loopback")))
+
+	fd3 = ""
+	err = Insert(ctx, "a", []byte("content\n"), true, WithStoreDir(storeDir), WithRunner(runner))
+	Ok(t, err)
+	Equal(t, "", fd3)
+}
+
 func TestCopy(t *testing.T) {
 	storeDir, err := ioutil.TempDir("", tmpDirPrefix)
 	if err != nil {
@@ -126,6 +202,36 @@ func TestList(t *testing.T) {
 	Equal(t, "google.com/baz", ls[2])
 }
 
+func TestResolveStoreDir(t *testing.T) {
+	home := makeTestTree(nil)
+	defer os.RemoveAll(home)
+	for _, kv := range [][2]string{{"HOME", home}, {"XDG_DATA_HOME", ""}, {"PASSWORD_STORE_DIR", ""}} {
+		old, ok := os.LookupEnv(kv[0])
+		os.Setenv(kv[0], kv[1])
+		if ok {
+			defer os.Setenv(kv[0], old)
+		} else {
+			defer os.Unsetenv(kv[0])
+		}
+	}
+
+	dotDir := filepath.Join(home, ".password-store")
+	xdgDir := filepath.Join(home, ".local", "share", "password-store")
+	Equal(t, dotDir, resolveStoreDir(nil))
+	Ok(t, os.MkdirAll(xdgDir, 0700))
+	Equal(t, xdgDir, resolveStoreDir(nil))
+	Ok(t, os.MkdirAll(dotDir, 0700))
+	Equal(t, dotDir, resolveStoreDir(nil))
+
+	os.Setenv("PASSWORD_STORE_DIR", "/env/store")
+	Equal(t, "/env/store", resolveStoreDir(nil))
+	Equal(t, "/opt/store", resolveStoreDir(&Options{StoreDir: "/opt/store"}))
+
+	env, err := commandEnv(nil, nil)
+	Ok(t, err)
+	Equal(t, "true", fmt.Sprint(strings.Contains(strings.Join(env, "\n"), "\nPASSWORD_STORE_DIR=/env/store\n")))
+}
+
 func TestShow(t *testing.T) {
 	storeDir, err := ioutil.TempDir("", tmpDirPrefix)
 	if err != nil {
@@ -150,6 +256,54 @@ func TestShow(t *testing.T) {
 	}
 }
 
+func TestCommandEnv(t *testing.T) {
+	for _, k := range []string{"LANG", "LC_ALL", "LC_MESSAGES"} {
+		defer os.Setenv(k, os.Getenv(k))
+		os.Setenv(k, "de_DE.UTF-8")
+	}
+
+	env, err := commandEnv(nil, &Options{StoreDir: "/tmp/store", GitAuthor: "CI Bot <ci@example.com>"})
+	Ok(t, err)
+
+	// As in os/exec, later entries take precedence.
+	lookup := func(key string) string {
+		var v string
+		for _, kv := range env {
+			if strings.HasPrefix(kv, key+"=") {
+				v = strings.TrimPrefix(kv, key+"=")
+			}
+		}
+		return v
+	}
+	Equal(t, "C", lookup("LC_ALL"))
+	Equal(t, "/tmp/store", lookup("PASSWORD_STORE_DIR"))
+	Equal(t, "--status-fd=2", lookup("PASSWORD_STORE_GPG_OPTS"))
+	Equal(t, "de_DE.UTF-8", lookup("LANG"))
+	Equal(t, "CI Bot", lookup("GIT_AUTHOR_NAME"))
+	Equal(t, "ci@example.com", lookup("GIT_COMMITTER_EMAIL"))
+
+	_, err = commandEnv(nil, &Options{GitAuthor: "not an address"})
+	if err == nil {
+		t.Errorf("expected error for bad git author")
+	}
+}
+
+func TestCommandErrorLocalized(t *testing.T) {
+	// gpg's messages are localized, but its status lines are not.
+	stderr := []byte(`[GNUPG:] ENC_TO D1BC414B0E928F8B 1 0
+gpg: Entschlüsselung mit dem öffentlichen Schlüssel fehlgeschlagen: Falsche Passphrase
+[GNUPG:] ERROR pkdecrypt_failed 67108875
+[GNUPG:] BEGIN_DECRYPTION
+[GNUPG:] DECRYPTION_FAILED
+gpg: Entschlüsselung fehlgeschlagen: Kein geheimer Schlüssel
+[GNUPG:] END_DECRYPTION
+`)
+	err := commandError(errors.New("exit status 2"), stderr)
+	if !errors.Is(err, ErrBadPassphrase) {
+		t.Errorf("expected ErrBadPassphrase, got: %s", err)
+	}
+}
+
 func Ok(t *testing.T, err error) {
 	t.Helper()
 	if err != nil {
@@ -163,3 +317,41 @@ func Equal(t *testing.T, expected, got string) {
 		t.Errorf("expected: %s, got: %s", expected, got)
 	}
 }
+
+func TestNonInteractive(t *testing.T) {
+	defer fakeCommand("pass", `exit 0`)()
+
+	opts := &Options{
+		StoreDir:       makeTestTree([]string{"a.gpg", "b.gpg", "dir/a.gpg"}),
+		PinentryMode:   "ask",
+		NonInteractive: true,
+	}
+	defer os.RemoveAll(opts.StoreDir)
+	ctx := context.Background()
+
+	Equal(t, "error", pinentryMode(opts))
+
+	for _, err := range []error{
+		Insert(ctx, "a", []byte("x"), false, opts),
+		Remove(ctx, "a", false, false, opts),
+		Move(ctx, "a", "b", false, opts),
+		Copy(ctx, "a", "dir", false, opts),
+	} {
+		if !errors.Is(err, ErrNotConfirmed) {
+			t.Errorf("expected ErrNotConfirmed, got: %v", err)
+		}
+	}
+	Ok(t, Insert(ctx, "c", []byte("x"), false, opts))
+	Ok(t, Insert(ctx, "a", []byte("x"), true, opts))
+	Ok(t, Remove(ctx, "a", false, true, opts))
+
+	env, err := commandEnv(nil, opts)
+	Ok(t, err)
+	joined := strings.Join(env, "\n")
+	if !strings.Contains(joined, "\nGIT_TERMINAL_PROMPT=0\n") {
+		t.Errorf("expected GIT_TERMINAL_PROMPT=0 in environment")
+	}
+	if !strings.Contains(joined, "--no-tty") {
+		t.Errorf("expected --no-tty in gpg options")
+	}
+}
@@ -0,0 +1,110 @@
+package pass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// pinsFile is the file, in the root of the store, that lists the pinned
+// entries, one per line. Like the names of the entries, it is not
+// encrypted.
+const pinsFile = ".pins"
+
+// Pin adds the named entry to the pinned entries returned by ListPinned,
+// for front ends to show first. The list is kept in the store, so it is
+// shared through git like the entries.
+func Pin(ctx context.Context, name string, options ...Option) error {
+	opts := resolveOptions(options)
+	name = resolveName(name, opts)
+	return mutate(ctx, "pin", []string{name}, opts, func() error {
+		if !entryExists(name, opts) {
+			return errors.New("name does not exist")
+		}
+		pins, err := readPins(opts)
+		if err != nil {
+			return err
+		}
+		for _, p := range pins {
+			if p == name {
+				return nil
+			}
+		}
+		return writePins(ctx, append(pins, name), fmt.Sprintf("Pin %s.", name), opts)
+	})
+}
+
+// Unpin removes the named entry from the pinned entries. It does nothing if
+// the entry is not pinned.
+func Unpin(ctx context.Context, name string, options ...Option) error {
+	opts := resolveOptions(options)
+	name = resolveName(name, opts)
+	return mutate(ctx, "unpin", []string{name}, opts, func() error {
+		pins, err := readPins(opts)
+		if err != nil {
+			return err
+		}
+		var kept []string
+		for _, p := range pins {
+			if p != name {
+				kept = append(kept, p)
+			}
+		}
+		if len(kept) == len(pins) {
+			return nil
+		}
+		return writePins(ctx, kept, fmt.Sprintf("Unpin %s.", name), opts)
+	})
+}
+
+// ListPinned returns the pinned entries, in the order they were pinned.
+// Entries that no longer exist are skipped.
+func ListPinned(ctx context.Context, options ...Option) ([]string, error) {
+	opts := resolveOptions(options)
+	pins, err := readPins(opts)
+	if err != nil {
+		return nil, err
+	}
+	var ret []string
+	for _, p := range pins {
+		if entryExists(p, opts) {
+			ret = append(ret, p)
+		}
+	}
+	return ret, nil
+}
+
+func readPins(opts *Options) ([]string, error) {
+	b, err := ioutil.ReadFile(filepath.Join(resolveStoreDir(opts), pinsFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read pins: %w", err)
+	}
+	var ret []string
+	for _, line := range strings.Split(string(b), "\n") {
+		if line != "" {
+			ret = append(ret, line)
+		}
+	}
+	return ret, nil
+}
+
+func writePins(ctx context.Context, pins []string, msg string, opts *Options) error {
+	var b []byte
+	for _, p := range pins {
+		b = append(b, p+"\n"...)
+	}
+	if err := ioutil.WriteFile(filepath.Join(resolveStoreDir(opts), pinsFile), b, 0600); err != nil {
+		return fmt.Errorf("write pins: %w", err)
+	}
+	if err := commitFiles(ctx, msg, []string{pinsFile}, opts); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
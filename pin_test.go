@@ -0,0 +1,35 @@
+package pass
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPin(t *testing.T) {
+	storeDir := makeTestTree([]string{"a.gpg", "b.gpg", "dir/c.gpg"})
+	defer os.RemoveAll(storeDir)
+	opts := &Options{StoreDir: storeDir}
+	ctx := context.Background()
+
+	Ok(t, Pin(ctx, "dir/c", opts))
+	Ok(t, Pin(ctx, "a", opts))
+	Ok(t, Pin(ctx, "a", opts))
+	Ok(t, Pin(ctx, "b", opts))
+	if err := Pin(ctx, "nope", opts); err == nil {
+		t.Errorf("expected error for missing entry")
+	}
+
+	pins, err := ListPinned(ctx, opts)
+	Ok(t, err)
+	Equal(t, "dir/c a b", filepath.ToSlash(strings.Join(pins, " ")))
+
+	Ok(t, Unpin(ctx, "a", opts))
+	Ok(t, Unpin(ctx, "a", opts))
+	Ok(t, os.Remove(filepath.Join(storeDir, "b.gpg")))
+	pins, err = ListPinned(ctx, opts)
+	Ok(t, err)
+	Equal(t, "dir/c", filepath.ToSlash(strings.Join(pins, " ")))
+}
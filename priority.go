@@ -0,0 +1,110 @@
+package pass
+
+import (
+	"context"
+	"sync"
+)
+
+// Priority is the priority of an operation when it waits for a gpg process
+// slot (see Options.MaxGPGProcesses). Waiting operations of a higher
+// priority get the next free slot first.
+type Priority int
+
+const (
+	// Interactive is the priority of operations on single entries, such
+	// as Show, which a user is likely waiting for.
+	Interactive Priority = iota
+
+	// Bulk is the priority of operations on many entries, such as
+	// ShowMany, SearchContent, Lint, Reencrypt and MoveFolder.
+	Bulk
+
+	numPriorities = iota
+)
+
+type priorityKey struct{}
+
+// WithPriority returns a copy of ctx that makes the functions called with
+// it run at the priority p, instead of their default priority.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// withDefaultPriority returns ctx with the priority p, unless it already
+// has a priority.
+func withDefaultPriority(ctx context.Context, p Priority) context.Context {
+	if _, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return ctx
+	}
+	return WithPriority(ctx, p)
+}
+
+func priority(ctx context.Context) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok && p >= 0 && p < numPriorities {
+		return p
+	}
+	return Interactive
+}
+
+// gpgLimiter limits the number of gpg processes run at once. Waiting
+// acquirers get free slots in order of priority, and then in the order
+// they started waiting.
+type gpgLimiter struct {
+	mu      sync.Mutex
+	free    int
+	waiting [numPriorities][]chan struct{}
+}
+
+func newGPGLimiter(n int) *gpgLimiter {
+	return &gpgLimiter{free: n}
+}
+
+// acquire waits for a slot, at the priority of ctx, until ctx is done.
+func (l *gpgLimiter) acquire(ctx context.Context) error {
+	l.mu.Lock()
+	if l.free > 0 {
+		l.free--
+		l.mu.Unlock()
+		return nil
+	}
+	p := priority(ctx)
+	ch := make(chan struct{})
+	l.waiting[p] = append(l.waiting[p], ch)
+	l.mu.Unlock()
+
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i, w := range l.waiting[p] {
+		if w == ch {
+			l.waiting[p] = append(l.waiting[p][:i], l.waiting[p][i+1:]...)
+			return ctx.Err()
+		}
+	}
+	// The slot was handed over as ctx was done; pass it on.
+	l.releaseLocked()
+	return ctx.Err()
+}
+
+func (l *gpgLimiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.releaseLocked()
+}
+
+func (l *gpgLimiter) releaseLocked() {
+	for p := range l.waiting {
+		if len(l.waiting[p]) > 0 {
+			ch := l.waiting[p][0]
+			l.waiting[p] = l.waiting[p][1:]
+			close(ch)
+			return
+		}
+	}
+	l.free++
+}
@@ -0,0 +1,72 @@
+package pass
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGPGLimiter(t *testing.T) {
+	l := newGPGLimiter(1)
+	ctx := context.Background()
+	Ok(t, l.acquire(ctx))
+
+	// Bulk waiters queue first, and an interactive one overtakes them.
+	order := make(chan string, 3)
+	start := func(name string, p Priority) {
+		go func() {
+			if err := l.acquire(WithPriority(ctx, p)); err != nil {
+				t.Error(err)
+				return
+			}
+			order <- name
+			l.release()
+		}()
+	}
+	waitQueued := func(p Priority, n int) {
+		for {
+			l.mu.Lock()
+			queued := len(l.waiting[p])
+			l.mu.Unlock()
+			if queued == n {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	start("bulk1", Bulk)
+	waitQueued(Bulk, 1)
+	start("bulk2", Bulk)
+	waitQueued(Bulk, 2)
+	start("interactive", Interactive)
+	waitQueued(Interactive, 1)
+
+	// A canceled waiter leaves the queue.
+	cctx, cancel := context.WithCancel(WithPriority(ctx, Bulk))
+	done := make(chan error)
+	go func() { done <- l.acquire(cctx) }()
+	waitQueued(Bulk, 3)
+	cancel()
+	Equal(t, fmt.Sprint(context.Canceled), fmt.Sprint(<-done))
+
+	l.release()
+	var got []string
+	for i := 0; i < 3; i++ {
+		got = append(got, <-order)
+	}
+	Equal(t, "interactive bulk1 bulk2", strings.Join(got, " "))
+
+	// All slots are free again.
+	for i := 0; i < 2; i++ {
+		ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		err := l.acquire(ctx)
+		cancel()
+		if i == 0 {
+			Ok(t, err)
+		} else if err != context.DeadlineExceeded {
+			t.Errorf("expected: %s, got: %v", context.DeadlineExceeded, err)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+package pass
+
+import "context"
+
+// PassphraseProvider supplies the gpg passphrase, for example from the
+// keyring of the operating system, so that programs do not need to handle
+// it themselves. Set it in Options.PassphraseProvider.
+type PassphraseProvider interface {
+	Passphrase(ctx context.Context) (Passphrase, error)
+}
+
+// PassphraseFunc is a function that implements PassphraseProvider.
+type PassphraseFunc func(ctx context.Context) (Passphrase, error)
+
+// Passphrase calls f(ctx).
+func (f PassphraseFunc) Passphrase(ctx context.Context) (Passphrase, error) { return f(ctx) }
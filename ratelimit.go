@@ -0,0 +1,90 @@
+package pass
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is returned when showing an entry would exceed the limits
+// of Options.RateLimiter.
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// Limit allows up to Max events in any period of length Per. The zero
+// Limit allows any number of events.
+type Limit struct {
+	Max int
+	Per time.Duration
+}
+
+// RateLimiter limits how often entries can be shown, both across all
+// entries and for each entry, so that a compromised program using the
+// package cannot quietly read the whole store. Set it in Options.RateLimiter.
+// A RateLimiter is safe for concurrent use.
+type RateLimiter struct {
+	global, perEntry Limit
+	onExceeded       func(name string)
+
+	mu      sync.Mutex
+	all     []time.Time            // times of recent shows, oldest first
+	entries map[string][]time.Time // times of recent shows per entry
+	now     func() time.Time
+}
+
+// NewRateLimiter returns a RateLimiter with the given limits. If
+// onExceeded is not nil, it is called with the name of the entry whenever a
+// show is refused.
+func NewRateLimiter(global, perEntry Limit, onExceeded func(name string)) *RateLimiter {
+	return &RateLimiter{
+		global:     global,
+		perEntry:   perEntry,
+		onExceeded: onExceeded,
+		entries:    make(map[string][]time.Time),
+		now:        time.Now,
+	}
+}
+
+// allow reports whether the named entry may be shown now, and if so
+// records that it was.
+func (r *RateLimiter) allow(name string) bool {
+	r.mu.Lock()
+	now := r.now()
+	r.all = prune(r.all, r.global, now)
+	entry := prune(r.entries[name], r.perEntry, now)
+
+	ok := within(r.all, r.global) && within(entry, r.perEntry)
+	if ok {
+		if r.global.Max > 0 {
+			r.all = append(r.all, now)
+		}
+		if r.perEntry.Max > 0 {
+			entry = append(entry, now)
+		}
+	}
+	if len(entry) > 0 {
+		r.entries[name] = entry
+	} else {
+		delete(r.entries, name)
+	}
+	r.mu.Unlock()
+
+	if !ok && r.onExceeded != nil {
+		r.onExceeded(name)
+	}
+	return ok
+}
+
+// prune removes the times that are no longer within the period of l.
+func prune(times []time.Time, l Limit, now time.Time) []time.Time {
+	i := 0
+	for i < len(times) && now.Sub(times[i]) >= l.Per {
+		i++
+	}
+	return times[i:]
+}
+
+// within reports whether one more event is allowed by l, given the times
+// of the events in its current period.
+func within(times []time.Time, l Limit) bool {
+	return l.Max <= 0 || len(times) < l.Max
+}
@@ -0,0 +1,61 @@
+package pass
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	var exceeded []string
+	r := NewRateLimiter(Limit{Max: 3, Per: time.Minute}, Limit{Max: 2, Per: time.Minute}, func(name string) {
+		exceeded = append(exceeded, name)
+	})
+	now := time.Now()
+	r.now = func() time.Time { return now }
+
+	expect := func(name string, expected bool) {
+		t.Helper()
+		if got := r.allow(name); got != expected {
+			t.Errorf("allow(%q): expected: %t, got: %t", name, expected, got)
+		}
+	}
+
+	expect("a", true)
+	expect("a", true)
+	expect("a", false) // per-entry limit
+	expect("b", true)
+	expect("c", false) // global limit
+
+	now = now.Add(time.Minute)
+	expect("a", true)
+	expect("c", true)
+
+	if len(exceeded) != 2 || exceeded[0] != "a" || exceeded[1] != "c" {
+		t.Errorf("unexpected exceeded calls: %v", exceeded)
+	}
+}
+
+func TestShowRateLimited(t *testing.T) {
+	opts := &Options{
+		StoreDir:    makeTestTree(nil),
+		RateLimiter: NewRateLimiter(Limit{}, Limit{Max: 1, Per: time.Hour}, nil),
+	}
+	ctx := context.Background()
+
+	// The first call fails because the entry does not exist, but still
+	// counts towards the limit.
+	_, err := Show(ctx, "bar", testGpgPassphrase, opts)
+	if errors.Is(err, ErrRateLimited) {
+		t.Errorf("unexpected rate limit error")
+	}
+	_, err = Show(ctx, "bar", testGpgPassphrase, opts)
+	if !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected rate limit error, got: %v", err)
+	}
+	_, err = ShowMany(ctx, []string{"bar"}, testGpgPassphrase, opts)
+	if !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected rate limit error, got: %v", err)
+	}
+}
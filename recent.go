@@ -0,0 +1,101 @@
+package pass
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// maxRecent is the number of names kept in Options.RecentFile.
+const maxRecent = 100
+
+// recentMu serializes the updates of recent files.
+var recentMu sync.Mutex
+
+// recordRecent moves the name to the front of Options.RecentFile, if it is
+// set. Failures are ignored, since the list is only a convenience.
+func recordRecent(ctx context.Context, name, gpgPassphrase string, opts *Options) {
+	if opts == nil || opts.RecentFile == "" {
+		return
+	}
+	recentMu.Lock()
+	defer recentMu.Unlock()
+
+	names, err := readRecent(ctx, gpgPassphrase, opts)
+	if err != nil {
+		return
+	}
+	list := []string{name}
+	for _, n := range names {
+		if n != name && len(list) < maxRecent {
+			list = append(list, n)
+		}
+	}
+
+	gpgIDFile := filepath.Join(resolveStoreDir(opts), ".gpg-id")
+	if err := verifyGPGIDFile(ctx, gpgIDFile, opts); err != nil {
+		return
+	}
+	gpgIDs, err := readGPGIDFile(gpgIDFile)
+	if err != nil {
+		return
+	}
+	content := []byte(strings.Join(list, "\n") + "\n")
+	tmp, err := encryptTemp(ctx, filepath.Dir(opts.RecentFile), content, gpgIDs, opts)
+	if err != nil {
+		return
+	}
+	if err := os.Rename(tmp, opts.RecentFile); err != nil {
+		os.Remove(tmp)
+	}
+}
+
+// readRecent returns the names in Options.RecentFile, most recent first.
+func readRecent(ctx context.Context, gpgPassphrase string, opts *Options) ([]string, error) {
+	if _, err := os.Stat(opts.RecentFile); os.IsNotExist(err) {
+		return nil, nil
+	}
+	content, err := decryptFile(ctx, opts.RecentFile, gpgPassphrase, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer wipe(content)
+	var ret []string
+	for _, line := range strings.Split(string(content), "\n") {
+		if line != "" {
+			ret = append(ret, line)
+		}
+	}
+	return ret, nil
+}
+
+// Recent returns up to n of the entries most recently shown using Show,
+// most recent first, as recorded in Options.RecentFile. Entries that no
+// longer exist are skipped.
+func Recent(ctx context.Context, n int, gpgPassphrase string, options ...Option) ([]string, error) {
+	opts := resolveOptions(options)
+	if opts.RecentFile == "" {
+		return nil, nil
+	}
+	gpgPassphrase, err := passphrase(ctx, gpgPassphrase, opts)
+	if err != nil {
+		return nil, err
+	}
+	names, err := readRecent(ctx, gpgPassphrase, opts)
+	if err != nil {
+		return nil, fmt.Errorf("read recent file: %w", err)
+	}
+	var ret []string
+	for _, name := range names {
+		if len(ret) == n {
+			break
+		}
+		if entryExists(name, opts) && checkACL([]string{name}, Read, opts) == nil {
+			ret = append(ret, name)
+		}
+	}
+	return ret, nil
+}
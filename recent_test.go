@@ -0,0 +1,53 @@
+package pass
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecent(t *testing.T) {
+	defer fakeCommand("pass", `for arg; do name="$arg"; done; cat "$PASSWORD_STORE_DIR/$name.gpg"`)()
+
+	dir := makeTestTree(nil)
+	defer os.RemoveAll(dir)
+	env, fprs := testKeyring(t, filepath.Join(dir, "gnupg"), "A <a@example.com>")
+	opts := &Options{
+		StoreDir:   filepath.Join(dir, "store"),
+		Env:        env,
+		RecentFile: filepath.Join(dir, "recent.gpg"),
+	}
+	writeTestFile(t, opts.StoreDir, ".gpg-id", fprs[0]+"\n")
+	for _, name := range []string{"a", "b", "c d", "gone"} {
+		writeTestFile(t, opts.StoreDir, name+".gpg", name)
+	}
+	ctx := context.Background()
+
+	names, err := Recent(ctx, 10, "", opts)
+	Ok(t, err)
+	Equal(t, "0", fmt.Sprint(len(names)))
+
+	for _, name := range []string{"a", "gone", "c d", "b", "a"} {
+		_, err := Show(ctx, name, "", opts)
+		Ok(t, err)
+	}
+	Ok(t, os.Remove(filepath.Join(opts.StoreDir, "gone.gpg")))
+
+	names, err = Recent(ctx, 10, "", opts)
+	Ok(t, err)
+	Equal(t, "a|b|c d", strings.Join(names, "|"))
+	names, err = Recent(ctx, 2, "", opts)
+	Ok(t, err)
+	Equal(t, "a|b", strings.Join(names, "|"))
+
+	// The file is encrypted.
+	b, err := ioutil.ReadFile(opts.RecentFile)
+	Ok(t, err)
+	if strings.Contains(string(b), "c d") {
+		t.Errorf("expected recent file to be encrypted")
+	}
+}
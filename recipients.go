@@ -0,0 +1,306 @@
+package pass
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Recipients returns the long key IDs of the keys that the named password
+// file is currently encrypted to.
+func Recipients(ctx context.Context, name string, options ...Option) ([]string, error) {
+	opts := resolveOptions(options)
+	p := filepath.Join(resolveStoreDir(opts), name+".gpg")
+	if _, err := os.Stat(p); err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.New("name does not exist")
+		}
+		return nil, fmt.Errorf("stat: %w", err)
+	}
+	return fileRecipients(ctx, p, entryOptions(name, opts))
+}
+
+// fileRecipients returns the long key IDs of the keys that the password
+// file at p is encrypted to.
+func fileRecipients(ctx context.Context, p string, opts *Options) ([]string, error) {
+	if opts != nil && opts.Crypto != nil {
+		data, err := ioutil.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		keys, err := opts.Crypto.EncryptedTo(ctx, data)
+		if err != nil {
+			return nil, err
+		}
+		return uniqueSorted(keys), nil
+	}
+	args := []string{"--batch", "--status-fd=1", "--list-only", "--decrypt", p}
+	stdout, _, err := execGPG(ctx, args, nil, opts)
+
+	var ret []string
+	for _, line := range statusLines(stdout) {
+		if line.keyword == "ENC_TO" && len(line.args) > 0 {
+			ret = append(ret, line.args[0])
+		}
+	}
+	if len(ret) == 0 {
+		if err != nil {
+			return nil, fmt.Errorf("exec gpg: %w", err)
+		}
+		return nil, errors.New("no recipients found")
+	}
+	return uniqueSorted(ret), nil
+}
+
+// ReencryptReport describes the outcome of a call to Reencrypt.
+type ReencryptReport struct {
+	Reencrypted []string         `json:"reencrypted"`      // Entries that were re-encrypted.
+	UpToDate    []string         `json:"up_to_date"`       // Entries whose recipients already matched.
+	Failed      map[string]error `json:"failed,omitempty"` // Entries that could not be checked or re-encrypted.
+}
+
+// Reencrypt re-encrypts the named entries whose recipients do not match
+// the keys in the effective .gpg-id file for the entry. Entries that are
+// already encrypted to the right keys are left untouched, which makes it
+// much faster than rekeying the whole store using Init. If names is empty,
+// all entries in the store are considered.
+//
+// The gpgPassphrase is used to decrypt the stale entries, as in Show.
+// A failure for an individual entry is recorded in the returned report and
+// does not stop the remaining entries from being processed.
+func Reencrypt(ctx context.Context, names []string, gpgPassphrase string, options ...Option) (*ReencryptReport, error) {
+	opts := resolveOptions(options)
+	ctx = withDefaultPriority(ctx, Bulk)
+	if len(names) == 0 {
+		var err error
+		names, err = List(ctx, "", opts)
+		if err != nil {
+			return nil, fmt.Errorf("list: %w", err)
+		}
+	}
+
+	report := &ReencryptReport{
+		Failed: make(map[string]error),
+	}
+	expectedCache := make(map[string][]string) // .gpg-id path -> key IDs
+
+	for _, name := range names {
+		stale, err := isStale(ctx, name, expectedCache, opts)
+		if err != nil {
+			report.Failed[name] = err
+			continue
+		}
+		if !stale {
+			report.UpToDate = append(report.UpToDate, name)
+			continue
+		}
+
+		content, err := Show(ctx, name, gpgPassphrase, opts)
+		if err != nil {
+			report.Failed[name] = err
+			continue
+		}
+		// The content is unchanged, so it is not validated again.
+		if err := Insert(ctx, name, content, true, opts, WithoutValidation()); err != nil {
+			report.Failed[name] = err
+			continue
+		}
+		report.Reencrypted = append(report.Reencrypted, name)
+	}
+
+	return report, nil
+}
+
+// isStale reports whether the named entry is encrypted to a set of keys
+// different from those listed in its effective .gpg-id file. The cache
+// holds the expected keys for each .gpg-id file seen so far.
+func isStale(ctx context.Context, name string, cache map[string][]string, opts *Options) (bool, error) {
+	gpgIDFile, err := findGPGIDFile(name, opts)
+	if err != nil {
+		return false, err
+	}
+
+	expected, ok := cache[gpgIDFile]
+	if !ok {
+		ids, err := readGPGIDFile(gpgIDFile)
+		if err != nil {
+			return false, err
+		}
+		expected, err = encryptionKeyIDs(ctx, ids, cryptoOptions(gpgIDFile, opts))
+		if err != nil {
+			return false, err
+		}
+		cache[gpgIDFile] = expected
+	}
+
+	current, err := Recipients(ctx, name, opts)
+	if err != nil {
+		return false, err
+	}
+	return !equalStrings(current, expected), nil
+}
+
+// findGPGIDFile returns the path of the .gpg-id file that applies to the
+// named entry: the one in the closest enclosing directory of the store.
+// The files named in Options.Cryptos count as .gpg-id files.
+func findGPGIDFile(name string, opts *Options) (string, error) {
+	storeDir := filepath.Clean(resolveStoreDir(opts))
+	dir := filepath.Dir(filepath.Join(storeDir, name))
+	files := recipientsFiles(opts)
+
+	for {
+		var found []string
+		for _, f := range files {
+			p := filepath.Join(dir, f)
+			if _, err := os.Stat(p); err == nil {
+				found = append(found, p)
+			}
+		}
+		if len(found) > 1 {
+			return "", fmt.Errorf("more than one recipients file in %s", dir)
+		}
+		if len(found) == 1 {
+			return found[0], nil
+		}
+		if dir == storeDir || !strings.HasPrefix(dir, storeDir) {
+			return "", errors.New("no .gpg-id file found")
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// readGPGIDFile returns the GPG IDs listed in a .gpg-id file, ignoring
+// comments and blank lines.
+func readGPGIDFile(p string) ([]string, error) {
+	b, err := ioutil.ReadFile(p)
+	if err != nil {
+		return nil, fmt.Errorf("read .gpg-id: %w", err)
+	}
+
+	var ret []string
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	for sc.Scan() {
+		line := sc.Text()
+		if i := strings.IndexByte(line, '#'); i != -1 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line != "" {
+			ret = append(ret, line)
+		}
+	}
+	return ret, nil
+}
+
+// encryptionKeyIDs returns the long key IDs of the keys that gpg encrypts
+// to for the given GPG IDs, and so that pass encrypts to: for each key, the
+// newest valid encryption subkey or, if it has none, the primary key if it
+// can encrypt. Keys that are expired, revoked, invalid, or disabled are
+// skipped.
+func encryptionKeyIDs(ctx context.Context, gpgIDs []string, opts *Options) ([]string, error) {
+	if opts != nil && opts.Crypto != nil {
+		keys, err := opts.Crypto.RecipientKeys(ctx, gpgIDs)
+		if err != nil {
+			return nil, err
+		}
+		return uniqueSorted(keys), nil
+	}
+	keys, err := listPublicKeys(ctx, gpgIDs, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []string
+	for _, key := range keys {
+		primary := key[0]
+		if !primary.usable() || strings.Contains(primary.capabilities, "D") {
+			continue
+		}
+		var best *gpgKey
+		for i, sub := range key[1:] {
+			// gpg keeps the first of subkeys made at the same time.
+			if sub.usable() && strings.Contains(sub.capabilities, "e") && (best == nil || sub.created > best.created) {
+				best = &key[1+i]
+			}
+		}
+		if best == nil && strings.Contains(primary.capabilities, "e") {
+			best = &key[0]
+		}
+		if best != nil {
+			ret = append(ret, best.id)
+		}
+	}
+	return uniqueSorted(ret), nil
+}
+
+// gpgKey is a public key or subkey, as listed by gpg --with-colons.
+type gpgKey struct {
+	id           string // long key ID
+	validity     string
+	created      int64 // Unix time
+	capabilities string
+}
+
+// usable reports whether the key is not invalid, disabled, revoked, or
+// expired.
+func (k gpgKey) usable() bool {
+	return !strings.ContainsAny(k.validity, "idre")
+}
+
+// listPublicKeys returns the public keys matching the GPG IDs, each as its
+// primary key followed by its subkeys.
+func listPublicKeys(ctx context.Context, gpgIDs []string, opts *Options) ([][]gpgKey, error) {
+	args := []string{"--batch", "--with-colons", "--list-keys", "--"}
+	args = append(args, gpgIDs...)
+	stdout, _, err := execGPG(ctx, args, nil, opts)
+	if err != nil {
+		return nil, fmt.Errorf("exec gpg: %w", err)
+	}
+
+	var ret [][]gpgKey
+	for _, fields := range colonRecords(stdout) {
+		if (fields[0] != "pub" && fields[0] != "sub") || len(fields) < 12 {
+			continue
+		}
+		created, _ := strconv.ParseInt(fields[5], 10, 64)
+		k := gpgKey{id: fields[4], validity: fields[1], created: created, capabilities: fields[11]}
+		if fields[0] == "pub" {
+			ret = append(ret, []gpgKey{k})
+		} else if len(ret) > 0 {
+			ret[len(ret)-1] = append(ret[len(ret)-1], k)
+		}
+	}
+	return ret, nil
+}
+
+func uniqueSorted(s []string) []string {
+	sort.Strings(s)
+	var ret []string
+	for i, v := range s {
+		if i > 0 && v == s[i-1] {
+			continue
+		}
+		ret = append(ret, v)
+	}
+	return ret
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,80 @@
+package pass
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestReencrypt(t *testing.T) {
+	storeDir, err := ioutil.TempDir("", tmpDirPrefix)
+	if err != nil {
+		log.Fatalf("create tmp dir: %s", err)
+	}
+
+	opts := &Options{
+		StoreDir: storeDir,
+	}
+	ctx := context.Background()
+	err = Init(ctx, testGpgID, "", opts)
+	Ok(t, err)
+
+	err = Insert(ctx, "google.com/bar", []byte("my_password"), false, opts)
+	Ok(t, err)
+
+	report, err := Reencrypt(ctx, nil, testGpgPassphrase, opts)
+	Ok(t, err)
+	if len(report.UpToDate) != 1 || len(report.Reencrypted) != 0 || len(report.Failed) != 0 {
+		t.Errorf("unexpected report: %+v", report)
+		return
+	}
+	Equal(t, "google.com/bar", report.UpToDate[0])
+}
+
+func TestReadGPGIDFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", tmpDirPrefix)
+	if err != nil {
+		log.Fatalf("create tmp dir: %s", err)
+	}
+
+	p := filepath.Join(dir, ".gpg-id")
+	content := "# team keys\nalice@example.com\n\n  bob@example.com # backup\n"
+	if err := ioutil.WriteFile(p, []byte(content), 0600); err != nil {
+		log.Fatalf("write .gpg-id: %s", err)
+	}
+
+	ids, err := readGPGIDFile(p)
+	Ok(t, err)
+	if len(ids) != 2 {
+		t.Errorf("expected 2 ids, got %d", len(ids))
+		return
+	}
+	Equal(t, "alice@example.com", ids[0])
+	Equal(t, "bob@example.com", ids[1])
+}
+
+func TestEncryptionKeyIDs(t *testing.T) {
+	// A has an expired, a revoked, an older, and a newest encryption
+	// subkey; B has no subkeys; C is expired.
+	const keys = `pub:u:255:22:AAAA000000000001:100:::u:::scESC::::::23::0:
+sub:e:255:18:AAAA000000000002:400:500:::::e::::::23:
+sub:r:255:18:AAAA000000000003:400::::::e::::::23:
+sub:u:255:18:AAAA000000000004:200::::::e::::::23:
+sub:u:255:18:AAAA000000000005:300::::::e::::::23:
+sub:u:255:18:AAAA000000000006:400::::::s::::::23:
+pub:u:3072:1:BBBB000000000001:100:::u:::escaESCA::::::23::0:
+pub:e:255:22:CCCC000000000001:100:200::u:::scESC::::::23::0:
+sub:e:255:18:CCCC000000000002:100:200:::::e::::::23:
+`
+	runner := RunnerFunc(func(cmd *exec.Cmd) error {
+		fmt.Fprint(cmd.Stdout, keys)
+		return nil
+	})
+	ids, err := encryptionKeyIDs(context.Background(), []string{"a", "b", "c"}, &Options{Runner: runner})
+	Ok(t, err)
+	Equal(t, "[AAAA000000000005 BBBB000000000001]", fmt.Sprint(ids))
+}
@@ -0,0 +1,547 @@
+package pass
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrSyncConflict is returned by SyncRemote when a file was changed both
+// in the store and in the remote since the last sync.
+var ErrSyncConflict = errors.New("sync conflict")
+
+// syncStateDir is the folder, relative to the root of the store, that
+// SyncRemote keeps the state of the last sync with each remote in, if the
+// store is not a git repository. See syncStatePath.
+const syncStateDir = ".sync"
+
+// ConflictPolicy is how SyncRemote handles files that were changed both in
+// the store and in the remote.
+type ConflictPolicy int
+
+const (
+	ConflictFail       ConflictPolicy = iota // Leave both as is and report a conflict.
+	ConflictKeepLocal                        // Overwrite the remote with the store.
+	ConflictKeepRemote                       // Overwrite the store with the remote.
+)
+
+// RemoteSpec is a remote for SyncRemote. URLs with the http or https
+// scheme are WebDAV collections; anything else is an rsync destination,
+// such as "user@host:path/to/store", which rsync usually reaches over ssh.
+type RemoteSpec struct {
+	URL string
+
+	// Extra arguments to rsync, such as []string{"-e", "ssh -p 2222"}.
+	RsyncArgs []string
+
+	// HTTP client and basic auth credentials for WebDAV. If Client is
+	// nil, http.DefaultClient is used.
+	Client   *http.Client
+	Username string
+	Password string
+
+	OnConflict ConflictPolicy
+}
+
+// SyncConflict is a file that was changed both in the store and in the
+// remote since the last sync.
+type SyncConflict struct {
+	Path          string    `json:"path"` // Slash separated path relative to the store.
+	LocalModTime  time.Time `json:"local_mod_time"`
+	RemoteModTime time.Time `json:"remote_mod_time"`
+}
+
+// SyncReport is the result of SyncRemote. Paths are slash separated and
+// relative to the store.
+type SyncReport struct {
+	Pushed    []string       `json:"pushed"`
+	Pulled    []string       `json:"pulled"`
+	Conflicts []SyncConflict `json:"conflicts"`
+}
+
+// SyncRemote synchronizes the password files and .gpg-id files of the store
+// with a remote, as an alternative to git push and pull. Changes are
+// detected by comparing the SHA-256 sums of the files in the store and in
+// the remote with those at the last sync, and copied in the direction they
+// were made. Files that changed on both sides are handled according to
+// remote.OnConflict; with ConflictFail, they are listed in the report and
+// an error wrapping ErrSyncConflict is returned once everything else is
+// synced. Files pulled from the remote are committed if the store is a git
+// repository.
+//
+// WebDAV remotes use the layout of Mirror, so a remote can be restored
+// using Restore and a WebDAVStore.
+func SyncRemote(ctx context.Context, remote RemoteSpec, options ...Option) (*SyncReport, error) {
+	opts := resolveOptions(options)
+	var report *SyncReport
+	err := mutate(ctx, "sync-remote", nil, opts, func() error {
+		if err := checkACL([]string{""}, Write, opts); err != nil {
+			return err
+		}
+		var err error
+		report, err = syncRemote(ctx, remote, opts)
+		return err
+	})
+	return report, err
+}
+
+// syncFile is the state of a file on one side of a sync.
+type syncFile struct {
+	sum     string
+	modTime time.Time
+}
+
+// syncTarget is the remote side of a sync.
+type syncTarget interface {
+	files(ctx context.Context) (map[string]syncFile, error)
+	get(ctx context.Context, rel string) ([]byte, error)
+	put(ctx context.Context, rel string, b []byte, modTime time.Time) error
+	remove(ctx context.Context, rel string) error
+	// finish writes the changes made using put and remove to the remote.
+	finish(ctx context.Context) error
+}
+
+func syncRemote(ctx context.Context, remote RemoteSpec, opts *Options) (*SyncReport, error) {
+	storeDir := resolveStoreDir(opts)
+
+	var target syncTarget
+	if u, err := url.Parse(remote.URL); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		target = &objectTarget{store: &WebDAVStore{
+			URL:      remote.URL,
+			Client:   remote.Client,
+			Username: remote.Username,
+			Password: remote.Password,
+		}}
+	} else {
+		staging, err := ioutil.TempDir("", "go-pass-sync-")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(staging)
+		target = &rsyncTarget{remote: remote, dir: staging, opts: opts}
+	}
+
+	statePath := syncStatePath(storeDir, remote.URL)
+	// The state of stores that became git repositories after a sync is
+	// still in syncStateDir.
+	legacyPath := filepath.Join(storeDir, syncStateDir, syncStateName(remote.URL))
+	base := make(map[string]string)
+	b, err := ioutil.ReadFile(statePath)
+	if os.IsNotExist(err) && legacyPath != statePath {
+		b, err = ioutil.ReadFile(legacyPath)
+	}
+	if err == nil {
+		if err := json.Unmarshal(b, &base); err != nil {
+			return nil, fmt.Errorf("parse sync state: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("read sync state: %w", err)
+	}
+
+	local := make(map[string]syncFile)
+	err = walkStoreFiles(ctx, storeDir, opts, func(rel string, info os.FileInfo) error {
+		b, err := ioutil.ReadFile(filepath.Join(storeDir, filepath.FromSlash(rel)))
+		if err != nil {
+			return err
+		}
+		local[rel] = syncFile{sum: sha256Hex(b), modTime: info.ModTime()}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	remoteFiles, err := target.files(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list remote: %w", err)
+	}
+	// The manifest of a WebDAV remote is not signed, so its paths are
+	// checked like those of Restore, and only store files are pulled.
+	for rel := range remoteFiles {
+		if !validManifestPath(rel) {
+			return nil, fmt.Errorf("invalid path in remote: %s", rel)
+		}
+		if !isStoreFile(path.Base(rel), opts) || hasGitDir(rel) {
+			delete(remoteFiles, rel)
+		}
+	}
+
+	paths := make(map[string]bool)
+	for _, m := range []map[string]syncFile{local, remoteFiles} {
+		for rel := range m {
+			paths[rel] = true
+		}
+	}
+	for rel := range base {
+		paths[rel] = true
+	}
+	sorted := make([]string, 0, len(paths))
+	for rel := range paths {
+		sorted = append(sorted, rel)
+	}
+	sort.Strings(sorted)
+
+	report := &SyncReport{}
+	state := make(map[string]string)
+	for _, rel := range sorted {
+		l, r, b := local[rel], remoteFiles[rel], base[rel]
+
+		push, pull := false, false
+		switch {
+		case l.sum == r.sum:
+		case l.sum == b:
+			pull = true
+		case r.sum == b:
+			push = true
+		case remote.OnConflict == ConflictKeepLocal:
+			push = true
+		case remote.OnConflict == ConflictKeepRemote:
+			pull = true
+		default:
+			report.Conflicts = append(report.Conflicts, SyncConflict{
+				Path:          rel,
+				LocalModTime:  l.modTime,
+				RemoteModTime: r.modTime,
+			})
+			if b != "" {
+				state[rel] = b
+			}
+			continue
+		}
+
+		switch {
+		case push:
+			if err := pushFile(ctx, target, storeDir, rel, l); err != nil {
+				return nil, fmt.Errorf("push %s: %w", rel, err)
+			}
+			report.Pushed = append(report.Pushed, rel)
+		case pull:
+			if err := pullFile(ctx, target, storeDir, rel, r); err != nil {
+				return nil, fmt.Errorf("pull %s: %w", rel, err)
+			}
+			report.Pulled = append(report.Pulled, rel)
+		}
+		synced := r.sum
+		if push {
+			synced = l.sum
+		}
+		if synced != "" {
+			state[rel] = synced
+		}
+	}
+
+	if err := target.finish(ctx); err != nil {
+		return nil, fmt.Errorf("update remote: %w", err)
+	}
+
+	if len(report.Pulled) > 0 {
+		paths := make([]string, len(report.Pulled))
+		for i, rel := range report.Pulled {
+			paths[i] = filepath.FromSlash(rel)
+		}
+		msg := fmt.Sprintf("Sync %d files from %s.", len(paths), remote.URL)
+		if err := commitFiles(ctx, msg, paths, opts); err != nil {
+			return nil, fmt.Errorf("commit: %w", err)
+		}
+	}
+
+	b, err = json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(statePath), 0700); err != nil {
+		return nil, fmt.Errorf("write sync state: %w", err)
+	}
+	if err := ioutil.WriteFile(statePath, b, 0600); err != nil {
+		return nil, fmt.Errorf("write sync state: %w", err)
+	}
+	if legacyPath != statePath {
+		os.Remove(legacyPath)
+		os.Remove(filepath.Dir(legacyPath)) // if empty
+	}
+
+	if len(report.Conflicts) > 0 {
+		return report, fmt.Errorf("%w: %d files", ErrSyncConflict, len(report.Conflicts))
+	}
+	return report, nil
+}
+
+func pushFile(ctx context.Context, target syncTarget, storeDir, rel string, l syncFile) error {
+	if l.sum == "" {
+		return target.remove(ctx, rel)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(storeDir, filepath.FromSlash(rel)))
+	if err != nil {
+		return err
+	}
+	return target.put(ctx, rel, b, l.modTime)
+}
+
+func pullFile(ctx context.Context, target syncTarget, storeDir, rel string, r syncFile) error {
+	p := filepath.Join(storeDir, filepath.FromSlash(rel))
+	if r.sum == "" {
+		return os.Remove(p)
+	}
+	b, err := target.get(ctx, rel)
+	if err != nil {
+		return err
+	}
+	if sha256Hex(b) != r.sum {
+		return errors.New("file changed in remote during sync")
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(p, b, 0600); err != nil {
+		return err
+	}
+	if !r.modTime.IsZero() {
+		return os.Chtimes(p, r.modTime, r.modTime)
+	}
+	return nil
+}
+
+// syncStatePath returns the path of the file that SyncRemote keeps the
+// state of the last sync with the remote in. It is in the git directory of
+// git repositories, so that it does not leave the work tree dirty.
+func syncStatePath(storeDir, remoteURL string) string {
+	if info, err := os.Stat(filepath.Join(storeDir, ".git")); err == nil && info.IsDir() {
+		return filepath.Join(storeDir, ".git", "go-pass-sync", syncStateName(remoteURL))
+	}
+	return filepath.Join(storeDir, syncStateDir, syncStateName(remoteURL))
+}
+
+// syncStateName returns the name of the file holding the state of the last
+// sync with the remote.
+func syncStateName(remoteURL string) string {
+	return sha256Hex([]byte(remoteURL))[:16] + ".json"
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// objectTarget syncs with an ObjectStore in the layout of Mirror.
+type objectTarget struct {
+	store ObjectStore
+	m     *MirrorManifest
+}
+
+func (t *objectTarget) files(ctx context.Context) (map[string]syncFile, error) {
+	m, err := readMirrorManifest(ctx, t.store)
+	if errors.Is(err, ErrObjectNotFound) {
+		m = &MirrorManifest{}
+	} else if err != nil {
+		return nil, err
+	}
+	if m.Files == nil {
+		m.Files = make(map[string]string)
+	}
+	if m.ModTimes == nil {
+		m.ModTimes = make(map[string]time.Time)
+	}
+	t.m = m
+
+	ret := make(map[string]syncFile, len(m.Files))
+	for rel, sum := range m.Files {
+		ret[rel] = syncFile{sum: sum, modTime: m.ModTimes[rel]}
+	}
+	return ret, nil
+}
+
+func (t *objectTarget) get(ctx context.Context, rel string) ([]byte, error) {
+	return t.store.Get(ctx, mirrorFilesPrefix+rel)
+}
+
+func (t *objectTarget) put(ctx context.Context, rel string, b []byte, modTime time.Time) error {
+	if err := t.store.Put(ctx, mirrorFilesPrefix+rel, b); err != nil {
+		return err
+	}
+	t.m.Files[rel] = sha256Hex(b)
+	t.m.ModTimes[rel] = modTime.UTC()
+	return nil
+}
+
+func (t *objectTarget) remove(ctx context.Context, rel string) error {
+	if err := t.store.Delete(ctx, mirrorFilesPrefix+rel); err != nil {
+		return err
+	}
+	delete(t.m.Files, rel)
+	delete(t.m.ModTimes, rel)
+	return nil
+}
+
+func (t *objectTarget) finish(ctx context.Context) error {
+	t.m.Time = time.Now().UTC()
+	b, err := json.Marshal(t.m)
+	if err != nil {
+		return err
+	}
+	return t.store.Put(ctx, mirrorManifestKey, b)
+}
+
+// rsyncTarget syncs with an rsync destination through a local copy of it
+// in dir.
+type rsyncTarget struct {
+	remote RemoteSpec
+	dir    string
+	opts   *Options
+}
+
+func (t *rsyncTarget) rsync(ctx context.Context, src, dst string) error {
+	args := []string{"--archive", "--delete"}
+	args = append(args, t.remote.RsyncArgs...)
+	args = append(args, "--", src, dst)
+	_, _, err := runCommand(ctx, "rsync", "rsync", args, baseEnv(t.opts), nil, nil, t.opts)
+	return err
+}
+
+func (t *rsyncTarget) files(ctx context.Context) (map[string]syncFile, error) {
+	if err := t.rsync(ctx, strings.TrimSuffix(t.remote.URL, "/")+"/", t.dir+"/"); err != nil {
+		return nil, err
+	}
+	ret := make(map[string]syncFile)
+	err := walkStoreFiles(ctx, t.dir, t.opts, func(rel string, info os.FileInfo) error {
+		b, err := ioutil.ReadFile(filepath.Join(t.dir, filepath.FromSlash(rel)))
+		if err != nil {
+			return err
+		}
+		ret[rel] = syncFile{sum: sha256Hex(b), modTime: info.ModTime()}
+		return nil
+	})
+	return ret, err
+}
+
+func (t *rsyncTarget) get(ctx context.Context, rel string) ([]byte, error) {
+	return ioutil.ReadFile(filepath.Join(t.dir, filepath.FromSlash(rel)))
+}
+
+func (t *rsyncTarget) put(ctx context.Context, rel string, b []byte, modTime time.Time) error {
+	p := filepath.Join(t.dir, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(p, b, 0600); err != nil {
+		return err
+	}
+	return os.Chtimes(p, modTime, modTime)
+}
+
+func (t *rsyncTarget) remove(ctx context.Context, rel string) error {
+	return os.Remove(filepath.Join(t.dir, filepath.FromSlash(rel)))
+}
+
+func (t *rsyncTarget) finish(ctx context.Context) error {
+	return t.rsync(ctx, t.dir+"/", strings.TrimSuffix(t.remote.URL, "/")+"/")
+}
+
+// WebDAVStore is an ObjectStore backed by a WebDAV collection. Missing
+// collections are created as needed.
+type WebDAVStore struct {
+	URL      string
+	Client   *http.Client // If nil, http.DefaultClient is used.
+	Username string
+	Password string
+}
+
+func (w *WebDAVStore) do(ctx context.Context, method, key string, body []byte) (*http.Response, error) {
+	u := strings.TrimSuffix(w.URL, "/") + "/" + key
+	req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if w.Username != "" || w.Password != "" {
+		req.SetBasicAuth(w.Username, w.Password)
+	}
+	c := w.Client
+	if c == nil {
+		c = http.DefaultClient
+	}
+	return c.Do(req)
+}
+
+func (w *WebDAVStore) Put(ctx context.Context, key string, data []byte) error {
+	resp, err := w.do(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusConflict {
+		// The parent collection does not exist.
+		if err := w.mkcol(ctx, path.Dir(key)); err != nil {
+			return err
+		}
+		resp, err = w.do(ctx, http.MethodPut, key, data)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+	}
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("put %s: %s", key, resp.Status)
+	}
+	return nil
+}
+
+// mkcol creates the collection dir and its missing parents.
+func (w *WebDAVStore) mkcol(ctx context.Context, dir string) error {
+	if dir == "." || dir == "/" {
+		return nil
+	}
+	resp, err := w.do(ctx, "MKCOL", dir+"/", nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusConflict {
+		if err := w.mkcol(ctx, path.Dir(dir)); err != nil {
+			return err
+		}
+		return w.mkcol(ctx, dir)
+	}
+	// 405 Method Not Allowed means the collection already exists.
+	if resp.StatusCode/100 != 2 && resp.StatusCode != http.StatusMethodNotAllowed {
+		return fmt.Errorf("mkcol %s: %s", dir, resp.Status)
+	}
+	return nil
+}
+
+func (w *WebDAVStore) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := w.do(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrObjectNotFound, key)
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("get %s: %s", key, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+func (w *WebDAVStore) Delete(ctx context.Context, key string) error {
+	resp, err := w.do(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("delete %s: %s", key, resp.Status)
+	}
+	return nil
+}
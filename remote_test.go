@@ -0,0 +1,195 @@
+package pass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeWebDAV is a WebDAV server that keeps files in memory.
+type fakeWebDAV struct {
+	mu    sync.Mutex
+	files map[string][]byte
+	cols  map[string]bool
+}
+
+func (f *fakeWebDAV) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p := strings.TrimSuffix(r.URL.Path, "/")
+	parent := p[:strings.LastIndexByte(p, '/')]
+	switch r.Method {
+	case "MKCOL":
+		if !f.cols[parent] {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		f.cols[p] = true
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodPut:
+		if !f.cols[parent] {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		b, _ := ioutil.ReadAll(r.Body)
+		f.files[p] = b
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodGet:
+		b, ok := f.files[p]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(b)
+	case http.MethodDelete:
+		delete(f.files, p)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func writeTestFile(t *testing.T, storeDir, name, content string) {
+	t.Helper()
+	p := filepath.Join(storeDir, filepath.FromSlash(name))
+	Ok(t, os.MkdirAll(filepath.Dir(p), 0700))
+	Ok(t, ioutil.WriteFile(p, []byte(content), 0600))
+}
+
+func testSyncRemote(t *testing.T, remote RemoteSpec) {
+	ctx := context.Background()
+	a := makeTestTree([]string{".gpg-id"})
+	defer os.RemoveAll(a)
+	b := makeTestTree(nil)
+	defer os.RemoveAll(b)
+
+	writeTestFile(t, a, "x/a.gpg", "1")
+	report, err := SyncRemote(ctx, remote, WithStoreDir(a))
+	Ok(t, err)
+	Equal(t, "[.gpg-id x/a.gpg]", fmt.Sprint(report.Pushed))
+
+	report, err = SyncRemote(ctx, remote, WithStoreDir(b))
+	Ok(t, err)
+	Equal(t, "[.gpg-id x/a.gpg]", fmt.Sprint(report.Pulled))
+	content, err := ioutil.ReadFile(filepath.Join(b, "x", "a.gpg"))
+	Ok(t, err)
+	Equal(t, "1", string(content))
+
+	writeTestFile(t, b, "x/a.gpg", "2")
+	Ok(t, os.Remove(filepath.Join(b, ".gpg-id")))
+	report, err = SyncRemote(ctx, remote, WithStoreDir(b))
+	Ok(t, err)
+	Equal(t, "[.gpg-id x/a.gpg]", fmt.Sprint(report.Pushed))
+
+	writeTestFile(t, a, "x/a.gpg", "3")
+	report, err = SyncRemote(ctx, remote, WithStoreDir(a))
+	if !errors.Is(err, ErrSyncConflict) {
+		t.Fatalf("expected ErrSyncConflict, got: %v", err)
+	}
+	Equal(t, "[.gpg-id]", fmt.Sprint(report.Pulled))
+	Equal(t, "1", fmt.Sprint(len(report.Conflicts)))
+	Equal(t, "x/a.gpg", report.Conflicts[0].Path)
+
+	remote.OnConflict = ConflictKeepRemote
+	report, err = SyncRemote(ctx, remote, WithStoreDir(a))
+	Ok(t, err)
+	Equal(t, "[x/a.gpg]", fmt.Sprint(report.Pulled))
+	content, err = ioutil.ReadFile(filepath.Join(a, "x", "a.gpg"))
+	Ok(t, err)
+	Equal(t, "2", string(content))
+}
+
+func TestSyncRemoteWebDAV(t *testing.T) {
+	srv := httptest.NewServer(&fakeWebDAV{
+		files: make(map[string][]byte),
+		cols:  map[string]bool{"": true, "/store": true},
+	})
+	defer srv.Close()
+
+	testSyncRemote(t, RemoteSpec{URL: srv.URL + "/store"})
+}
+
+func TestSyncRemoteRsync(t *testing.T) {
+	// Copies the second to last argument to the last argument.
+	defer fakeCommand("rsync", `
+for arg; do src="$dst"; dst="$arg"; done
+mkdir -p "$dst" && find "$dst" -mindepth 1 -delete && cp -a "$src." "$dst"`)()
+
+	dir, err := ioutil.TempDir("", tmpDirPrefix)
+	if err != nil {
+		log.Fatalf("create tmp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	testSyncRemote(t, RemoteSpec{URL: dir})
+}
+
+func TestSyncRemoteStateInGitDir(t *testing.T) {
+	srv := httptest.NewServer(&fakeWebDAV{
+		files: make(map[string][]byte),
+		cols:  map[string]bool{"": true, "/store": true},
+	})
+	defer srv.Close()
+	remote := RemoteSpec{URL: srv.URL + "/store"}
+
+	ctx := context.Background()
+	a := makeTestTree([]string{".gpg-id", "a.gpg"})
+	defer os.RemoveAll(a)
+
+	_, err := SyncRemote(ctx, remote, WithStoreDir(a))
+	Ok(t, err)
+
+	// The state kept in the store before it became a git repository is
+	// still used, and moved into the git directory.
+	Ok(t, exec.Command("git", "init", "-q", a).Run())
+	report, err := SyncRemote(ctx, remote, WithStoreDir(a))
+	Ok(t, err)
+	Equal(t, "0", fmt.Sprint(len(report.Pushed)+len(report.Pulled)))
+	if _, err := os.Stat(filepath.Join(a, syncStateDir)); !os.IsNotExist(err) {
+		t.Errorf("expected %s to be removed, got: %v", syncStateDir, err)
+	}
+	_, err = os.Stat(syncStatePath(a, remote.URL))
+	Ok(t, err)
+}
+
+func TestSyncRemoteBadManifest(t *testing.T) {
+	ctx := context.Background()
+	storeDir := makeTestTree([]string{".gpg-id"})
+	defer os.RemoveAll(storeDir)
+	sum := func(s string) string { return sha256Hex([]byte(s)) }
+	dav := &fakeWebDAV{
+		files: map[string][]byte{
+			"/store/manifest.json": []byte(fmt.Sprintf(`{"files":{"../outside.gpg":%q}}`, sum("pwned"))),
+			"/outside.gpg":         []byte("pwned"),
+		},
+		cols: map[string]bool{"": true, "/store": true},
+	}
+	srv := httptest.NewServer(dav)
+	defer srv.Close()
+	remote := RemoteSpec{URL: srv.URL + "/store"}
+
+	_, err := SyncRemote(ctx, remote, WithStoreDir(storeDir))
+	Equal(t, "invalid path in remote: ../outside.gpg", fmt.Sprint(err))
+	if _, err := os.Stat(filepath.Join(filepath.Dir(storeDir), "outside.gpg")); !os.IsNotExist(err) {
+		t.Errorf("expected no file outside of the store, got: %v", err)
+	}
+
+	// Only store files are pulled.
+	dav.files = map[string][]byte{
+		"/store/manifest.json":          []byte(fmt.Sprintf(`{"files":{"a.gpg":%q,"notes.txt":%q,".git/hooks/x.gpg":%q}}`, sum("a"), sum("n"), sum("x"))),
+		"/store/files/a.gpg":            []byte("a"),
+		"/store/files/notes.txt":        []byte("n"),
+		"/store/files/.git/hooks/x.gpg": []byte("x"),
+	}
+	report, err := SyncRemote(ctx, remote, WithStoreDir(storeDir))
+	Ok(t, err)
+	Equal(t, "[a.gpg]", fmt.Sprint(report.Pulled))
+}
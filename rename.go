@@ -0,0 +1,87 @@
+package pass
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+)
+
+// Rename is a rename of an entry by RenameAll.
+type Rename struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// RenameMapper returns the new name of the entry named old, or reports
+// that it is to be skipped. Returning old as the new name also skips it.
+type RenameMapper func(old string) (new string, skip bool)
+
+// RenameAll renames the entries of the store as given by mapper, for
+// migrating to a new naming scheme. It returns the renames, in order of
+// their old names. If dryRun is set, nothing is changed.
+//
+// The renames are done as by Move, as a Batch, so they are committed
+// together, and none of them are kept if one fails. It is an error for two
+// entries to get the same new name, or for a new name to be an existing
+// entry.
+func RenameAll(ctx context.Context, mapper RenameMapper, dryRun bool, options ...Option) ([]Rename, error) {
+	opts := resolveOptions(options)
+	renames, err := planRenames(ctx, mapper, opts)
+	if err != nil || dryRun {
+		return renames, err
+	}
+
+	var names []string
+	for _, r := range renames {
+		names = append(names, r.Old, r.New)
+	}
+	err = mutate(ctx, "rename-all", names, opts, func() error {
+		msg := fmt.Sprintf("Rename %d entries.", len(renames))
+		return batch(ctx, msg, func(tx *Tx) error {
+			for _, r := range renames {
+				if err := tx.Move(r.Old, r.New, false); err != nil {
+					return fmt.Errorf("move %s: %w", r.Old, err)
+				}
+			}
+			return nil
+		}, opts)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return renames, nil
+}
+
+func planRenames(ctx context.Context, mapper RenameMapper, opts *Options) ([]Rename, error) {
+	entries, err := List(ctx, "", opts)
+	if err != nil {
+		return nil, fmt.Errorf("list: %w", err)
+	}
+	sort.Strings(entries)
+
+	var ret []Rename
+	seen := make(map[string]string) // new name -> old name
+	for _, old := range entries {
+		newName, skip := mapper(old)
+		if skip {
+			continue
+		}
+		newName = normalizeName(filepath.Clean(newName), opts)
+		if newName == old {
+			continue
+		}
+		if newName == "." || newName == "" {
+			return nil, fmt.Errorf("%s: empty new name", old)
+		}
+		if prev, ok := seen[newName]; ok {
+			return nil, fmt.Errorf("%s and %s: same new name %s", prev, old, newName)
+		}
+		if entryExists(newName, opts) {
+			return nil, fmt.Errorf("%s: new name %s already exists", old, newName)
+		}
+		seen[newName] = old
+		ret = append(ret, Rename{Old: old, New: newName})
+	}
+	return ret, nil
+}
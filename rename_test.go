@@ -0,0 +1,75 @@
+package pass
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenameAll(t *testing.T) {
+	defer fakeCommand("pass", `
+case "$1" in
+git) shift; exec git -C "$PASSWORD_STORE_DIR" "$@" ;;
+mv)
+	shift
+	mkdir -p "$(dirname "$PASSWORD_STORE_DIR/$2")"
+	git -C "$PASSWORD_STORE_DIR" mv "$1.gpg" "$2.gpg" &&
+	git -C "$PASSWORD_STORE_DIR" commit -q -m "Rename $1 to $2."
+	;;
+esac`)()
+
+	dir := makeTestTree(nil)
+	defer os.RemoveAll(dir)
+	env, fprs := testKeyring(t, filepath.Join(dir, "gnupg"), "A <a@example.com>")
+	env = append(env, "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com", "GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+	opts := &Options{StoreDir: filepath.Join(dir, "store"), Env: env}
+	ctx := context.Background()
+
+	writeTestFile(t, opts.StoreDir, ".gpg-id", fprs[0]+"\n")
+	for _, name := range []string{"github.com", "gitlab.com", "keep", "web/example.com"} {
+		p, err := encryptTemp(ctx, opts.StoreDir, []byte("x\n"), fprs, opts)
+		Ok(t, err)
+		Ok(t, os.MkdirAll(filepath.Dir(filepath.Join(opts.StoreDir, name+".gpg")), 0700))
+		Ok(t, os.Rename(p, filepath.Join(opts.StoreDir, name+".gpg")))
+	}
+	Ok(t, Git(ctx, []string{"init", "-q"}, opts))
+	Ok(t, Git(ctx, []string{"add", "-A"}, opts))
+	Ok(t, Git(ctx, []string{"commit", "-q", "-m", "Initial."}, opts))
+
+	mapper := func(old string) (string, bool) {
+		if !strings.HasSuffix(old, ".com") || strings.Contains(old, string(filepath.Separator)) {
+			return "", true
+		}
+		return filepath.Join("web", old), false
+	}
+
+	renames, err := RenameAll(ctx, mapper, true, opts)
+	Ok(t, err)
+	Equal(t, "[{github.com web/github.com} {gitlab.com web/gitlab.com}]", filepath.ToSlash(fmt.Sprint(renames)))
+	if !entryExists("github.com", opts) {
+		t.Fatalf("expected dry run to change nothing")
+	}
+
+	renames, err = RenameAll(ctx, mapper, false, opts)
+	Ok(t, err)
+	Equal(t, "2", fmt.Sprint(len(renames)))
+	list, err := List(ctx, "", opts)
+	Ok(t, err)
+	Equal(t, "[keep web/example.com web/github.com web/gitlab.com]", filepath.ToSlash(fmt.Sprint(list)))
+	out, err := gitOutput(ctx, []string{"log", "--format=%s"}, opts)
+	Ok(t, err)
+	Equal(t, "Rename 2 entries.\nInitial.", strings.TrimSpace(string(out)))
+
+	// Conflicting new names.
+	_, err = RenameAll(ctx, func(old string) (string, bool) { return "same", false }, true, opts)
+	if err == nil {
+		t.Errorf("expected error for duplicate new names")
+	}
+	_, err = RenameAll(ctx, func(old string) (string, bool) { return "keep", old == "web/example.com" }, true, opts)
+	if err == nil {
+		t.Errorf("expected error for existing new name")
+	}
+}
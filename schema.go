@@ -0,0 +1,164 @@
+package pass
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// FieldFormat is the format required of the value of a field.
+type FieldFormat int
+
+const (
+	AnyFormat   FieldFormat = iota
+	URLFormat               // An absolute URL with a host.
+	EmailFormat             // A bare email address.
+)
+
+// FieldRule is a rule of a Schema for the field with the key.
+type FieldRule struct {
+	Key      string // Matched ignoring case, like Secret.Field.
+	Required bool
+	Format   FieldFormat
+	Pattern  *regexp.Regexp // Optional. Must match the whole value.
+}
+
+// Schema is the structure required of the entries of a folder.
+type Schema struct {
+	Fields []FieldRule
+}
+
+// Schemas maps folders to the schema of the entries in them, including
+// those in subfolders without a schema of their own. The root of the store
+// is "". Use Schemas.Validate as Options.Validator to check entries on
+// Insert, and Lint to check existing entries.
+type Schemas map[string]Schema
+
+// FieldError is a field that does not conform to a FieldRule.
+type FieldError struct {
+	Key    string
+	Reason string
+}
+
+func (e FieldError) Error() string { return fmt.Sprintf("field %s: %s", e.Key, e.Reason) }
+
+// SchemaError is the list of fields of an entry that do not conform to
+// its schema.
+type SchemaError []FieldError
+
+func (e SchemaError) Error() string {
+	s := make([]string, len(e))
+	for i, fe := range e {
+		s[i] = fe.Error()
+	}
+	return strings.Join(s, "; ")
+}
+
+// Validate is a Validator that checks the content of the named entry
+// against the schema of its folder. The error it returns is a SchemaError.
+func (s Schemas) Validate(name string, content []byte) error {
+	schema, ok := s.lookup(name)
+	if !ok {
+		return nil
+	}
+	var errs SchemaError
+	for _, r := range schema.Fields {
+		v, ok := Secret(content).Field(r.Key)
+		if !ok {
+			if r.Required {
+				errs = append(errs, FieldError{r.Key, "missing"})
+			}
+			continue
+		}
+		if reason := r.check(string(v)); reason != "" {
+			errs = append(errs, FieldError{r.Key, reason})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// lookup returns the schema of the deepest folder containing the named
+// entry that has one.
+func (s Schemas) lookup(name string) (Schema, bool) {
+	dir := path.Dir(strings.ReplaceAll(name, "\\", "/"))
+	for {
+		if dir == "." || dir == "/" {
+			dir = ""
+		}
+		if schema, ok := s[dir]; ok {
+			return schema, true
+		}
+		if dir == "" {
+			return Schema{}, false
+		}
+		dir = path.Dir(dir)
+	}
+}
+
+func (r FieldRule) check(v string) string {
+	switch r.Format {
+	case URLFormat:
+		if u, err := url.Parse(v); err != nil || !u.IsAbs() || u.Host == "" {
+			return "not a url"
+		}
+	case EmailFormat:
+		if a, err := mail.ParseAddress(v); err != nil || a.Address != v {
+			return "not an email address"
+		}
+	}
+	if r.Pattern != nil {
+		if loc := r.Pattern.FindStringIndex(v); loc == nil || loc[0] != 0 || loc[1] != len(v) {
+			return fmt.Sprintf("does not match %s", r.Pattern)
+		}
+	}
+	return ""
+}
+
+// LintIssue is an entry rejected by Lint.
+type LintIssue struct {
+	Name string `json:"name"`
+	Err  error  `json:"error"` // The error returned by the Validator.
+}
+
+// Lint checks the entries in the subfolder, or the whole store if it is
+// "", with Options.Validator, or DefaultValidator if it is nil, and
+// returns the entries it rejects. The entries are decrypted as by
+// ShowMany.
+func Lint(ctx context.Context, subfolder, gpgPassphrase string, options ...Option) ([]LintIssue, error) {
+	opts := resolveOptions(options)
+	ret, err := lint(ctx, subfolder, gpgPassphrase, opts)
+	if aErr := audit(ctx, "lint", []string{subfolder}, err, opts); aErr != nil && err == nil {
+		return nil, fmt.Errorf("write audit log: %w", aErr)
+	}
+	return ret, err
+}
+
+func lint(ctx context.Context, subfolder, gpgPassphrase string, opts *Options) ([]LintIssue, error) {
+	names, err := List(ctx, subfolder, opts)
+	if err != nil {
+		return nil, fmt.Errorf("list: %w", err)
+	}
+	contents, err := showMany(ctx, names, gpgPassphrase, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	v := opts.Validator
+	if v == nil {
+		v = DefaultValidator
+	}
+	var ret []LintIssue
+	for _, name := range names {
+		if err := v(name, contents[name]); err != nil {
+			ret = append(ret, LintIssue{Name: name, Err: err})
+		}
+	}
+	return ret, nil
+}
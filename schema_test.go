@@ -0,0 +1,87 @@
+package pass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+var testSchemas = Schemas{
+	"web": {Fields: []FieldRule{
+		{Key: "username", Required: true},
+		{Key: "url", Required: true, Format: URLFormat},
+		{Key: "email", Format: EmailFormat},
+	}},
+	"web/legacy": {},
+	"db": {Fields: []FieldRule{
+		{Key: "port", Pattern: regexp.MustCompile(`\d+`)},
+	}},
+}
+
+func TestSchemasValidate(t *testing.T) {
+	testcases := []struct {
+		name, content string
+		errs          string
+	}{
+		{"web/a", "x\nusername: bob\nurl: https://example.com\n", ""},
+		{"web/sub/a", "x\nUser: bob\nURL: example.com\nemail: Bob <bob@example.com>\n", "[username missing url not a url email not an email address]"},
+		{"web/a", "x\nusername: bob\nurl: https://example.com\nemail: bob@example.com", ""},
+		{"web/legacy/a", "x\n", ""},
+		{"db/a", "x\nport: 5432\n", ""},
+		{"db/a", "x\nport: 5432x\n", "[port does not match \\d+]"},
+		{"other", "x\n", ""},
+	}
+	for _, tc := range testcases {
+		err := testSchemas.Validate(tc.name, []byte(tc.content))
+		var got SchemaError
+		errors.As(err, &got)
+		var errs []string
+		for _, fe := range got {
+			errs = append(errs, fe.Key+" "+fe.Reason)
+		}
+		if tc.errs == "" {
+			Ok(t, err)
+			continue
+		}
+		Equal(t, tc.errs, fmt.Sprint(errs))
+	}
+
+	// As Options.Validator.
+	err := validate("web/a", []byte("x\n"), &Options{Validator: testSchemas.Validate})
+	if !errors.Is(err, ErrInvalidContent) {
+		t.Errorf("expected ErrInvalidContent, got: %v", err)
+	}
+}
+
+func TestLint(t *testing.T) {
+	dir := makeTestTree(nil)
+	defer os.RemoveAll(dir)
+	env, fprs := testKeyring(t, filepath.Join(dir, "gnupg"), "A <a@example.com>")
+	opts := &Options{StoreDir: filepath.Join(dir, "store"), Env: env, Validator: testSchemas.Validate}
+	ctx := context.Background()
+
+	Ok(t, os.MkdirAll(filepath.Join(opts.StoreDir, "web"), 0700))
+	for name, content := range map[string]string{
+		"web/good": "x\nusername: bob\nurl: https://example.com\n",
+		"web/bad":  "x\nurl: https://example.com\n",
+		"db":       "x\n",
+	} {
+		p, err := encryptTemp(ctx, opts.StoreDir, []byte(content), fprs, opts)
+		Ok(t, err)
+		Ok(t, os.Rename(p, filepath.Join(opts.StoreDir, name+".gpg")))
+	}
+
+	issues, err := Lint(ctx, "", "", opts)
+	Ok(t, err)
+	Equal(t, "1", fmt.Sprint(len(issues)))
+	Equal(t, filepath.Join("web", "bad"), issues[0].Name)
+	Equal(t, "field username: missing", issues[0].Err.Error())
+
+	issues, err = Lint(ctx, "", "", opts, &Options{StoreDir: opts.StoreDir, Env: env})
+	Ok(t, err)
+	Equal(t, "0", fmt.Sprint(len(issues)))
+}
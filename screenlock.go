@@ -0,0 +1,125 @@
+package pass
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// screenLockPollInterval is how often LockOnScreenLock checks whether the
+// screen is locked on macOS.
+const screenLockPollInterval = 3 * time.Second
+
+// LockOnScreenLock locks the store, as with Lock, each time the screen is
+// locked, until ctx is done, so that its secrets are wiped when the user
+// walks away. It returns once it watches the screen.
+//
+// On Linux, it listens for the Lock signal of logind on the system bus,
+// and for the ActiveChanged signal of the screensaver on the session bus,
+// using dbus-monitor. On macOS, it checks whether the screen is locked
+// every few seconds, using ioreg. Other systems are not supported.
+func (s *Store) LockOnScreenLock(ctx context.Context) error {
+	switch runtime.GOOS {
+	case "linux":
+		if _, err := exec.LookPath("dbus-monitor"); err != nil {
+			return err
+		}
+		lock := func() { s.Lock(ctx) }
+		go s.watchDBus(ctx, "--system", lock,
+			"type='signal',interface='org.freedesktop.login1.Session',member='Lock'")
+		go s.watchDBus(ctx, "--session", lock,
+			"type='signal',interface='org.freedesktop.ScreenSaver',member='ActiveChanged'",
+			"type='signal',interface='org.gnome.ScreenSaver',member='ActiveChanged'")
+		return nil
+	case "darwin":
+		if _, err := exec.LookPath("ioreg"); err != nil {
+			return err
+		}
+		go s.pollScreenLock(ctx)
+		return nil
+	}
+	return fmt.Errorf("screen lock is not supported on %s", runtime.GOOS)
+}
+
+// watchDBus runs dbus-monitor on the bus with the match rules until ctx is
+// done, calling lock for each signal that locks the screen.
+func (s *Store) watchDBus(ctx context.Context, bus string, lock func(), rules ...string) error {
+	activeChanged := false // the last signal was ActiveChanged
+	w := &lineWriter{fn: func(line string) {
+		switch {
+		case strings.HasPrefix(line, "signal "):
+			activeChanged = strings.HasSuffix(line, "member=ActiveChanged")
+			if strings.HasSuffix(line, "member=Lock") {
+				lock()
+			}
+		case activeChanged && strings.TrimSpace(line) == "boolean true":
+			activeChanged = false
+			lock()
+		}
+	}}
+	cmd := exec.CommandContext(ctx, "dbus-monitor", append([]string{bus}, rules...)...)
+	cmd.Stdout = w
+	return s.runner().Run(cmd)
+}
+
+// pollScreenLock locks the store each time the screen becomes locked,
+// until ctx is done.
+func (s *Store) pollScreenLock(ctx context.Context) {
+	wasLocked := false
+	t := time.NewTicker(screenLockPollInterval)
+	defer t.Stop()
+	for {
+		locked, err := s.screenLocked(ctx)
+		if err == nil {
+			if locked && !wasLocked {
+				s.Lock(ctx)
+			}
+			wasLocked = locked
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// screenLocked reports whether the screen is locked on macOS.
+func (s *Store) screenLocked(ctx context.Context) (bool, error) {
+	stdout, _, err := runCommand(ctx, "ioreg", "ioreg", []string{"-n", "Root", "-d1"}, nil, nil, nil, &Options{Runner: s.opts.Runner})
+	if err != nil {
+		return false, err
+	}
+	return bytes.Contains(stdout, []byte(`"CGSSessionScreenIsLocked"=Yes`)), nil
+}
+
+func (s *Store) runner() Runner {
+	if s.opts.Runner != nil {
+		return s.opts.Runner
+	}
+	return defaultRunner{}
+}
+
+// lineWriter is an io.Writer that calls fn for each line written to it,
+// without the newline.
+type lineWriter struct {
+	fn  func(string)
+	buf []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i == -1 {
+			break
+		}
+		w.fn(string(w.buf[:i]))
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
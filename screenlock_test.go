@@ -0,0 +1,45 @@
+package pass
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestLockOnScreenLock(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("dbus-monitor is used on linux only")
+	}
+	defer fakeCommand("dbus-monitor", `
+if [ "$1" = --system ]; then
+	echo "signal time=1.0 sender=:1.2 -> destination=(null destination) serial=5 path=/org/freedesktop/login1/session/_32; interface=org.freedesktop.login1.Session; member=Lock"
+else
+	echo "signal time=1.0 sender=:1.9 -> destination=(null destination) serial=7 path=/org/freedesktop/ScreenSaver; interface=org.freedesktop.ScreenSaver; member=ActiveChanged"
+	echo "   boolean false"
+	echo "signal time=2.0 sender=:1.9 -> destination=(null destination) serial=8 path=/org/freedesktop/ScreenSaver; interface=org.freedesktop.ScreenSaver; member=ActiveChanged"
+	echo "   boolean true"
+fi`)()
+
+	storeDir := makeTestTree(nil)
+	defer os.RemoveAll(storeDir)
+	locked := make(chan struct{}, 3)
+	s := NewStore(&Options{StoreDir: storeDir, OnLock: func() { locked <- struct{}{} }})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	Ok(t, s.LockOnScreenLock(ctx))
+	for i := 0; i < 2; i++ {
+		select {
+		case <-locked:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("store locked %d times, expected 2", i)
+		}
+	}
+	select {
+	case <-locked:
+		t.Errorf("store locked when the screensaver was deactivated")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
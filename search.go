@@ -0,0 +1,55 @@
+package pass
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+)
+
+// ContentMatch is a line of an entry matched by SearchContent.
+type ContentMatch struct {
+	Name string `json:"name"` // The name of the entry.
+	Line int    `json:"line"` // The line number, starting at 1.
+	Text Secret `json:"text"` // The content of the line.
+}
+
+// SearchContent returns the lines of the entries in subfolder, or the
+// whole store if subfolder is empty, that match re. It is like the "grep"
+// subcommand, but decrypts the entries in parallel, as ShowMany does, and
+// uses Go regular expressions. The matches are in the order of List.
+func SearchContent(ctx context.Context, re *regexp.Regexp, subfolder, gpgPassphrase string, options ...Option) ([]ContentMatch, error) {
+	opts := resolveOptions(options)
+	ret, err := searchContent(ctx, re, subfolder, gpgPassphrase, opts)
+	if aErr := audit(ctx, "search", []string{subfolder}, err, opts); aErr != nil && err == nil {
+		return nil, fmt.Errorf("write audit log: %w", aErr)
+	}
+	return ret, err
+}
+
+func searchContent(ctx context.Context, re *regexp.Regexp, subfolder, gpgPassphrase string, opts *Options) ([]ContentMatch, error) {
+	names, err := List(ctx, subfolder, opts)
+	if err != nil {
+		return nil, fmt.Errorf("list: %w", err)
+	}
+	contents, err := showMany(ctx, names, gpgPassphrase, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []ContentMatch
+	for _, name := range names {
+		sc := bufio.NewScanner(bytes.NewReader(contents[name]))
+		for n := 1; sc.Scan(); n++ {
+			if re.Match(sc.Bytes()) {
+				ret = append(ret, ContentMatch{
+					Name: name,
+					Line: n,
+					Text: Secret(append([]byte(nil), sc.Bytes()...)),
+				})
+			}
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,39 @@
+package pass
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"regexp"
+	"testing"
+)
+
+func TestSearchContent(t *testing.T) {
+	storeDir, err := ioutil.TempDir("", tmpDirPrefix)
+	if err != nil {
+		log.Fatalf("create tmp dir: %s", err)
+	}
+
+	opts := &Options{
+		StoreDir: storeDir,
+	}
+	ctx := context.Background()
+	err = Init(ctx, testGpgID, "", opts)
+	Ok(t, err)
+
+	err = Insert(ctx, "google.com/bar", []byte("bar_password\nuser: alice\n"), false, opts)
+	Ok(t, err)
+	err = Insert(ctx, "google.com/baz", []byte("baz_password\nuser: bob\n"), false, opts)
+	Ok(t, err)
+
+	matches, err := SearchContent(ctx, regexp.MustCompile(`^user: b`), "", testGpgPassphrase, opts)
+	Ok(t, err)
+	if len(matches) != 1 {
+		t.Fatalf("expected 1 match, got: %d", len(matches))
+	}
+	Equal(t, "google.com/baz", matches[0].Name)
+	if matches[0].Line != 2 {
+		t.Errorf("expected: line 2, got: line %d", matches[0].Line)
+	}
+	Equal(t, "user: bob", string(matches[0].Text))
+}
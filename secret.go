@@ -0,0 +1,100 @@
+package pass
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// redacted is what Secret and Passphrase print as.
+const redacted = "[REDACTED]"
+
+// Secret is the content of a password file. It prints as "[REDACTED]"
+// with any fmt verb and encodes as "[REDACTED]" in JSON, so that it is not
+// leaked by accidentally logging it or a struct containing it. Convert it
+// to a string or []byte to use the content.
+type Secret []byte
+
+// String returns "[REDACTED]".
+func (Secret) String() string { return redacted }
+
+// GoString returns "[REDACTED]".
+func (Secret) GoString() string { return redacted }
+
+// Format implements fmt.Formatter, printing "[REDACTED]" for every verb.
+func (Secret) Format(f fmt.State, verb rune) { io.WriteString(f, redacted) }
+
+// MarshalJSON encodes the Secret as the JSON string "[REDACTED]".
+func (Secret) MarshalJSON() ([]byte, error) { return []byte(`"` + redacted + `"`), nil }
+
+// Passphrase is a gpg passphrase. Like Secret, it prints and encodes in
+// JSON as "[REDACTED]". Convert it to a string to use it.
+type Passphrase string
+
+// String returns "[REDACTED]".
+func (Passphrase) String() string { return redacted }
+
+// GoString returns "[REDACTED]".
+func (Passphrase) GoString() string { return redacted }
+
+// Format implements fmt.Formatter, printing "[REDACTED]" for every verb.
+func (Passphrase) Format(f fmt.State, verb rune) { io.WriteString(f, redacted) }
+
+// MarshalJSON encodes the Passphrase as the JSON string "[REDACTED]".
+func (Passphrase) MarshalJSON() ([]byte, error) { return []byte(`"` + redacted + `"`), nil }
+
+// Aliases of the keys of the fields read by Secret.Username, Secret.URL,
+// and Secret.Comment, in order of preference.
+var (
+	usernameKeys = []string{"username", "user", "login"}
+	urlKeys      = []string{"url", "uri", "website"}
+	commentKeys  = []string{"comment", "comments", "notes"}
+)
+
+// Password returns the first line of the content, which by convention is
+// the password.
+func (s Secret) Password() Secret {
+	if i := bytes.IndexByte(s, '\n'); i != -1 {
+		return bytes.TrimSuffix(s[:i], []byte("\r"))
+	}
+	return s
+}
+
+// Field returns the value of the first "key: value" line after the first
+// line of the content whose key matches key, ignoring case. The value has
+// surrounding whitespace removed.
+func (s Secret) Field(key string) (Secret, bool) {
+	lines := bytes.Split(s, []byte("\n"))
+	for _, line := range lines[1:] {
+		i := bytes.IndexByte(line, ':')
+		if i == -1 {
+			continue
+		}
+		if strings.EqualFold(string(bytes.TrimSpace(line[:i])), key) {
+			return bytes.TrimSpace(line[i+1:]), true
+		}
+	}
+	return nil, false
+}
+
+// Username returns the value of the "username", "user", or "login"
+// field, or "" if there is none.
+func (s Secret) Username() string { return s.firstField(usernameKeys) }
+
+// URL returns the value of the "url", "uri", or "website" field, or "" if
+// there is none.
+func (s Secret) URL() string { return s.firstField(urlKeys) }
+
+// Comment returns the value of the "comment", "comments", or "notes"
+// field, or "" if there is none.
+func (s Secret) Comment() string { return s.firstField(commentKeys) }
+
+func (s Secret) firstField(keys []string) string {
+	for _, k := range keys {
+		if v, ok := s.Field(k); ok {
+			return string(v)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,51 @@
+package pass
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func TestSecretRedacted(t *testing.T) {
+	s := Secret("hunter2")
+	p := Passphrase("hunter2")
+
+	for _, format := range []string{"%v", "%+v", "%#v", "%s", "%q", "%x", "%X", "%d"} {
+		Equal(t, redacted, fmt.Sprintf(format, s))
+		Equal(t, redacted, fmt.Sprintf(format, p))
+	}
+
+	v := struct {
+		Content    Secret
+		Passphrase Passphrase
+	}{s, p}
+	Equal(t, "{[REDACTED] [REDACTED]}", fmt.Sprintf("%v", v))
+
+	b, err := json.Marshal(v)
+	Ok(t, err)
+	Equal(t, `{"Content":"[REDACTED]","Passphrase":"[REDACTED]"}`, string(b))
+
+	Equal(t, "hunter2", string(s))
+	Equal(t, "hunter2", string(p))
+}
+
+func TestSecretFields(t *testing.T) {
+	s := Secret("hunter2\r\nLogin: alice\nURL : https://example.com/login\nnotes: first\ncomment: second\nPIN: 1234\n")
+
+	Equal(t, "hunter2", string(s.Password()))
+	Equal(t, "alice", s.Username())
+	Equal(t, "https://example.com/login", s.URL())
+	Equal(t, "second", s.Comment())
+
+	pin, ok := s.Field("pin")
+	if !ok {
+		t.Errorf("expected pin field")
+	}
+	Equal(t, "1234", string(pin))
+
+	if _, ok := s.Field("hunter2"); ok {
+		t.Errorf("expected the password line to not be a field")
+	}
+	Equal(t, "", Secret("only_password").Username())
+	Equal(t, "only_password", string(Secret("only_password").Password()))
+}
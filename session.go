@@ -0,0 +1,123 @@
+package pass
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrSessionExpired is returned by the methods of a Session after its TTL
+// lapsed or it was closed.
+var ErrSessionExpired = errors.New("session expired")
+
+// Session is a Store unlocked with a gpg passphrase for a limited time.
+// Like a Store, it is an Option, which sets the options of the Store and
+// Options.Passphrase while the session is unexpired, so it can be passed
+// to any function of the package:
+//
+//	sess, err := store.Unlock(passphrase, 5*time.Minute)
+//	...
+//	defer sess.Close()
+//	pass.Move(ctx, oldPath, newPath, false, sess)
+//
+// Once expired, it sets only the options of the Store. The passphrase is
+// overwritten in memory when the session expires or is closed, although
+// copies made while it was in use may remain until they are garbage
+// collected. A Session is safe for concurrent use.
+type Session struct {
+	store *Store
+
+	mu         sync.Mutex
+	passphrase []byte // nil once expired
+	expires    time.Time
+	timer      *time.Timer
+}
+
+// Unlock returns a Session that uses the passphrase for ttl.
+func (s *Store) Unlock(passphrase Passphrase, ttl time.Duration) (*Session, error) {
+	if passphrase == "" {
+		return nil, errors.New("empty passphrase")
+	}
+	if ttl <= 0 {
+		return nil, errors.New("ttl must be positive")
+	}
+	sess := &Session{
+		store:      s,
+		passphrase: []byte(passphrase),
+		expires:    time.Now().Add(ttl),
+	}
+	sess.mu.Lock()
+	sess.timer = time.AfterFunc(ttl, func() { sess.Close() })
+	sess.mu.Unlock()
+	s.addSession(sess)
+	s.touch()
+	return sess, nil
+}
+
+func (sess *Session) apply(o *Options) {
+	sess.store.apply(o)
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if sess.passphrase != nil {
+		o.Passphrase = Passphrase(sess.passphrase)
+	}
+}
+
+// Expires returns when the session expires.
+func (sess *Session) Expires() time.Time {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	return sess.expires
+}
+
+// Show is like the package-level Show with the session. It returns
+// ErrSessionExpired if the session expired.
+func (sess *Session) Show(ctx context.Context, name string, options ...Option) (Secret, error) {
+	if err := sess.check(); err != nil {
+		return nil, err
+	}
+	return Show(ctx, name, "", append([]Option{sess}, options...)...)
+}
+
+// ShowMany is like the package-level ShowMany with the session.
+func (sess *Session) ShowMany(ctx context.Context, names []string, options ...Option) (map[string]Secret, error) {
+	if err := sess.check(); err != nil {
+		return nil, err
+	}
+	return ShowMany(ctx, names, "", append([]Option{sess}, options...)...)
+}
+
+// Get is like the package-level Get with the session.
+func (sess *Session) Get(ctx context.Context, name string, v interface{}, options ...Option) error {
+	if err := sess.check(); err != nil {
+		return err
+	}
+	return Get(ctx, name, "", v, append([]Option{sess}, options...)...)
+}
+
+func (sess *Session) check() error {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if sess.passphrase == nil {
+		return ErrSessionExpired
+	}
+	return nil
+}
+
+// Close ends the session, overwriting the passphrase.
+func (sess *Session) Close() error {
+	sess.store.removeSession(sess)
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if sess.passphrase == nil {
+		return nil
+	}
+	sess.timer.Stop()
+	wipe(sess.passphrase)
+	sess.passphrase = nil
+	if now := time.Now(); now.Before(sess.expires) {
+		sess.expires = now
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package pass
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Arithmetic in GF(2^8) with the AES polynomial x^8 + x^4 + x^3 + x + 1,
+// using tables of powers of the generator 3.
+var gfExp, gfLog [256]byte
+
+func init() {
+	x := byte(1)
+	for i := 0; i < 255; i++ {
+		gfExp[i] = x
+		gfLog[x] = byte(i)
+		// Multiply x by 3: x*2 + x.
+		x2 := x << 1
+		if x&0x80 != 0 {
+			x2 ^= 0x1b
+		}
+		x ^= x2
+	}
+	gfExp[255] = gfExp[0]
+}
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[(int(gfLog[a])+int(gfLog[b]))%255]
+}
+
+func gfDiv(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return gfExp[(int(gfLog[a])-int(gfLog[b])+255)%255]
+}
+
+// shamirSplit splits secret into n shares, any k of which reconstruct it.
+// Share i, for i from 1 to n, is the evaluation at x = i of a random
+// polynomial of degree k-1 per byte, whose constant term is the byte.
+func shamirSplit(secret []byte, n, k int) ([][]byte, error) {
+	if k < 2 || n < k || n > 255 {
+		return nil, errors.New("need 2 <= k <= n <= 255")
+	}
+	coeffs := make([]byte, (k-1)*len(secret))
+	if _, err := rand.Read(coeffs); err != nil {
+		return nil, err
+	}
+	shares := make([][]byte, n)
+	for i := range shares {
+		x := byte(i + 1)
+		share := make([]byte, len(secret))
+		for j, s := range secret {
+			// Horner's method, from the highest degree coefficient.
+			var y byte
+			for d := k - 2; d >= 0; d-- {
+				y = gfMul(y, x) ^ coeffs[d*len(secret)+j]
+			}
+			share[j] = gfMul(y, x) ^ s
+		}
+		shares[i] = share
+	}
+	return shares, nil
+}
+
+// shamirCombine reconstructs the secret from shares keyed by their x
+// coordinate, using Lagrange interpolation at x = 0.
+func shamirCombine(shares map[byte][]byte) ([]byte, error) {
+	var size int
+	for _, s := range shares {
+		size = len(s)
+		break
+	}
+	secret := make([]byte, size)
+	for xi, si := range shares {
+		if len(si) != size {
+			return nil, errors.New("shares have different lengths")
+		}
+		// The Lagrange basis polynomial for xi, at 0.
+		l := byte(1)
+		for xj := range shares {
+			if xj != xi {
+				l = gfMul(l, gfDiv(xj, xj^xi))
+			}
+		}
+		for b := range secret {
+			secret[b] ^= gfMul(si[b], l)
+		}
+	}
+	return secret, nil
+}
+
+// shareName returns the name of the ith of n shares of the named entry.
+func shareName(name string, i, n int) string {
+	return fmt.Sprintf("%s.share-%d-of-%d", name, i, n)
+}
+
+// SplitSecret splits the content of the named entry into shares using
+// Shamir's secret sharing, so that any k of them reconstruct it but fewer
+// reveal nothing about it. A share is stored for each of the GPG IDs, as
+// an entry named "<name>.share-<i>-of-<n>" encrypted only to that GPG ID,
+// and the names of the shares are returned. The entry itself is left as
+// is; remove it to make the shares the only way to recover the secret, for
+// example for break-glass credentials. See CombineSecret.
+func SplitSecret(ctx context.Context, name, gpgPassphrase string, gpgIDs []string, k int, options ...Option) ([]string, error) {
+	opts := resolveOptions(options)
+	n := len(gpgIDs)
+	names := make([]string, n)
+	for i := range names {
+		names[i] = shareName(name, i+1, n)
+	}
+
+	err := mutate(ctx, "split-secret", append([]string{name}, names...), opts, func() error {
+		content, err := show(ctx, name, gpgPassphrase, opts)
+		if err != nil {
+			return fmt.Errorf("show: %w", err)
+		}
+		shares, err := shamirSplit(content, n, k)
+		if err != nil {
+			return err
+		}
+
+		storeDir := resolveStoreDir(opts)
+		var paths []string
+		for i, share := range shares {
+			text := fmt.Sprintf("%d-%s\nsecret: %s\nthreshold: %d\nrecipient: %s\n",
+				i+1, hex.EncodeToString(share), name, k, gpgIDs[i])
+			p := filepath.Join(storeDir, names[i]+".gpg")
+			if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+				return err
+			}
+			tmp, err := encryptTemp(ctx, filepath.Dir(p), []byte(text), []string{gpgIDs[i]}, opts)
+			if err != nil {
+				return fmt.Errorf("encrypt share %d: %w", i+1, err)
+			}
+			if err := os.Rename(tmp, p); err != nil {
+				os.Remove(tmp)
+				return err
+			}
+			paths = append(paths, names[i]+".gpg")
+		}
+
+		msg := fmt.Sprintf("Split %s into %d shares.", name, n)
+		if err := commitFiles(ctx, msg, paths, opts); err != nil {
+			return fmt.Errorf("commit: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// CombineSecret reconstructs a secret split using SplitSecret from the
+// decrypted contents of at least the threshold number of its shares, as
+// returned by Show for each share by the holder of its key.
+func CombineSecret(ctx context.Context, shares []Secret) (Secret, error) {
+	if len(shares) < 2 {
+		return nil, errors.New("need at least 2 shares")
+	}
+	points := make(map[byte][]byte, len(shares))
+	threshold := 0
+	for _, s := range shares {
+		line := strings.SplitN(string(s), "\n", 2)[0]
+		i := strings.IndexByte(line, '-')
+		if i == -1 {
+			return nil, errors.New("not a share")
+		}
+		x, err := strconv.ParseUint(line[:i], 10, 8)
+		if err != nil || x == 0 {
+			return nil, errors.New("not a share")
+		}
+		y, err := hex.DecodeString(strings.TrimSpace(line[i+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("not a share: %w", err)
+		}
+		points[byte(x)] = y
+		if v, ok := s.Field("threshold"); ok {
+			threshold, _ = strconv.Atoi(string(v))
+		}
+	}
+	if len(points) < threshold {
+		return nil, fmt.Errorf("need %d distinct shares, got %d", threshold, len(points))
+	}
+	secret, err := shamirCombine(points)
+	if err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
@@ -0,0 +1,68 @@
+package pass
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestShamir(t *testing.T) {
+	secret := []byte("correct horse battery staple")
+	shares, err := shamirSplit(secret, 5, 3)
+	Ok(t, err)
+
+	for _, xs := range [][]byte{{1, 2, 3}, {5, 3, 1}, {2, 4, 5, 1}} {
+		points := make(map[byte][]byte)
+		for _, x := range xs {
+			points[x] = shares[x-1]
+		}
+		got, err := shamirCombine(points)
+		Ok(t, err)
+		Equal(t, string(secret), string(got))
+	}
+
+	got, err := shamirCombine(map[byte][]byte{1: shares[0], 2: shares[1]})
+	Ok(t, err)
+	if string(got) == string(secret) {
+		t.Errorf("expected 2 of 3 shares not to reconstruct the secret")
+	}
+}
+
+func TestSplitSecret(t *testing.T) {
+	defer fakeCommand("pass", `for arg; do name="$arg"; done; cat "$PASSWORD_STORE_DIR/$name.gpg"`)()
+
+	dir := makeTestTree(nil)
+	defer os.RemoveAll(dir)
+	env, fprs := testKeyring(t, filepath.Join(dir, "gnupg"), "A <a@example.com>", "B <b@example.com>", "C <c@example.com>")
+	opts := &Options{StoreDir: filepath.Join(dir, "store"), Env: env}
+	writeTestFile(t, opts.StoreDir, "root/password.gpg", "s3cret\nuser: root\n")
+	ctx := context.Background()
+
+	names, err := SplitSecret(ctx, "root/password", "", fprs, 2, opts)
+	Ok(t, err)
+	Equal(t, fmt.Sprint([]string{
+		"root/password.share-1-of-3",
+		"root/password.share-2-of-3",
+		"root/password.share-3-of-3",
+	}), fmt.Sprint(names))
+
+	recipients, err := Recipients(ctx, names[1], opts)
+	Ok(t, err)
+	Equal(t, "1", fmt.Sprint(len(recipients)))
+
+	var shares []Secret
+	for _, name := range names[1:] {
+		s, err := decryptFile(ctx, filepath.Join(opts.StoreDir, name+".gpg"), "", opts)
+		Ok(t, err)
+		shares = append(shares, s)
+	}
+	got, err := CombineSecret(ctx, shares)
+	Ok(t, err)
+	Equal(t, "s3cret\nuser: root\n", string(got))
+
+	if _, err := CombineSecret(ctx, shares[:1]); err == nil {
+		t.Errorf("expected error for too few shares")
+	}
+}
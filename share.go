@@ -0,0 +1,127 @@
+package pass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ShareReport is the result of ShareEntry.
+type ShareReport struct {
+	Recipients []string `json:"recipients"` // The long key IDs the entry is now encrypted to.
+	Warnings   []string `json:"warnings,omitempty"`
+}
+
+// ShareEntry re-encrypts the named entry to the recipients of its folder
+// and the key keyID, to give the owner of the key access to this one entry
+// without changing the .gpg-id file of the folder. The entry is decrypted
+// using gpgPassphrase, as in Show.
+//
+// The entry then differs from its folder, so Reencrypt, Insert, and
+// "pass edit" remove the access again; the report warns about this.
+func ShareEntry(ctx context.Context, name, keyID, gpgPassphrase string, options ...Option) (*ShareReport, error) {
+	opts := resolveOptions(options)
+	name = resolveName(name, opts)
+	var report *ShareReport
+	err := mutate(ctx, "share", []string{name}, opts, func() error {
+		var err error
+		report, err = shareEntry(ctx, name, keyID, gpgPassphrase, opts)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func shareEntry(ctx context.Context, name, keyID, gpgPassphrase string, opts *Options) (*ShareReport, error) {
+	p := filepath.Join(resolveStoreDir(opts), name+".gpg")
+	if _, err := os.Stat(p); err != nil {
+		return nil, errors.New("entry does not exist")
+	}
+	gpgIDFile, err := findGPGIDFile(name, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyGPGIDFile(ctx, gpgIDFile, opts); err != nil {
+		return nil, err
+	}
+	gpgIDs, err := readGPGIDFile(gpgIDFile)
+	if err != nil {
+		return nil, err
+	}
+	eopts := entryOptions(name, opts)
+	current, err := fileRecipients(ctx, p, eopts)
+	if err != nil {
+		return nil, err
+	}
+	extra, err := recipientKeys(ctx, []string{keyID}, eopts)
+	if err != nil {
+		return nil, err
+	}
+	gpgIDs = append(gpgIDs, keyID)
+	expected, err := recipientKeys(ctx, gpgIDs, eopts)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ShareReport{Recipients: current}
+	if containsAll(current, extra) {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("%s is already encrypted to %s", name, keyID))
+		return report, nil
+	}
+
+	// Re-encrypting reads the content, so it needs the same permissions
+	// as Show.
+	if err := checkRead(ctx, name, opts); err != nil {
+		return nil, err
+	}
+	gpgPassphrase, err = passphrase(ctx, gpgPassphrase, opts)
+	if err != nil {
+		return nil, err
+	}
+	content, err := decryptFile(ctx, p, gpgPassphrase, eopts)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt: %w", err)
+	}
+	defer wipe(content)
+	tmp, err := encryptTemp(ctx, filepath.Dir(p), content, gpgIDs, eopts)
+	if err != nil {
+		return nil, fmt.Errorf("encrypt: %w", err)
+	}
+	defer os.Remove(tmp)
+	got, err := fileRecipients(ctx, tmp, eopts)
+	if err != nil {
+		return nil, fmt.Errorf("verify: %w", err)
+	}
+	if !equalStrings(got, expected) {
+		return nil, errors.New("verify: entry is not encrypted to the expected keys")
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		return nil, fmt.Errorf("rename: %w", err)
+	}
+
+	msg := fmt.Sprintf("Share %s with %s.", name, keyID)
+	if err := commitFiles(ctx, msg, []string{name + ".gpg"}, opts); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+	report.Recipients = got
+	report.Warnings = append(report.Warnings, fmt.Sprintf("%s is no longer encrypted to the same keys as its folder; Reencrypt, Insert, and pass edit remove the access of %s", name, keyID))
+	return report, nil
+}
+
+// containsAll reports whether a holds all the strings of b.
+func containsAll(a, b []string) bool {
+	set := make(map[string]bool, len(a))
+	for _, s := range a {
+		set[s] = true
+	}
+	for _, s := range b {
+		if !set[s] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,57 @@
+package pass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestShareEntry(t *testing.T) {
+	dir := makeTestTree(nil)
+	defer os.RemoveAll(dir)
+	env, fprs := testKeyring(t, filepath.Join(dir, "gnupg"), "A <a@example.com>", "B <b@example.com>")
+	storeDir := filepath.Join(dir, "store")
+	writeTestFile(t, storeDir, ".gpg-id", fprs[0]+"\n")
+	opts := &Options{StoreDir: storeDir, Env: env}
+	ctx := context.Background()
+	tmp, err := encryptTemp(ctx, storeDir, []byte("secret\n"), []string{fprs[0]}, opts)
+	Ok(t, err)
+	Ok(t, os.Rename(tmp, filepath.Join(storeDir, "a.gpg")))
+
+	report, err := ShareEntry(ctx, "a", fprs[1], "", opts)
+	Ok(t, err)
+	expected, err := encryptionKeyIDs(ctx, fprs, opts)
+	Ok(t, err)
+	Equal(t, fmt.Sprint(uniqueSorted(expected)), fmt.Sprint(report.Recipients))
+	Equal(t, "1", fmt.Sprint(len(report.Warnings)))
+	Equal(t, "true", fmt.Sprint(strings.Contains(report.Warnings[0], "no longer encrypted to the same keys as its folder")))
+
+	got, err := Recipients(ctx, "a", opts)
+	Ok(t, err)
+	Equal(t, fmt.Sprint(report.Recipients), fmt.Sprint(got))
+	content, err := decryptFile(ctx, filepath.Join(storeDir, "a.gpg"), "", opts)
+	Ok(t, err)
+	Equal(t, "secret\n", string(content))
+
+	report, err = ShareEntry(ctx, "a", fprs[1], "", opts)
+	Ok(t, err)
+	Equal(t, fmt.Sprintf("[a is already encrypted to %s]", fprs[1]), fmt.Sprint(report.Warnings))
+
+	_, err = ShareEntry(ctx, "missing", fprs[1], "", opts)
+	Equal(t, "entry does not exist", fmt.Sprint(err))
+
+	// Sharing reads the entry, which Write alone does not allow.
+	tmp, err = encryptTemp(ctx, storeDir, []byte("other\n"), []string{fprs[0]}, opts)
+	Ok(t, err)
+	Ok(t, os.Rename(tmp, filepath.Join(storeDir, "b.gpg")))
+	acl := NewACL()
+	Ok(t, acl.Allow("ci-bot", "**", Write))
+	_, err = ShareEntry(ctx, "b", fprs[1], "", &Options{StoreDir: storeDir, Env: env, ACL: acl, Principal: "ci-bot"})
+	if !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("expected: %s, got: %v", ErrPermissionDenied, err)
+	}
+}
@@ -0,0 +1,185 @@
+package pass
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInvalidShareLink is returned by (*ShareLinks).Redeem for a token
+// that was not created by the ShareLinks, was already used, or expired.
+var ErrInvalidShareLink = errors.New("invalid or expired share link")
+
+// ShareLinks creates and serves single-use links that grant read access
+// to one entry until they expire, so that a secret can be shared with a
+// teammate through a URL that stops working after it is opened once.
+//
+// The tokens are signed with a key that is generated by NewShareLinks,
+// and the tokens not used yet are only kept in memory, so links stop
+// working when the process exits. Serve it over HTTPS only.
+type ShareLinks struct {
+	// ErrorLog logs the errors of reading entries in ServeHTTP, which are
+	// not sent to the client. If nil, the log package's standard logger
+	// is used.
+	ErrorLog *log.Logger
+
+	key     []byte
+	options []Option
+
+	mu      sync.Mutex
+	pending map[string]time.Time // token ID -> expiry
+}
+
+// shareToken is the signed content of a share link token.
+type shareToken struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Expires int64  `json:"exp"` // Unix time
+}
+
+// NewShareLinks returns a ShareLinks for the store. The entries are
+// decrypted with the options, which must not need a passphrase prompt,
+// for example by using a Session or Options.Passphrase.
+func NewShareLinks(options ...Option) (*ShareLinks, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return &ShareLinks{
+		key:     key,
+		options: options,
+		pending: make(map[string]time.Time),
+	}, nil
+}
+
+// Create returns a token that grants reading the named entry once, within
+// ttl, which must be positive. Append it to the URL at which l is served,
+// as the query parameter "token". The options of l must allow reading the
+// entry, as in Show.
+func (l *ShareLinks) Create(name string, ttl time.Duration) (string, error) {
+	if ttl <= 0 {
+		return "", errors.New("ttl must be positive")
+	}
+	opts := resolveOptions(l.options)
+	name, err := lookupName(context.Background(), name, opts)
+	if err != nil {
+		return "", err
+	}
+	if !entryExists(name, opts) {
+		return "", errors.New("entry does not exist")
+	}
+	if err := checkRead(context.Background(), name, opts); err != nil {
+		return "", err
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	exp := time.Now().Add(ttl)
+	t := shareToken{
+		ID:      hex.EncodeToString(id),
+		Name:    name,
+		Expires: exp.Unix(),
+	}
+	payload, err := json.Marshal(t)
+	if err != nil {
+		return "", err
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	for id, exp := range l.pending {
+		if now.After(exp) {
+			delete(l.pending, id)
+		}
+	}
+	l.pending[t.ID] = exp
+	l.mu.Unlock()
+
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(payload) + "." + enc.EncodeToString(l.sign(payload)), nil
+}
+
+// Redeem checks the token and uses it up, returning the name of the entry
+// it grants reading.
+func (l *ShareLinks) Redeem(token string) (string, error) {
+	enc := base64.RawURLEncoding
+	i := strings.IndexByte(token, '.')
+	if i == -1 {
+		return "", ErrInvalidShareLink
+	}
+	payload, err := enc.DecodeString(token[:i])
+	if err != nil {
+		return "", ErrInvalidShareLink
+	}
+	sig, err := enc.DecodeString(token[i+1:])
+	if err != nil || !hmac.Equal(sig, l.sign(payload)) {
+		return "", ErrInvalidShareLink
+	}
+	var t shareToken
+	if err := json.Unmarshal(payload, &t); err != nil {
+		return "", ErrInvalidShareLink
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	exp, ok := l.pending[t.ID]
+	if !ok {
+		return "", ErrInvalidShareLink
+	}
+	delete(l.pending, t.ID)
+	if time.Now().After(exp) {
+		return "", ErrInvalidShareLink
+	}
+	return t.Name, nil
+}
+
+func (l *ShareLinks) logf(format string, args ...interface{}) {
+	if l.ErrorLog != nil {
+		l.ErrorLog.Printf(format, args...)
+	} else {
+		log.Printf(format, args...)
+	}
+}
+
+func (l *ShareLinks) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, l.key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// ServeHTTP serves the content of the entry of the token in the query
+// parameter "token", using up the token. It responds with the status 410
+// if the token is invalid, used, or expired, and with the status 500 if the
+// entry cannot be read; the error is logged to l.ErrorLog rather than sent.
+func (l *ShareLinks) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	name, err := l.Redeem(r.URL.Query().Get("token"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusGone)
+		return
+	}
+	content, err := Show(r.Context(), name, "", l.options...)
+	if err != nil {
+		l.logf("share link: show %s: %s", name, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	defer wipe(content)
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(content)
+}
@@ -0,0 +1,92 @@
+package pass
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShareLinks(t *testing.T) {
+	storeDir := makeTestTree([]string{"a.gpg"})
+	defer os.RemoveAll(storeDir)
+	runner := RunnerFunc(func(cmd *exec.Cmd) error {
+		io.WriteString(cmd.Stdout, "secret\n")
+		return nil
+	})
+	links, err := NewShareLinks(&Options{StoreDir: storeDir, Runner: runner})
+	Ok(t, err)
+
+	_, err = links.Create("missing", time.Hour)
+	Equal(t, "entry does not exist", fmt.Sprint(err))
+
+	token, err := links.Create("a", time.Hour)
+	Ok(t, err)
+	srv := httptest.NewServer(links)
+	defer srv.Close()
+	get := func(token string) (int, string) {
+		t.Helper()
+		resp, err := srv.Client().Get(srv.URL + "/?token=" + token)
+		Ok(t, err)
+		defer resp.Body.Close()
+		b, err := ioutil.ReadAll(resp.Body)
+		Ok(t, err)
+		return resp.StatusCode, string(b)
+	}
+	code, body := get(token)
+	Equal(t, "200 secret\n", fmt.Sprint(code, " ", body))
+	code, _ = get(token)
+	Equal(t, "410", fmt.Sprint(code))
+
+	// A token signed by other links, or tampered with, is rejected.
+	other, err := NewShareLinks(&Options{StoreDir: storeDir, Runner: runner})
+	Ok(t, err)
+	token, err = other.Create("a", time.Hour)
+	Ok(t, err)
+	_, err = links.Redeem(token)
+	Equal(t, "true", fmt.Sprint(errors.Is(err, ErrInvalidShareLink)))
+	_, err = other.Redeem("x" + token)
+	Equal(t, "true", fmt.Sprint(errors.Is(err, ErrInvalidShareLink)))
+
+	_, err = links.Create("a", 0)
+	Equal(t, "ttl must be positive", fmt.Sprint(err))
+	token, err = links.Create("a", time.Millisecond)
+	Ok(t, err)
+	time.Sleep(2 * time.Millisecond)
+	_, err = links.Redeem(token)
+	Equal(t, "true", fmt.Sprint(errors.Is(err, ErrInvalidShareLink)))
+
+	// Creating a link needs Read on the entry.
+	acl := NewACL()
+	Ok(t, acl.Allow("ci-bot", "**", Write))
+	writeOnly, err := NewShareLinks(&Options{StoreDir: storeDir, Runner: runner, ACL: acl, Principal: "ci-bot"})
+	Ok(t, err)
+	_, err = writeOnly.Create("a", time.Hour)
+	if err != ErrPermissionDenied {
+		t.Errorf("expected: %s, got: %v", ErrPermissionDenied, err)
+	}
+
+	// The errors of reading the entry are logged, not sent.
+	failing := RunnerFunc(func(cmd *exec.Cmd) error {
+		io.WriteString(cmd.Stderr, "gpg: decryption failed: No secret key\n")
+		return errors.New("exit status 2")
+	})
+	links, err = NewShareLinks(&Options{StoreDir: storeDir, Runner: failing})
+	Ok(t, err)
+	var logged bytes.Buffer
+	links.ErrorLog = log.New(&logged, "", 0)
+	srv.Config.Handler = links
+	token, err = links.Create("a", time.Hour)
+	Ok(t, err)
+	code, body = get(token)
+	Equal(t, "500 Internal Server Error\n", fmt.Sprint(code, " ", body))
+	Equal(t, "true", fmt.Sprint(strings.Contains(logged.String(), "share link: show a: ")))
+}
@@ -0,0 +1,42 @@
+package pass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ErrBadGPGIDSignature is returned when Options.StoreSigningKeys is set
+// and the .gpg-id file that applies to an entry is not signed by one of
+// the keys. pass refuses to encrypt to such a file too.
+var ErrBadGPGIDSignature = errors.New(".gpg-id is not signed by a store signing key")
+
+// verifyGPGIDFile checks, like pass, that the .gpg-id file at p has a
+// valid signature in p+".sig" by one of Options.StoreSigningKeys. Files
+// named in Options.Cryptos are not checked.
+func verifyGPGIDFile(ctx context.Context, p string, opts *Options) error {
+	if opts == nil || len(opts.StoreSigningKeys) == 0 || filepath.Base(p) != ".gpg-id" {
+		return nil
+	}
+	args := []string{"--batch", "--status-fd=1", "--verify", "--", p + ".sig", p}
+	stdout, _, err := execGPG(ctx, args, nil, opts)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrBadGPGIDSignature, p, err)
+	}
+	for _, s := range statusLines(stdout) {
+		// The last argument of VALIDSIG is the fingerprint of the
+		// primary key.
+		if s.keyword != "VALIDSIG" || len(s.args) == 0 {
+			continue
+		}
+		primary := s.args[len(s.args)-1]
+		for _, k := range opts.StoreSigningKeys {
+			if strings.EqualFold(k, primary) {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrBadGPGIDSignature, p)
+}
@@ -0,0 +1,42 @@
+package pass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVerifyGPGIDFile(t *testing.T) {
+	dir := makeTestTree(nil)
+	defer os.RemoveAll(dir)
+	env, fprs := testKeyring(t, filepath.Join(dir, "gnupg"), "A <a@example.com>", "B <b@example.com>")
+	storeDir := filepath.Join(dir, "store")
+	writeTestFile(t, storeDir, ".gpg-id", fprs[0]+"\n")
+	gpgIDFile := filepath.Join(storeDir, ".gpg-id")
+	ctx := context.Background()
+
+	opts := &Options{Env: env, StoreSigningKeys: []string{fprs[0]}}
+	err := verifyGPGIDFile(ctx, gpgIDFile, opts)
+	Equal(t, "true", fmt.Sprint(errors.Is(err, ErrBadGPGIDSignature)))
+
+	args := []string{"--batch", "--detach-sign", "--local-user", fprs[0], "--output", gpgIDFile + ".sig", gpgIDFile}
+	_, _, err = execGPG(ctx, args, nil, opts)
+	Ok(t, err)
+	Ok(t, verifyGPGIDFile(ctx, gpgIDFile, opts))
+	Ok(t, verifyGPGIDFile(ctx, gpgIDFile, &Options{Env: env, StoreSigningKeys: []string{strings.ToLower(fprs[0])}}))
+
+	err = verifyGPGIDFile(ctx, gpgIDFile, &Options{Env: env, StoreSigningKeys: []string{fprs[1]}})
+	Equal(t, "true", fmt.Sprint(errors.Is(err, ErrBadGPGIDSignature)))
+
+	writeTestFile(t, storeDir, ".gpg-id", fprs[1]+"\n")
+	err = verifyGPGIDFile(ctx, gpgIDFile, opts)
+	Equal(t, "true", fmt.Sprint(errors.Is(err, ErrBadGPGIDSignature)))
+
+	env2, err := commandEnv(nil, opts)
+	Ok(t, err)
+	Equal(t, "true", fmt.Sprint(hasEnv(env2, "PASSWORD_STORE_SIGNING_KEY")))
+}
@@ -0,0 +1,62 @@
+package pass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNoSmartcard is returned by RequireSmartcard when the entries of the
+// store can be decrypted without a smartcard.
+var ErrNoSmartcard = errors.New("decryption key is not on a smartcard")
+
+// RequireSmartcard checks that the entries of the store can only be
+// decrypted using a smartcard: that the keyring holds the secret key of
+// at least one of the encryption subkeys of the keys listed in the
+// .gpg-id files of the store on a smartcard, and none of them on disk. It
+// returns an error wrapping ErrNoSmartcard otherwise. Programs that must
+// only use hardware-backed keys can call it before other functions.
+//
+// It checks the gpg keyring; entries encrypted with Options.Crypto are not
+// considered.
+func RequireSmartcard(ctx context.Context, options ...Option) error {
+	opts := resolveOptions(options)
+	gpgIDs, err := allGPGIDs(opts)
+	if err != nil {
+		return err
+	}
+	gpgOpts := *opts
+	gpgOpts.Crypto = nil
+	// Older and expired subkeys still decrypt the entries encrypted to
+	// them, so all encryption subkeys count, not only those that gpg
+	// encrypts to now.
+	list, err := listPublicKeys(ctx, gpgIDs, &gpgOpts)
+	if err != nil {
+		return fmt.Errorf("list keys: %w", err)
+	}
+	var keys []string
+	for _, key := range list {
+		for _, sub := range key[1:] {
+			if !strings.ContainsAny(sub.validity, "idr") && strings.Contains(sub.capabilities, "e") {
+				keys = append(keys, sub.id)
+			}
+		}
+	}
+	card, disk, err := secretKeyLocations(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("list secret keys: %w", err)
+	}
+
+	onCard := false
+	for _, k := range keys {
+		if disk[k] {
+			return fmt.Errorf("%w: secret key %s is on disk", ErrNoSmartcard, k)
+		}
+		onCard = onCard || card[k]
+	}
+	if !onCard {
+		return fmt.Errorf("%w: no secret key on a smartcard", ErrNoSmartcard)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package pass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestRequireSmartcard(t *testing.T) {
+	storeDir := makeTestTree(nil)
+	defer os.RemoveAll(storeDir)
+	writeTestFile(t, storeDir, ".gpg-id", "alice@example.com\n")
+
+	const publicKeys = `pub:u:255:22:AAAA000000000001:1:::u:::scESC::::::23::0:
+sub:u:255:18:AAAA000000000002:1::::::e::::::23:
+sub:u:255:18:AAAA000000000003:1::::::e::::::23:
+`
+	var secretKeys string
+	runner := RunnerFunc(func(cmd *exec.Cmd) error {
+		args := strings.Join(cmd.Args[1:], " ")
+		switch {
+		case strings.Contains(args, "--list-secret-keys"):
+			fmt.Fprint(cmd.Stdout, secretKeys)
+		case strings.Contains(args, "--list-keys"):
+			fmt.Fprint(cmd.Stdout, publicKeys)
+		default:
+			return fmt.Errorf("unexpected args: %s", args)
+		}
+		return nil
+	})
+	opts := &Options{StoreDir: storeDir, Runner: runner}
+	ctx := context.Background()
+
+	// The primary key is on disk, but cannot decrypt.
+	secretKeys = `sec:u:255:22:AAAA000000000001:1:::u:::scESC:::+:::23::0:
+ssb:u:255:18:AAAA000000000002:1::::::e:::D2760001240100000006:::23:
+ssb:u:255:18:AAAA000000000003:1::::::e:::#:::23:
+`
+	Ok(t, RequireSmartcard(ctx, opts))
+
+	secretKeys = `ssb:u:255:18:AAAA000000000002:1::::::e:::D2760001240100000006:::23:
+ssb:u:255:18:AAAA000000000003:1::::::e:::+:::23:
+`
+	if err := RequireSmartcard(ctx, opts); !errors.Is(err, ErrNoSmartcard) {
+		t.Errorf("expected %v for key on disk, got: %v", ErrNoSmartcard, err)
+	}
+
+	secretKeys = ""
+	if err := RequireSmartcard(ctx, opts); !errors.Is(err, ErrNoSmartcard) {
+		t.Errorf("expected %v for missing key, got: %v", ErrNoSmartcard, err)
+	}
+}
@@ -0,0 +1,110 @@
+package pass
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SortMode is the order of the entries returned by List and ListFast.
+type SortMode int
+
+const (
+	// SortDefault is the order in which the store is walked: lexical
+	// within each folder, with the contents of a folder kept together.
+	SortDefault SortMode = iota
+
+	// SortLexical orders entries by the bytes of their names.
+	SortLexical
+
+	// SortNatural is like SortLexical, but compares runs of digits by
+	// their numeric value, so that "host2" comes before "host10".
+	SortNatural
+
+	// SortCaseInsensitive is like SortLexical, but ignores case.
+	SortCaseInsensitive
+
+	// SortModTime orders entries by modification time, most recently
+	// modified first.
+	SortModTime
+)
+
+// sortEntries sorts the entry names according to mode. Ties are broken
+// lexically, so that the order is deterministic.
+func sortEntries(names []string, mode SortMode, storeDir string) error {
+	switch mode {
+	case SortDefault:
+	case SortLexical:
+		sort.Strings(names)
+	case SortNatural:
+		sort.SliceStable(names, func(i, j int) bool {
+			return naturalLess(names[i], names[j])
+		})
+	case SortCaseInsensitive:
+		sort.Slice(names, func(i, j int) bool {
+			a, b := strings.ToLower(names[i]), strings.ToLower(names[j])
+			if a != b {
+				return a < b
+			}
+			return names[i] < names[j]
+		})
+	case SortModTime:
+		mtimes := make(map[string]time.Time, len(names))
+		for _, n := range names {
+			info, err := os.Lstat(filepath.Join(storeDir, n+".gpg"))
+			if err != nil {
+				return err
+			}
+			mtimes[n] = info.ModTime()
+		}
+		sort.Slice(names, func(i, j int) bool {
+			a, b := mtimes[names[i]], mtimes[names[j]]
+			if !a.Equal(b) {
+				return a.After(b)
+			}
+			return names[i] < names[j]
+		})
+	}
+	return nil
+}
+
+// naturalLess reports whether a sorts before b, comparing runs of ASCII
+// digits by numeric value and everything else bytewise.
+func naturalLess(a, b string) bool {
+	for a != "" && b != "" {
+		if isDigit(a[0]) && isDigit(b[0]) {
+			da, db := digitPrefix(a), digitPrefix(b)
+			na, nb := strings.TrimLeft(da, "0"), strings.TrimLeft(db, "0")
+			if len(na) != len(nb) {
+				return len(na) < len(nb)
+			}
+			if na != nb {
+				return na < nb
+			}
+			if len(da) != len(db) {
+				return len(da) < len(db) // fewer leading zeros first
+			}
+			a, b = a[len(da):], b[len(db):]
+			continue
+		}
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) < len(b)
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func digitPrefix(s string) string {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return s[:i]
+}
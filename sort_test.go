@@ -0,0 +1,71 @@
+package pass
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNaturalLess(t *testing.T) {
+	testcases := []struct {
+		a, b     string
+		expected bool
+	}{
+		{"host2", "host10", true},
+		{"host10", "host2", false},
+		{"host02", "host2", false},
+		{"host2", "host02", true},
+		{"a/b1", "a/b1x", true},
+		{"abc", "abd", true},
+		{"v1.10", "v1.9", false},
+		{"x", "x", false},
+	}
+	for _, tc := range testcases {
+		if got := naturalLess(tc.a, tc.b); got != tc.expected {
+			t.Errorf("naturalLess(%q, %q): expected: %t, got: %t", tc.a, tc.b, tc.expected, got)
+		}
+	}
+}
+
+func TestListSortMode(t *testing.T) {
+	storeDir := makeTestTree([]string{
+		"host10.gpg",
+		"Host3.gpg",
+		"host2.gpg",
+		"a/b.gpg",
+	})
+	now := time.Now()
+	for i, name := range []string{"a/b.gpg", "host10.gpg", "Host3.gpg", "host2.gpg"} {
+		mtime := now.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(filepath.Join(storeDir, name), mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testcases := []struct {
+		mode     SortMode
+		expected string
+	}{
+		{SortDefault, "[Host3 a/b host10 host2]"},
+		{SortLexical, "[Host3 a/b host10 host2]"},
+		{SortNatural, "[Host3 a/b host2 host10]"},
+		{SortCaseInsensitive, "[a/b host10 host2 Host3]"},
+		{SortModTime, "[host2 Host3 host10 a/b]"},
+	}
+
+	ctx := context.Background()
+	for _, tc := range testcases {
+		opts := &Options{StoreDir: storeDir, SortMode: tc.mode}
+
+		ls, err := List(ctx, "", opts)
+		Ok(t, err)
+		Equal(t, tc.expected, fmt.Sprint(ls))
+
+		ls, err = ListFast(ctx, "", opts)
+		Ok(t, err)
+		Equal(t, tc.expected, fmt.Sprint(ls))
+	}
+}
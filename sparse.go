@@ -0,0 +1,201 @@
+package pass
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// CloneSparse clones the git repository at gitURL into the store, checking
+// out only the files in the root of the store and in the given folders.
+// The contents of other entries are not downloaded until their folders
+// are checked out using CheckoutFolders, which keeps the clone small for
+// large team stores. ListRemote lists all entries, including those that
+// are not checked out.
+func CloneSparse(ctx context.Context, gitURL string, folders []string, options ...Option) error {
+	opts := resolveOptions(options)
+	defer lockNames(nil, true, opts)()
+	err := checkACL([]string{""}, Write, opts)
+	if err == nil {
+		err = cloneSparse(ctx, gitURL, folders, opts)
+	}
+	if aErr := audit(ctx, "clone-sparse", folders, err, opts); aErr != nil && err == nil {
+		return fmt.Errorf("write audit log: %w", aErr)
+	}
+	return err
+}
+
+func cloneSparse(ctx context.Context, gitURL string, folders []string, opts *Options) error {
+	storeDir := resolveStoreDir(opts)
+	args := []string{"clone", "--filter=blob:none", "--no-checkout", "--", gitURL, storeDir}
+	if _, _, err := runCommand(ctx, "git", "git", args, baseEnv(opts), nil, nil, opts); err != nil {
+		return fmt.Errorf("exec git: %w", err)
+	}
+	if err := runGit(ctx, []string{"sparse-checkout", "init", "--cone"}, opts); err != nil {
+		return err
+	}
+	if err := setSparseFolders(ctx, "set", folders, opts); err != nil {
+		return err
+	}
+	return runGit(ctx, []string{"checkout"}, opts)
+}
+
+// CheckoutFolders adds folders to those checked out in a store cloned
+// using CloneSparse.
+func CheckoutFolders(ctx context.Context, folders []string, options ...Option) error {
+	opts := resolveOptions(options)
+	defer lockNames(nil, true, opts)()
+	err := checkACL([]string{""}, Write, opts)
+	if err == nil {
+		err = setSparseFolders(ctx, "add", folders, opts)
+	}
+	if aErr := audit(ctx, "checkout-folders", folders, err, opts); aErr != nil && err == nil {
+		return fmt.Errorf("write audit log: %w", aErr)
+	}
+	return err
+}
+
+func setSparseFolders(ctx context.Context, subcommand string, folders []string, opts *Options) error {
+	args := []string{"sparse-checkout", subcommand, "--"}
+	for _, f := range folders {
+		f = path.Clean(filepath.ToSlash(f))
+		if f == "." || path.IsAbs(f) || strings.HasPrefix(f, "../") {
+			return fmt.Errorf("invalid folder: %s", f)
+		}
+		args = append(args, f)
+	}
+	return runGit(ctx, args, opts)
+}
+
+// ListRemote is like List, but lists the entries in the git tree of HEAD
+// instead of the files on disk, so that it includes the entries that are
+// not checked out in a store cloned using CloneSparse. Options.SortMode
+// SortModTime is not supported.
+func ListRemote(ctx context.Context, subfolder string, options ...Option) ([]string, error) {
+	opts := resolveOptions(options)
+	if opts.SortMode == SortModTime {
+		return nil, errors.New("sort by modification time is not supported")
+	}
+
+	args := []string{"ls-tree", "-r", "-z", "--name-only", "--full-tree", "HEAD"}
+	if subfolder != "" {
+		args = append(args, "--", filepath.ToSlash(filepath.Clean(subfolder)))
+	}
+	out, err := gitOutput(ctx, args, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	ignore, err := loadIgnoreFile(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string // slash separated, relative to the store, with .gpg suffix
+	for _, b := range bytes.Split(out, []byte{0}) {
+		f := string(b)
+		if !strings.HasSuffix(f, ".gpg") || strings.HasPrefix(f, trashDir+"/") || ignoredPath(ignore, f, subfolder) {
+			continue
+		}
+		files = append(files, f)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return walkOrderLess(files[i], files[j])
+	})
+
+	ret := make([]string, len(files))
+	for i, f := range files {
+		ret[i] = filepath.FromSlash(strings.TrimSuffix(f, ".gpg"))
+	}
+	if err := sortEntries(ret, opts.SortMode, resolveStoreDir(opts)); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// ignoredPath reports whether the slash separated file path rel, or one of
+// the folders it is in below subfolder, is ignored.
+func ignoredPath(ignore *ignoreMatcher, rel, subfolder string) bool {
+	if ignore.ignored(rel, false) {
+		return true
+	}
+	target := filepath.ToSlash(filepath.Clean(subfolder))
+	for dir := path.Dir(rel); dir != "." && dir != target; dir = path.Dir(dir) {
+		if ignore.ignored(dir, true) {
+			return true
+		}
+	}
+	return false
+}
+
+// ShowRemote returns the content of the named password file in the git
+// repository at gitURL without cloning the store. Only the commit of the
+// remote HEAD, its trees, and the password file itself are fetched, if the
+// server supports partial clones; otherwise the files of that commit are.
+// The password file is decrypted using gpg directly, like ShowMany.
+func ShowRemote(ctx context.Context, gitURL, name, gpgPassphrase string, options ...Option) (Secret, error) {
+	opts := resolveOptions(options)
+	content, err := showRemote(ctx, gitURL, name, gpgPassphrase, opts)
+	if aErr := audit(ctx, "show-remote", []string{name}, err, opts); aErr != nil && err == nil {
+		return nil, fmt.Errorf("write audit log: %w", aErr)
+	}
+	return content, err
+}
+
+func showRemote(ctx context.Context, gitURL, name, gpgPassphrase string, opts *Options) (Secret, error) {
+	gpgPassphrase, err := passphrase(ctx, gpgPassphrase, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkACL([]string{name}, Read, opts); err != nil {
+		return nil, err
+	}
+	if opts.RateLimiter != nil && !opts.RateLimiter.allow(name) {
+		return nil, ErrRateLimited
+	}
+
+	dir, err := ioutil.TempDir("", "go-pass-remote-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	git := func(args ...string) ([]byte, error) {
+		args = append([]string{"-C", dir}, args...)
+		stdout, _, err := runCommand(ctx, "git", "git", args, baseEnv(opts), nil, nil, opts)
+		if err != nil {
+			return nil, fmt.Errorf("exec git: %w", err)
+		}
+		return stdout, nil
+	}
+	if _, err := git("init", "--quiet"); err != nil {
+		return nil, err
+	}
+	if _, err := git("remote", "add", "origin", gitURL); err != nil {
+		return nil, err
+	}
+	if _, err := git("fetch", "--quiet", "--depth=1", "--filter=blob:none", "origin", "HEAD"); err != nil {
+		return nil, err
+	}
+	blob := "FETCH_HEAD:" + path.Clean(filepath.ToSlash(name)) + ".gpg"
+	if _, err := git("cat-file", "-e", blob); err != nil {
+		return nil, errors.New("name does not exist")
+	}
+	b, err := git("cat-file", "blob", blob)
+	if err != nil {
+		return nil, err
+	}
+
+	p := filepath.Join(dir, "entry.gpg")
+	if err := ioutil.WriteFile(p, b, 0600); err != nil {
+		return nil, err
+	}
+	return decryptFile(ctx, p, gpgPassphrase, opts)
+}
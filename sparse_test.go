@@ -0,0 +1,92 @@
+package pass
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestCloneSparse(t *testing.T) {
+	defer fakeCommand("pass", `shift; exec git -C "$PASSWORD_STORE_DIR" "$@"`)()
+
+	src := makeTestTree([]string{".gpg-id", "top.gpg", "team/a/x.gpg", "team/b/y.gpg", "other/z.gpg"})
+	defer os.RemoveAll(src)
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"add", "--all"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "init"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = src
+		if out, err := cmd.CombinedOutput(); err != nil {
+			log.Fatalf("git %s: %s: %s", args[0], err, out)
+		}
+	}
+
+	dir, err := ioutil.TempDir("", tmpDirPrefix)
+	if err != nil {
+		log.Fatalf("create tmp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	opts := &Options{StoreDir: filepath.Join(dir, "store")}
+	ctx := context.Background()
+
+	Ok(t, CloneSparse(ctx, "file://"+src, []string{"team/a"}, opts))
+	local, err := List(ctx, "", opts)
+	Ok(t, err)
+	Equal(t, fmt.Sprint([]string{filepath.Join("team", "a", "x"), "top"}), fmt.Sprint(local))
+
+	remote, err := ListRemote(ctx, "", opts)
+	Ok(t, err)
+	Equal(t, fmt.Sprint([]string{
+		filepath.Join("other", "z"),
+		filepath.Join("team", "a", "x"),
+		filepath.Join("team", "b", "y"),
+		"top",
+	}), fmt.Sprint(remote))
+
+	remote, err = ListRemote(ctx, "team", opts)
+	Ok(t, err)
+	Equal(t, fmt.Sprint([]string{filepath.Join("team", "a", "x"), filepath.Join("team", "b", "y")}), fmt.Sprint(remote))
+
+	Ok(t, CheckoutFolders(ctx, []string{"team/b"}, opts))
+	local, err = List(ctx, "", opts)
+	Ok(t, err)
+	Equal(t, fmt.Sprint([]string{filepath.Join("team", "a", "x"), filepath.Join("team", "b", "y"), "top"}), fmt.Sprint(local))
+}
+
+func TestShowRemote(t *testing.T) {
+	// Prints the "decrypted" file as is.
+	defer fakeCommand("gpg", `for arg; do f="$arg"; done; cat "$f"`)()
+
+	src := makeTestTree([]string{"other.gpg"})
+	defer os.RemoveAll(src)
+	Ok(t, ioutil.WriteFile(filepath.Join(src, "a.gpg"), []byte("a_password"), 0600))
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"config", "uploadpack.allowFilter", "true"},
+		{"add", "--all"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "init"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = src
+		if out, err := cmd.CombinedOutput(); err != nil {
+			log.Fatalf("git %s: %s: %s", args[0], err, out)
+		}
+	}
+
+	ctx := context.Background()
+	got, err := ShowRemote(ctx, "file://"+src, "a", "")
+	Ok(t, err)
+	Equal(t, "a_password", string(got))
+
+	_, err = ShowRemote(ctx, "file://"+src, "missing", "")
+	if err == nil {
+		t.Errorf("expected error for missing entry")
+	}
+}
@@ -0,0 +1,104 @@
+package pass
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// statsLargest is the number of entries in StoreStats.Largest.
+const statsLargest = 10
+
+// EntrySize is the size of the password file of an entry.
+type EntrySize struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+// StoreStats describes the contents of a store. See Stats.
+type StoreStats struct {
+	Entries   int   `json:"entries"`
+	Folders   int   `json:"folders"`    // Folders containing entries, at any depth.
+	TotalSize int64 `json:"total_size"` // Of the password files, in bytes.
+
+	// The largest entries, largest first.
+	Largest []EntrySize `json:"largest"`
+
+	// The number of entries directly in each folder, keyed by the name
+	// of the folder. The root of the store is "".
+	PerFolder map[string]int `json:"per_folder"`
+
+	// The time of the last commit, or the zero time if the store is not
+	// a git repository or has no commits.
+	LastCommit time.Time `json:"last_commit"`
+}
+
+// Stats returns statistics about the entries in the store, as listed by
+// List.
+func Stats(ctx context.Context, options ...Option) (StoreStats, error) {
+	opts := resolveOptions(options)
+	storeDir := resolveStoreDir(opts)
+
+	names, err := List(ctx, "", opts)
+	if err != nil {
+		return StoreStats{}, err
+	}
+
+	s := StoreStats{
+		Entries:   len(names),
+		PerFolder: make(map[string]int),
+	}
+	sizes := make([]EntrySize, 0, len(names))
+	folders := make(map[string]bool)
+	for _, name := range names {
+		info, err := os.Lstat(filepath.Join(storeDir, name+".gpg"))
+		if err != nil {
+			return StoreStats{}, err
+		}
+		s.TotalSize += info.Size()
+		sizes = append(sizes, EntrySize{Name: name, Size: info.Size()})
+
+		dir := filepath.Dir(name)
+		if dir == "." {
+			dir = ""
+		}
+		s.PerFolder[dir]++
+		for ; dir != ""; dir = parentFolder(dir) {
+			folders[dir] = true
+		}
+	}
+	s.Folders = len(folders)
+
+	sort.SliceStable(sizes, func(i, j int) bool { return sizes[i].Size > sizes[j].Size })
+	if len(sizes) > statsLargest {
+		sizes = sizes[:statsLargest]
+	}
+	s.Largest = sizes
+
+	if isGitRepo(opts) {
+		out, err := gitOutput(ctx, []string{"log", "-1", "--format=%ct"}, opts)
+		// An error means there are no commits yet.
+		if err == nil {
+			sec, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+			if err != nil {
+				return StoreStats{}, fmt.Errorf("parse commit time: %w", err)
+			}
+			s.LastCommit = time.Unix(sec, 0)
+		}
+	}
+	return s, nil
+}
+
+// parentFolder returns the folder containing the folder dir, or "" for the
+// root of the store.
+func parentFolder(dir string) string {
+	if p := filepath.Dir(dir); p != "." {
+		return p
+	}
+	return ""
+}
@@ -0,0 +1,27 @@
+package pass
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	storeDir := makeTestTree([]string{".gpg-id", "a.gpg", "x/b.gpg", "x/y/c.gpg", "x/y/d.gpg"})
+	defer os.RemoveAll(storeDir)
+	writeTestFile(t, storeDir, "x/b.gpg", "12345")
+	writeTestFile(t, storeDir, "x/y/c.gpg", "123")
+
+	s, err := Stats(context.Background(), WithStoreDir(storeDir))
+	Ok(t, err)
+	Equal(t, "4", fmt.Sprint(s.Entries))
+	Equal(t, "2", fmt.Sprint(s.Folders))
+	Equal(t, "8", fmt.Sprint(s.TotalSize))
+	Equal(t, filepath.Join("x", "b"), s.Largest[0].Name)
+	Equal(t, filepath.Join("x", "y", "c"), s.Largest[1].Name)
+	Equal(t, "4", fmt.Sprint(len(s.Largest)))
+	Equal(t, "1 1 2", fmt.Sprint(s.PerFolder[""], s.PerFolder["x"], s.PerFolder[filepath.Join("x", "y")]))
+	Equal(t, "true", fmt.Sprint(s.LastCommit.IsZero()))
+}
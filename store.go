@@ -0,0 +1,86 @@
+package pass
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// Store is a password store and the options for using it. A Store is an
+// Option that sets all of its options, so it can be passed to the
+// functions of the package:
+//
+//	pass.Show(ctx, name, passphrase, store)
+//
+// A Store is safe for concurrent use. The functions called with it
+// serialize changes to each entry, and reads of an entry with changes to
+// it, while operations on different entries run in parallel; changes to a
+// store that is a git repository are serialized, since each of them
+// commits. Functions that act on the whole store, such as Batch, Git and
+// Destroy, run alone. Only calls made with the same Store are coordinated.
+type Store struct {
+	opts Options
+
+	ephemeral bool
+	closeOnce sync.Once
+	closeErr  error
+
+	mu       sync.Mutex
+	idle     *time.Timer           // nil unless Options.AutoLock is set
+	sessions map[*Session]struct{} // open sessions, closed by Lock
+}
+
+// NewStore returns a Store with the options.
+func NewStore(options ...Option) *Store {
+	s := &Store{opts: *resolveOptions(options)}
+	s.opts.locks = newStoreLocks()
+	n := s.opts.MaxGPGProcesses
+	if n <= 0 {
+		n = DefaultMaxGPGProcesses
+	}
+	s.opts.gpgLimiter = newGPGLimiter(n)
+	return s
+}
+
+func (s *Store) apply(o *Options) {
+	*o = s.opts
+	s.touch()
+}
+
+// Dir returns the directory of the store.
+func (s *Store) Dir() string {
+	return resolveStoreDir(&s.opts)
+}
+
+// NewEphemeralStore creates a store in a new temporary directory and
+// initializes it for the GPG ID. Options.StoreDir is ignored. Close the
+// store to destroy it, as with Destroy, when it is no longer needed.
+func NewEphemeralStore(ctx context.Context, gpgID string, options ...Option) (*Store, error) {
+	dir, err := ioutil.TempDir("", "go-pass-")
+	if err != nil {
+		return nil, fmt.Errorf("create tmp dir: %w", err)
+	}
+
+	s := NewStore(append(options[:len(options):len(options)], WithStoreDir(dir))...)
+	s.ephemeral = true
+	if err := Init(ctx, gpgID, "", s); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close destroys the store if it was created by NewEphemeralStore. It
+// does nothing for other stores.
+func (s *Store) Close() error {
+	if !s.ephemeral {
+		return nil
+	}
+	s.closeOnce.Do(func() {
+		s.closeErr = Destroy(context.Background(), func(string) bool { return true }, s)
+	})
+	return s.closeErr
+}
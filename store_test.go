@@ -0,0 +1,234 @@
+package pass
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEphemeralStore(t *testing.T) {
+	defer fakeCommand("pass", `shift; printf '%s\n' "$@" > "$PASSWORD_STORE_DIR/.gpg-id"`)()
+
+	ctx := context.Background()
+	s, err := NewEphemeralStore(ctx, testGpgID, WithStoreDir("/ignored"))
+	Ok(t, err)
+	if s.Dir() == "/ignored" {
+		t.Fatalf("expected a temporary store dir")
+	}
+
+	got, err := ioutil.ReadFile(filepath.Join(s.Dir(), ".gpg-id"))
+	Ok(t, err)
+	Equal(t, testGpgID+"\n", string(got))
+
+	// The store is an Option.
+	ok, err := IsInitialized(ctx, s)
+	Ok(t, err)
+	if !ok {
+		t.Errorf("expected store to be initialized")
+	}
+
+	Ok(t, s.Close())
+	Ok(t, s.Close())
+	if _, err := os.Stat(s.Dir()); !os.IsNotExist(err) {
+		t.Errorf("expected store to be removed, got: %v", err)
+	}
+}
+
+func TestStoreConcurrent(t *testing.T) {
+	storeDir := makeTestTree([]string{"a.gpg", "b.gpg"})
+	defer os.RemoveAll(storeDir)
+
+	// The runner checks that no command on an entry overlaps with a
+	// change to it.
+	var (
+		mu      sync.Mutex
+		readers = make(map[string]int)
+		writers = make(map[string]int)
+		bad     int
+	)
+	runner := RunnerFunc(func(cmd *exec.Cmd) error {
+		name := cmd.Args[len(cmd.Args)-1]
+		write := cmd.Args[1] == "insert"
+		mu.Lock()
+		if writers[name] > 0 || (write && readers[name] > 0) {
+			bad++
+		}
+		if write {
+			writers[name]++
+		} else {
+			readers[name]++
+		}
+		mu.Unlock()
+
+		time.Sleep(time.Millisecond)
+
+		mu.Lock()
+		if write {
+			writers[name]--
+		} else {
+			readers[name]--
+		}
+		mu.Unlock()
+		return nil
+	})
+
+	s := NewStore(WithStoreDir(storeDir), WithRunner(runner))
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		name := []string{"a", "b"}[i%2]
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if err := Insert(ctx, name, []byte("x"), true, s); err != nil {
+				t.Error(err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := Show(ctx, name, "", s); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	Equal(t, "0", fmt.Sprint(bad))
+}
+
+func TestStoreMaxGPGProcesses(t *testing.T) {
+	storeDir := makeTestTree([]string{"a.gpg", "b.gpg", "c.gpg"})
+	defer os.RemoveAll(storeDir)
+
+	var mu sync.Mutex
+	running, max := 0, 0
+	runner := RunnerFunc(func(cmd *exec.Cmd) error {
+		mu.Lock()
+		running++
+		if running > max {
+			max = running
+		}
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return nil
+	})
+
+	s := NewStore(&Options{StoreDir: storeDir, Runner: runner, MaxGPGProcesses: 2})
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for i := 0; i < 12; i++ {
+		name := []string{"a", "b", "c"}[i%3]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := Show(ctx, name, "", s); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if max > 2 {
+		t.Errorf("expected at most 2 gpg processes at once, got %d", max)
+	}
+}
+
+func TestSession(t *testing.T) {
+	storeDir := makeTestTree([]string{"a.gpg"})
+	defer os.RemoveAll(storeDir)
+
+	var mu sync.Mutex
+	var stdins []string
+	runner := RunnerFunc(func(cmd *exec.Cmd) error {
+		b, err := ioutil.ReadAll(cmd.Stdin)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		stdins = append(stdins, string(b))
+		mu.Unlock()
+		return nil
+	})
+	s := NewStore(&Options{StoreDir: storeDir, Runner: runner, PinentryMode: "loopback"})
+	ctx := context.Background()
+
+	sess, err := s.Unlock("hunter2", time.Hour)
+	Ok(t, err)
+	_, err = sess.Show(ctx, "a")
+	Ok(t, err)
+	_, err = Show(ctx, "a", "", sess)
+	Ok(t, err)
+	Equal(t, "hunter2 hunter2", strings.Join(stdins, " "))
+
+	Ok(t, sess.Close())
+	if _, err := sess.Show(ctx, "a"); err != ErrSessionExpired {
+		t.Errorf("expected: %s, got: %v", ErrSessionExpired, err)
+	}
+
+	sess, err = s.Unlock("hunter2", time.Millisecond)
+	Ok(t, err)
+	time.Sleep(10 * time.Millisecond)
+	if _, err := sess.Show(ctx, "a"); err != ErrSessionExpired {
+		t.Errorf("expected: %s, got: %v", ErrSessionExpired, err)
+	}
+}
+
+func TestStoreAutoLock(t *testing.T) {
+	storeDir := makeTestTree([]string{"a.gpg"})
+	defer os.RemoveAll(storeDir)
+
+	runner := RunnerFunc(func(cmd *exec.Cmd) error {
+		if strings.Contains(strings.Join(cmd.Args, " "), "show") {
+			fmt.Fprint(cmd.Stdout, "secret\n")
+		}
+		return nil
+	})
+	locked := make(chan struct{}, 1)
+	cache := NewCache(time.Hour)
+	s := NewStore(&Options{
+		StoreDir:     storeDir,
+		Runner:       runner,
+		PinentryMode: "loopback",
+		Cache:        cache,
+		AutoLock:     500 * time.Millisecond,
+		OnLock:       func() { locked <- struct{}{} },
+	})
+	ctx := context.Background()
+
+	sess, err := s.Unlock("hunter2", time.Hour)
+	Ok(t, err)
+	_, err = sess.Show(ctx, "a")
+	Ok(t, err)
+	cache.mu.Lock()
+	Equal(t, "1", fmt.Sprint(len(cache.entries)))
+	cache.mu.Unlock()
+
+	select {
+	case <-locked:
+	case <-time.After(5 * time.Second):
+		t.Fatal("store not locked")
+	}
+	if _, err := sess.Show(ctx, "a"); err != ErrSessionExpired {
+		t.Errorf("expected: %s, got: %v", ErrSessionExpired, err)
+	}
+	cache.mu.Lock()
+	Equal(t, "0", fmt.Sprint(len(cache.entries)))
+	cache.mu.Unlock()
+
+	// Using the store restarts the timer.
+	_, err = Show(ctx, "a", "hunter2", s)
+	Ok(t, err)
+	select {
+	case <-locked:
+	case <-time.After(5 * time.Second):
+		t.Fatal("store not locked again")
+	}
+}
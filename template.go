@@ -0,0 +1,113 @@
+package pass
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"text/template"
+)
+
+// templatesDir is the folder, relative to the root of the store, that
+// holds the entry templates shared through the store.
+const templatesDir = ".templates"
+
+// passwordChars are the characters of the passwords generated by the
+// password template function.
+const passwordChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789!#$%&*+-=?@^_"
+
+// ErrTemplateNotFound is returned by NewFromTemplate when there is no
+// template with the name.
+var ErrTemplateNotFound = errors.New("template not found")
+
+// NewFromTemplate inserts a new entry with the content produced by the
+// named template. The template is looked up in Options.Templates, and then
+// in the file .templates/<template>.tmpl in the store, which is not
+// encrypted and so must not contain secrets.
+//
+// Templates are text/template templates executed with vars as data, so
+// {{.username}} is the value of vars["username"]; a missing var is an
+// error. Two functions are available: {{password n}} is a random password
+// of n characters, and {{otpSecret}} is a random base32 TOTP secret, for
+// use in an otpauth:// URI. For example:
+//
+//	{{password 24}}
+//	username: {{.username}}
+//	url: {{.url}}
+//
+// It is an error if the entry exists.
+func NewFromTemplate(ctx context.Context, name, tmpl string, vars map[string]string, options ...Option) error {
+	opts := resolveOptions(options)
+	text, err := loadTemplate(tmpl, opts)
+	if err != nil {
+		return err
+	}
+	content, err := executeTemplate(tmpl, text, vars)
+	if err != nil {
+		return err
+	}
+	if entryExists(resolveName(name, opts), opts) {
+		return errors.New("name already exists")
+	}
+	return Insert(ctx, name, content, false, opts)
+}
+
+func loadTemplate(tmpl string, opts *Options) (string, error) {
+	if text, ok := opts.Templates[tmpl]; ok {
+		return text, nil
+	}
+	p := filepath.Join(resolveStoreDir(opts), templatesDir, filepath.Clean("/"+tmpl)+".tmpl")
+	b, err := ioutil.ReadFile(p)
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("%s: %w", tmpl, ErrTemplateNotFound)
+	}
+	if err != nil {
+		return "", fmt.Errorf("read template: %w", err)
+	}
+	return string(b), nil
+}
+
+func executeTemplate(name, text string, vars map[string]string) ([]byte, error) {
+	t, err := template.New(name).Option("missingkey=error").Funcs(template.FuncMap{
+		"password":  randomPassword,
+		"otpSecret": randomOTPSecret,
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parse template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, vars); err != nil {
+		return nil, fmt.Errorf("execute template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func randomPassword(n int) (string, error) {
+	if n <= 0 {
+		return "", errors.New("password length must be positive")
+	}
+	b := make([]byte, n)
+	max := big.NewInt(int64(len(passwordChars)))
+	for i := range b {
+		j, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = passwordChars[j.Int64()]
+	}
+	return string(b), nil
+}
+
+func randomOTPSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base32.StdEncoding.EncodeToString(b), nil
+}
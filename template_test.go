@@ -0,0 +1,60 @@
+package pass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewFromTemplate(t *testing.T) {
+	storeDir := makeTestTree([]string{"exists.gpg"})
+	defer os.RemoveAll(storeDir)
+	Ok(t, os.MkdirAll(filepath.Join(storeDir, templatesDir), 0700))
+	Ok(t, ioutil.WriteFile(filepath.Join(storeDir, templatesDir, "web-login.tmpl"),
+		[]byte("{{password 20}}\nusername: {{.username}}\nurl: {{.url}}\notpauth://totp/{{.username}}?secret={{otpSecret}}\n"), 0600))
+
+	var inserted []string
+	runner := RunnerFunc(func(cmd *exec.Cmd) error {
+		b, err := ioutil.ReadAll(cmd.Stdin)
+		if err != nil {
+			return err
+		}
+		inserted = append(inserted, cmd.Args[len(cmd.Args)-1], string(b))
+		return nil
+	})
+	opts := &Options{
+		StoreDir:  storeDir,
+		Runner:    runner,
+		Templates: map[string]string{"note": "{{.text}}\n"},
+	}
+	ctx := context.Background()
+
+	Ok(t, NewFromTemplate(ctx, "web/example", "web-login", map[string]string{"username": "alice", "url": "https://example.com"}, opts))
+	Equal(t, "web/example", inserted[0])
+	content := Secret(inserted[1])
+	Equal(t, "20", fmt.Sprint(len(content.Password())))
+	Equal(t, "alice", content.Username())
+	Equal(t, "https://example.com", content.URL())
+	_, err := parseOTP(content)
+	Ok(t, err)
+
+	Ok(t, NewFromTemplate(ctx, "note", "note", map[string]string{"text": "hello"}, opts))
+	Equal(t, "hello\n", inserted[3])
+
+	if err := NewFromTemplate(ctx, "x", "web-login", map[string]string{"username": "alice"}, opts); err == nil || !strings.Contains(err.Error(), "url") {
+		t.Errorf("expected missing var error, got: %v", err)
+	}
+	if err := NewFromTemplate(ctx, "x", "nope", nil, opts); !errors.Is(err, ErrTemplateNotFound) {
+		t.Errorf("expected ErrTemplateNotFound, got: %v", err)
+	}
+	if err := NewFromTemplate(ctx, "exists", "note", map[string]string{"text": "hello"}, opts); err == nil {
+		t.Errorf("expected error for existing entry")
+	}
+	Equal(t, "4", fmt.Sprint(len(inserted)))
+}
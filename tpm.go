@@ -0,0 +1,91 @@
+package pass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// The names of the files of a sealed passphrase in the directory given to
+// SealTPM and TPMProvider.
+const (
+	tpmPublicFile  = "passphrase.pub"
+	tpmPrivateFile = "passphrase.priv"
+)
+
+// SealTPM seals the passphrase to the TPM of the machine, using the
+// tpm2-tools programs, and writes the sealed object to the directory dir,
+// so that TPMProvider can unseal it on the same machine without the
+// passphrase being stored in plain text. The object is sealed under the
+// primary key of the owner hierarchy, which is derived anew by the TPM
+// each time, and so cannot be unsealed on another machine.
+func SealTPM(ctx context.Context, dir string, passphrase Passphrase, options ...Option) error {
+	opts := resolveOptions(options)
+	if passphrase == "" {
+		return errors.New("empty passphrase")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	tmpDir, err := ioutil.TempDir("", "go-pass-tpm-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+	primary := filepath.Join(tmpDir, "primary.ctx")
+
+	if err := runTPM(ctx, "tpm2_createprimary", []string{"-Q", "-C", "o", "-c", primary}, "", opts); err != nil {
+		return err
+	}
+	args := []string{"-Q", "-C", primary, "-i", "-",
+		"-u", filepath.Join(dir, tpmPublicFile), "-r", filepath.Join(dir, tpmPrivateFile)}
+	return runTPM(ctx, "tpm2_create", args, string(passphrase), opts)
+}
+
+// TPMProvider is a PassphraseProvider that unseals the passphrase sealed
+// to the TPM with SealTPM, using the tpm2-tools programs.
+type TPMProvider struct {
+	Dir string // The directory given to SealTPM.
+
+	// Optional. Runs the tpm2-tools programs.
+	Runner Runner
+}
+
+// Passphrase implements PassphraseProvider.
+func (t TPMProvider) Passphrase(ctx context.Context) (Passphrase, error) {
+	opts := &Options{Runner: t.Runner}
+	tmpDir, err := ioutil.TempDir("", "go-pass-tpm-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmpDir)
+	primary := filepath.Join(tmpDir, "primary.ctx")
+	sealed := filepath.Join(tmpDir, "sealed.ctx")
+
+	if err := runTPM(ctx, "tpm2_createprimary", []string{"-Q", "-C", "o", "-c", primary}, "", opts); err != nil {
+		return "", err
+	}
+	args := []string{"-Q", "-C", primary,
+		"-u", filepath.Join(t.Dir, tpmPublicFile), "-r", filepath.Join(t.Dir, tpmPrivateFile), "-c", sealed}
+	if err := runTPM(ctx, "tpm2_load", args, "", opts); err != nil {
+		return "", err
+	}
+	stdout, _, err := runCommand(ctx, "tpm2_unseal", "tpm2_unseal", []string{"-c", sealed}, nil, nil, nil, opts)
+	if err != nil {
+		return "", fmt.Errorf("exec tpm2_unseal: %w", err)
+	}
+	defer wipe(stdout)
+	return Passphrase(stdout), nil
+}
+
+func runTPM(ctx context.Context, program string, args []string, stdin string, opts *Options) error {
+	_, _, err := runCommand(ctx, program, program, args, nil, strings.NewReader(stdin), nil, opts)
+	if err != nil {
+		return fmt.Errorf("exec %s: %w", program, err)
+	}
+	return nil
+}
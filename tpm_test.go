@@ -0,0 +1,37 @@
+package pass
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestTPM(t *testing.T) {
+	// The fake tools keep the "sealed" passphrase in the private file, and
+	// pass it through the context files.
+	defer fakeCommand("tpm2_createprimary", `echo primary > "$5"`)()
+	defer fakeCommand("tpm2_create", `
+[ "$(cat "$3")" = primary ] || exit 1
+cat > "$9"
+echo pub > "$7"`)()
+	defer fakeCommand("tpm2_load", `
+[ "$(cat "$3")" = primary ] || exit 1
+cat "$7" > "$9"`)()
+	defer fakeCommand("tpm2_unseal", `cat "$2"`)()
+
+	dir, err := ioutil.TempDir("", tmpDirPrefix)
+	Ok(t, err)
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	Ok(t, SealTPM(ctx, dir, "hunter2"))
+	p, err := TPMProvider{Dir: dir}.Passphrase(ctx)
+	Ok(t, err)
+	Equal(t, "hunter2", string(p))
+
+	_, err = TPMProvider{Dir: dir + "-missing"}.Passphrase(ctx)
+	if err == nil {
+		t.Errorf("expected error for missing sealed object")
+	}
+}
@@ -0,0 +1,77 @@
+package pass
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// ExportEntry writes the content of the named entry to w, encrypted to the
+// key recipientKey instead of the recipients of the store, as an ASCII
+// armored gpg message, so that it can be handed to someone outside of the
+// store, who reads it with ImportEntry or gpg --decrypt. The key must be
+// in the keyring and valid. The entry is decrypted using gpgPassphrase, as
+// in Show.
+func ExportEntry(ctx context.Context, name, recipientKey, gpgPassphrase string, w io.Writer, options ...Option) error {
+	opts := resolveOptions(options)
+	content, err := Show(ctx, name, gpgPassphrase, opts)
+	if err == nil {
+		defer wipe(content)
+		args := []string{"--batch", "--yes", "--armor", "--no-encrypt-to", "--status-fd=2", "--encrypt", "--recipient", recipientKey}
+		var stdout []byte
+		stdout, _, err = execGPG(ctx, args, bytes.NewReader(content), opts)
+		if err != nil {
+			err = fmt.Errorf("exec gpg: %w", err)
+		} else {
+			_, err = w.Write(stdout)
+		}
+	}
+	if aErr := audit(ctx, "export", []string{resolveName(name, opts)}, err, opts); aErr != nil && err == nil {
+		return fmt.Errorf("write audit log: %w", aErr)
+	}
+	return err
+}
+
+// ImportEntry inserts the content of the gpg message read from r, such as
+// one written by ExportEntry, as the named entry, as in Insert. The
+// message is decrypted with the local keys, using gpgPassphrase as in
+// Show, and the entry is encrypted to the recipients of the store.
+func ImportEntry(ctx context.Context, name string, r io.Reader, gpgPassphrase string, force bool, options ...Option) error {
+	opts := resolveOptions(options)
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("read: %w", err)
+	}
+	gpgPassphrase, err = passphrase(ctx, gpgPassphrase, opts)
+	if err != nil {
+		return err
+	}
+
+	// The message is on stdin, so the passphrase goes on another file
+	// descriptor.
+	args := []string{"--quiet", "--batch", "--status-fd=2"}
+	var extra []io.Reader
+	if mode := pinentryMode(opts); mode == "loopback" {
+		args = append(args, "--passphrase-fd=3", "--pinentry-mode=loopback")
+		extra = append(extra, passphraseReader(gpgPassphrase))
+	} else {
+		args = append(args, "--pinentry-mode="+mode)
+	}
+	if noTTY(opts) {
+		args = append(args, "--no-tty")
+	}
+	args = append(args, agentCacheArgs(opts)...)
+	args = append(args, "--decrypt")
+	env := append(baseEnv(opts), "LC_ALL=C")
+	content, _, err := runCommandFiles(ctx, "gpg", gpgProgram(), args, env, bytes.NewReader(data), extra, nil, opts)
+	if err != nil {
+		return fmt.Errorf("exec gpg: %w", err)
+	}
+	defer wipe(content)
+	if err := afterDecrypt(ctx, opts); err != nil {
+		return err
+	}
+	return Insert(ctx, name, content, force, opts)
+}
@@ -0,0 +1,44 @@
+package pass
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExportImportEntry(t *testing.T) {
+	defer fakeCommand("pass", `for arg; do name="$arg"; done; cat "$PASSWORD_STORE_DIR/$name.gpg"`)()
+
+	dir := makeTestTree(nil)
+	defer os.RemoveAll(dir)
+	env, _ := testKeyring(t, filepath.Join(dir, "gnupg"), "A <a@example.com>")
+	otherEnv, otherFprs := testKeyring(t, filepath.Join(dir, "other"), "B <b@example.com>")
+	ctx := context.Background()
+
+	// Give A the public key of B.
+	pub, _, err := execGPG(ctx, []string{"--armor", "--export", otherFprs[0]}, nil, &Options{Env: otherEnv})
+	Ok(t, err)
+	_, _, err = execGPG(ctx, []string{"--batch", "--import"}, bytes.NewReader(pub), &Options{Env: env})
+	Ok(t, err)
+	_, _, err = execGPG(ctx, []string{"--batch", "--yes", "--quick-sign-key", otherFprs[0]}, nil, &Options{Env: env})
+	Ok(t, err)
+
+	opts := &Options{StoreDir: filepath.Join(dir, "store"), Env: env}
+	writeTestFile(t, opts.StoreDir, "a.gpg", "secret\n")
+	var buf bytes.Buffer
+	Ok(t, ExportEntry(ctx, "a", otherFprs[0], "", &buf, opts))
+	Equal(t, "true", fmt.Sprint(strings.HasPrefix(buf.String(), "-----BEGIN PGP MESSAGE-----")))
+
+	defer fakeCommand("pass", `cat > "$PASSWORD_STORE_DIR/imported"`)()
+	otherOpts := &Options{StoreDir: filepath.Join(dir, "otherstore"), Env: otherEnv}
+	Ok(t, os.MkdirAll(otherOpts.StoreDir, 0700))
+	Ok(t, ImportEntry(ctx, "b", &buf, "", false, otherOpts))
+	b, err := ioutil.ReadFile(filepath.Join(otherOpts.StoreDir, "imported"))
+	Ok(t, err)
+	Equal(t, "secret\n", string(b))
+}
@@ -0,0 +1,225 @@
+package pass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// trashDir is the folder, relative to the root of the store, that removed
+// entries are moved to when Options.Trash is set.
+const trashDir = ".trash"
+
+// trashTimeFormat is the format of the time suffix of names in the trash.
+const trashTimeFormat = "20060102T150405.000Z"
+
+// TrashEntry is an entry or folder in the trash.
+type TrashEntry struct {
+	Name      string    `json:"name"`       // The original name of the entry or folder.
+	TrashName string    `json:"trash_name"` // The name in the trash; use with Recover.
+	DeletedAt time.Time `json:"deleted_at"` // When the entry or folder was removed.
+	IsFolder  bool      `json:"is_folder"`
+}
+
+// moveToTrash moves the named entry, or folder if recursive is set, to the
+// trash. The password files are moved as is, so they remain encrypted to
+// their original recipients.
+func moveToTrash(ctx context.Context, name string, recursive bool, opts *Options) error {
+	storeDir := resolveStoreDir(opts)
+	name = filepath.Clean(name)
+
+	src := name + ".gpg"
+	if _, err := os.Stat(filepath.Join(storeDir, src)); os.IsNotExist(err) {
+		info, err := os.Stat(filepath.Join(storeDir, name))
+		if err != nil || !info.IsDir() {
+			return errors.New("name does not exist")
+		}
+		if !recursive {
+			return errors.New("name is a folder; use recursive")
+		}
+		src = name
+	}
+
+	trashName := name + "@" + time.Now().UTC().Format(trashTimeFormat)
+	dst := filepath.Join(trashDir, trashName)
+	if strings.HasSuffix(src, ".gpg") {
+		dst += ".gpg"
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filepath.Join(storeDir, dst)), 0700); err != nil {
+		return fmt.Errorf("make trash dir: %w", err)
+	}
+	if _, err := os.Lstat(filepath.Join(storeDir, dst)); err == nil {
+		return errors.New("trash name already exists")
+	}
+	if err := os.Rename(filepath.Join(storeDir, src), filepath.Join(storeDir, dst)); err != nil {
+		return fmt.Errorf("move to trash: %w", err)
+	}
+
+	msg := fmt.Sprintf("Move %s to trash.", name)
+	if err := commitFiles(ctx, msg, []string{src, dst}, opts); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+// ListTrash returns the entries and folders in the trash, as removed by
+// Remove when Options.Trash is set.
+func ListTrash(ctx context.Context, options ...Option) ([]TrashEntry, error) {
+	opts := resolveOptions(options)
+	root := filepath.Join(resolveStoreDir(opts), trashDir)
+
+	var ret []TrashEntry
+
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			if p == root && os.IsNotExist(err) {
+				return filepath.SkipDir // empty trash
+			}
+			return err
+		}
+		if p == root {
+			return nil
+		}
+		if !info.IsDir() && !strings.HasSuffix(info.Name(), ".gpg") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			panic(err) // should not happen
+		}
+		e, ok := parseTrashName(strings.TrimSuffix(rel, ".gpg"))
+		if !ok {
+			return nil // a folder containing trashed entries
+		}
+		e.IsFolder = info.IsDir()
+		ret = append(ret, e)
+		if info.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func parseTrashName(trashName string) (TrashEntry, bool) {
+	i := strings.LastIndexByte(trashName, '@')
+	if i == -1 {
+		return TrashEntry{}, false
+	}
+	t, err := time.Parse(trashTimeFormat, trashName[i+1:])
+	if err != nil {
+		return TrashEntry{}, false
+	}
+	return TrashEntry{
+		Name:      trashName[:i],
+		TrashName: trashName,
+		DeletedAt: t,
+	}, true
+}
+
+// Recover moves an entry or folder in the trash back to its original
+// name. The trashName is a TrashEntry.TrashName returned by ListTrash. If
+// force is set, an existing entry with the original name is overwritten.
+// Write access is needed on both names.
+func Recover(ctx context.Context, trashName string, force bool, options ...Option) error {
+	opts := resolveOptions(options)
+	if err := checkName(trashName); err != nil {
+		return err
+	}
+	e, ok := parseTrashName(trashName)
+	if !ok {
+		return errors.New("not a trash name")
+	}
+	if err := checkName(e.Name); err != nil {
+		return err
+	}
+	return mutate(ctx, "recover", []string{trashName, e.Name}, opts, func() error {
+		return recoverFromTrash(ctx, e, force, opts)
+	})
+}
+
+func recoverFromTrash(ctx context.Context, e TrashEntry, force bool, opts *Options) error {
+	storeDir := resolveStoreDir(opts)
+
+	src := filepath.Join(trashDir, e.TrashName+".gpg")
+	dst := e.Name + ".gpg"
+	if _, err := os.Lstat(filepath.Join(storeDir, src)); os.IsNotExist(err) {
+		src = filepath.Join(trashDir, e.TrashName)
+		dst = e.Name
+		if _, err := os.Lstat(filepath.Join(storeDir, src)); err != nil {
+			return errors.New("trash name does not exist")
+		}
+	}
+
+	if _, err := os.Lstat(filepath.Join(storeDir, dst)); err == nil && !force {
+		return errors.New("name already exists")
+	}
+	if err := os.MkdirAll(filepath.Dir(filepath.Join(storeDir, dst)), 0700); err != nil {
+		return fmt.Errorf("make dir: %w", err)
+	}
+	if err := os.Rename(filepath.Join(storeDir, src), filepath.Join(storeDir, dst)); err != nil {
+		return fmt.Errorf("recover: %w", err)
+	}
+
+	msg := fmt.Sprintf("Recover %s from trash.", e.Name)
+	if err := commitFiles(ctx, msg, []string{src, dst}, opts); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+// PurgeTrash permanently deletes the entries and folders that were moved to
+// the trash more than olderThan ago.
+func PurgeTrash(ctx context.Context, olderThan time.Duration, options ...Option) error {
+	opts := resolveOptions(options)
+	return mutate(ctx, "purge-trash", []string{trashDir}, opts, func() error {
+		return purgeTrash(ctx, olderThan, opts)
+	})
+}
+
+func purgeTrash(ctx context.Context, olderThan time.Duration, opts *Options) error {
+	storeDir := resolveStoreDir(opts)
+
+	entries, err := ListTrash(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("list trash: %w", err)
+	}
+
+	cutoff := time.Now().Add(-olderThan)
+	var purged []string
+	for _, e := range entries {
+		if !e.DeletedAt.Before(cutoff) {
+			continue
+		}
+		p := filepath.Join(trashDir, e.TrashName)
+		if !e.IsFolder {
+			p += ".gpg"
+		}
+		if opts != nil && opts.Shred {
+			if err := shredAll(filepath.Join(storeDir, p)); err != nil {
+				return err
+			}
+		} else if err := os.RemoveAll(filepath.Join(storeDir, p)); err != nil {
+			return fmt.Errorf("remove: %w", err)
+		}
+		purged = append(purged, p)
+	}
+	if len(purged) == 0 {
+		return nil
+	}
+
+	msg := fmt.Sprintf("Purge %d items from trash.", len(purged))
+	if err := commitFiles(ctx, msg, purged, opts); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
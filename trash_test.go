@@ -0,0 +1,100 @@
+package pass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTrash(t *testing.T) {
+	storeDir := makeTestTree([]string{
+		"a.gpg",
+		"work/b.gpg",
+		"work/c.gpg",
+	})
+
+	ctx := context.Background()
+	opts := &Options{StoreDir: storeDir, Trash: true}
+
+	err := Remove(ctx, "a", false, false, opts)
+	Ok(t, err)
+	if err := Remove(ctx, "work", false, false, opts); err == nil {
+		t.Errorf("expected error removing folder without recursive")
+	}
+	err = Remove(ctx, "work", true, false, opts)
+	Ok(t, err)
+
+	ls, err := List(ctx, "", opts)
+	Ok(t, err)
+	if len(ls) != 0 {
+		t.Errorf("expected empty list, got %v", ls)
+	}
+	ls, err = ListFast(ctx, "", opts)
+	Ok(t, err)
+	if len(ls) != 0 {
+		t.Errorf("expected empty list, got %v", ls)
+	}
+
+	trash, err := ListTrash(ctx, opts)
+	Ok(t, err)
+	if len(trash) != 2 {
+		t.Errorf("expected 2 trash entries, got %d", len(trash))
+		return
+	}
+	Equal(t, "a", trash[0].Name)
+	Equal(t, "false", fmt.Sprint(trash[0].IsFolder))
+	Equal(t, "work", trash[1].Name)
+	Equal(t, "true", fmt.Sprint(trash[1].IsFolder))
+	if time.Since(trash[0].DeletedAt) > time.Minute {
+		t.Errorf("unexpected deletion time: %s", trash[0].DeletedAt)
+	}
+
+	err = Recover(ctx, trash[1].TrashName, false, opts)
+	Ok(t, err)
+	ls, err = List(ctx, "", opts)
+	Ok(t, err)
+	Equal(t, "[work/b work/c]", fmt.Sprint(ls))
+
+	err = PurgeTrash(ctx, time.Hour, opts)
+	Ok(t, err)
+	_, err = os.Stat(filepath.Join(storeDir, trashDir, trash[0].TrashName+".gpg"))
+	Ok(t, err)
+
+	err = PurgeTrash(ctx, 0, opts)
+	Ok(t, err)
+	trash, err = ListTrash(ctx, opts)
+	Ok(t, err)
+	if len(trash) != 0 {
+		t.Errorf("expected empty trash, got %v", trash)
+	}
+}
+
+func TestRecoverChecksName(t *testing.T) {
+	const deleted = "@20200102T030405.000Z"
+	storeDir := makeTestTree([]string{
+		".trash/prod/key" + deleted + ".gpg",
+		".trash/a/.." + deleted + ".gpg",
+	})
+	defer os.RemoveAll(storeDir)
+	ctx := context.Background()
+
+	// The original name is checked, not only the name in the trash.
+	acl := NewACL()
+	Ok(t, acl.Allow("ci-bot", "**"+deleted, Write))
+	opts := &Options{StoreDir: storeDir, ACL: acl, Principal: "ci-bot"}
+	if err := Recover(ctx, "prod/key"+deleted, false, opts); err != ErrPermissionDenied {
+		t.Errorf("expected: %s, got: %v", ErrPermissionDenied, err)
+	}
+
+	for _, trashName := range []string{"a/.." + deleted, "../../outside" + deleted} {
+		if err := Recover(ctx, trashName, false, WithStoreDir(storeDir)); !errors.Is(err, ErrInvalidName) {
+			t.Errorf("%s: expected: %s, got: %v", trashName, ErrInvalidName, err)
+		}
+	}
+	_, err := os.Stat(filepath.Join(storeDir, ".trash", "a", ".."+deleted+".gpg"))
+	Ok(t, err)
+}
@@ -0,0 +1,63 @@
+package pass
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrInvalidContent is returned by Insert when Options.Validator rejects
+// the content of an entry.
+var ErrInvalidContent = errors.New("invalid content")
+
+// Validator checks the content of the named entry before Insert stores it,
+// returning an error describing the problem if it should be rejected.
+type Validator func(name string, content []byte) error
+
+var (
+	// A command line, as copied from a terminal or shell history file:
+	// optionally a prompt, a history number, or a zsh extended history
+	// prefix, followed by a common command.
+	shellLineRe = regexp.MustCompile(`^(\$\s+|#\s+|\s*\d+\s+|: \d+:\d+;)?(sudo|pass|gpg|git|ssh|scp|curl|wget|export|echo|cd|ls|cat|mysql|psql|docker|kubectl)\s`)
+
+	privateKeyRe = regexp.MustCompile(`^-----BEGIN [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----$`)
+)
+
+// DefaultValidator is a Validator that rejects obvious mistakes in the
+// password on the first line of an entry: an empty password, trailing
+// whitespace, a shell command pasted by accident, and a private key, which
+// would leave only its header line as the password. Multiline content that
+// keeps a private key below the password is allowed.
+func DefaultValidator(name string, content []byte) error {
+	line := content
+	if i := bytes.IndexByte(line, '\n'); i != -1 {
+		line = line[:i]
+	}
+	password := strings.TrimSuffix(string(line), "\r")
+
+	switch {
+	case password == "":
+		return errors.New("password is empty")
+	case strings.TrimRight(password, " \t") != password:
+		return errors.New("password has trailing whitespace")
+	case shellLineRe.MatchString(password):
+		return errors.New("password looks like a shell command")
+	case privateKeyRe.MatchString(password):
+		return errors.New("content looks like a private key")
+	}
+	return nil
+}
+
+// validate runs Options.Validator on the content of the named entry,
+// unless Options.SkipValidation is set.
+func validate(name string, content []byte, opts *Options) error {
+	if opts == nil || opts.Validator == nil || opts.SkipValidation {
+		return nil
+	}
+	if err := opts.Validator(name, content); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidContent, err)
+	}
+	return nil
+}
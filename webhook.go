@@ -0,0 +1,77 @@
+package pass
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// WebhookEvent is the JSON body that a Notifier posts. It never contains
+// the content of entries.
+type WebhookEvent struct {
+	Time      time.Time `json:"time"`
+	Operation string    `json:"op"`
+	Names     []string  `json:"names,omitempty"`
+	Commit    string    `json:"commit,omitempty"` // HEAD after the change, if the store is a git repository.
+}
+
+// Notifier posts a WebhookEvent to each of its URLs after each change to
+// the store, and after each Git call that changes HEAD, such as a pull.
+// Set it in Options.Notifier.
+type Notifier struct {
+	URLs []string
+
+	// Optional. The client used to post events. Defaults to
+	// http.DefaultClient.
+	Client *http.Client
+
+	// Optional. Called when posting an event to url fails. Failures do
+	// not fail the change.
+	OnError func(url string, err error)
+}
+
+// notify posts an event for the operation to the URLs of n.
+func (n *Notifier) notify(ctx context.Context, op string, names []string, opts *Options) {
+	e := WebhookEvent{
+		Time:      time.Now().UTC(),
+		Operation: op,
+		Names:     names,
+	}
+	if isGitRepo(opts) {
+		e.Commit, _ = gitHead(ctx, opts)
+	}
+	body, err := json.Marshal(e)
+	if err != nil {
+		panic(err) // should not happen
+	}
+
+	client := n.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	for _, u := range n.URLs {
+		if err := postEvent(ctx, client, u, body); err != nil && n.OnError != nil {
+			n.OnError(u, err)
+		}
+	}
+}
+
+func postEvent(ctx context.Context, client *http.Client, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package pass
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNotifier(t *testing.T) {
+	events := make(chan WebhookEvent, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e WebhookEvent
+		if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+			t.Errorf("decode event: %s", err)
+		}
+		events <- e
+	}))
+	defer srv.Close()
+
+	var failed []string
+	opts := &Options{
+		StoreDir: makeTestTree([]string{"a.gpg"}),
+		Notifier: &Notifier{
+			URLs: []string{srv.URL, srv.URL + "/missing\x7f"},
+			OnError: func(url string, err error) {
+				failed = append(failed, url)
+			},
+		},
+	}
+
+	err := Alias(context.Background(), "a", "b", opts)
+	Ok(t, err)
+
+	e := <-events
+	Equal(t, "alias", e.Operation)
+	Equal(t, "[b a]", fmt.Sprint(e.Names))
+	Equal(t, "", e.Commit)
+	Equal(t, fmt.Sprint([]string{srv.URL + "/missing\x7f"}), fmt.Sprint(failed))
+}
@@ -0,0 +1,75 @@
+package pass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"syscall"
+	"unicode/utf16"
+	"unsafe"
+)
+
+var (
+	advapi32      = syscall.NewLazyDLL("advapi32.dll")
+	procCredReadW = advapi32.NewProc("CredReadW")
+	procCredFree  = advapi32.NewProc("CredFree")
+)
+
+const (
+	credTypeGeneric = 1
+	errorNotFound   = syscall.Errno(1168)
+)
+
+// credential is the CREDENTIALW structure.
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        syscall.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+// CredentialManagerProvider is a PassphraseProvider that reads the
+// passphrase from a generic credential in the Windows Credential Manager.
+// The password of the credential is read as UTF-16, as it is stored by the
+// Credential Manager and by cmdkey, for example:
+//
+//	cmdkey /generic:go-pass /user:gpg /pass
+type CredentialManagerProvider struct {
+	Target string // The target name of the credential.
+}
+
+// Passphrase implements PassphraseProvider.
+func (c CredentialManagerProvider) Passphrase(ctx context.Context) (Passphrase, error) {
+	target, err := syscall.UTF16PtrFromString(c.Target)
+	if err != nil {
+		return "", err
+	}
+	var cred *credential
+	r, _, err := procCredReadW.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&cred)))
+	if r == 0 {
+		if errors.Is(err, errorNotFound) {
+			return "", fmt.Errorf("credential %s not found", c.Target)
+		}
+		return "", fmt.Errorf("read credential: %w", err)
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+
+	n := cred.CredentialBlobSize / 2
+	if n == 0 {
+		return "", nil
+	}
+	blob := (*[1 << 20]uint16)(unsafe.Pointer(cred.CredentialBlob))[:n:n]
+	p := Passphrase(utf16.Decode(blob))
+	for i := range blob {
+		blob[i] = 0
+	}
+	return p, nil
+}
@@ -0,0 +1,69 @@
+package pass
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// EntryFile returns the path of the password file of the named entry.
+// The file may not exist.
+func EntryFile(name string, options ...Option) string {
+	opts := resolveOptions(options)
+	name = resolveName(name, opts)
+	return filepath.Join(resolveStoreDir(opts), name+".gpg")
+}
+
+// WSLToWindowsPath returns the Windows path of p, an absolute path in the
+// WSL distribution distro, so that programs on Windows can open the files
+// of a store used by pass in WSL: a drive path for paths under /mnt, such
+// as C:\Users\alice for /mnt/c/Users/alice, or else a path under
+// \\wsl.localhost\distro.
+func WSLToWindowsPath(p, distro string) (string, error) {
+	if !strings.HasPrefix(p, "/") {
+		return "", fmt.Errorf("not an absolute path: %s", p)
+	}
+	p = path.Clean(p)
+	parts := strings.SplitN(strings.TrimPrefix(p, "/"), "/", 3)
+	if len(parts) >= 2 && parts[0] == "mnt" && isDriveLetter(parts[1]) {
+		ret := strings.ToUpper(parts[1]) + `:\`
+		if len(parts) == 3 {
+			ret += strings.ReplaceAll(parts[2], "/", `\`)
+		}
+		return ret, nil
+	}
+	if distro == "" {
+		return "", fmt.Errorf("no distribution for path %s", p)
+	}
+	return `\\wsl.localhost\` + distro + strings.ReplaceAll(p, "/", `\`), nil
+}
+
+// WindowsToWSLPath returns the path in WSL of the Windows path p: a path
+// under /mnt for a drive path, or the path in the distribution for a path
+// under \\wsl.localhost or \\wsl$. Forward slashes are accepted as
+// separators in p.
+func WindowsToWSLPath(p string) (string, error) {
+	s := strings.ReplaceAll(p, `\`, "/")
+	if len(s) >= 2 && s[1] == ':' && isDriveLetter(s[:1]) {
+		rest := strings.TrimPrefix(s[2:], "/")
+		if len(s) > 2 && s[2] != '/' {
+			return "", fmt.Errorf("not an absolute path: %s", p)
+		}
+		return path.Clean("/mnt/" + strings.ToLower(s[:1]) + "/" + rest), nil
+	}
+	for _, host := range []string{"//wsl.localhost/", "//wsl$/"} {
+		if len(s) > len(host) && strings.EqualFold(s[:len(host)], host) {
+			parts := strings.SplitN(s[len(host):], "/", 2)
+			if len(parts) == 1 {
+				return "/", nil
+			}
+			return path.Clean("/" + parts[1]), nil
+		}
+	}
+	return "", fmt.Errorf("not a drive or WSL path: %s", p)
+}
+
+func isDriveLetter(s string) bool {
+	return len(s) == 1 && (s[0] >= 'a' && s[0] <= 'z' || s[0] >= 'A' && s[0] <= 'Z')
+}
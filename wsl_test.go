@@ -0,0 +1,42 @@
+package pass
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestWSLPaths(t *testing.T) {
+	for _, tc := range []struct{ wsl, windows string }{
+		{"/mnt/c", `C:\`},
+		{"/mnt/c/Users/alice/store", `C:\Users\alice\store`},
+		{"/home/alice/.password-store", `\\wsl.localhost\Ubuntu\home\alice\.password-store`},
+	} {
+		got, err := WSLToWindowsPath(tc.wsl, "Ubuntu")
+		Ok(t, err)
+		Equal(t, tc.windows, got)
+		got, err = WindowsToWSLPath(tc.windows)
+		Ok(t, err)
+		Equal(t, tc.wsl, got)
+	}
+
+	got, err := WindowsToWSLPath(`\\wsl$\Debian\home\bob\a.gpg`)
+	Ok(t, err)
+	Equal(t, "/home/bob/a.gpg", got)
+	got, err = WindowsToWSLPath("d:/store/")
+	Ok(t, err)
+	Equal(t, "/mnt/d/store", got)
+
+	_, err = WindowsToWSLPath(`\\server\share\store`)
+	Equal(t, `not a drive or WSL path: \\server\share\store`, fmt.Sprint(err))
+	_, err = WindowsToWSLPath(`C:store`)
+	Equal(t, `not an absolute path: C:store`, fmt.Sprint(err))
+	_, err = WSLToWindowsPath("relative/path", "Ubuntu")
+	Equal(t, "not an absolute path: relative/path", fmt.Sprint(err))
+	_, err = WSLToWindowsPath("/home/alice", "")
+	Equal(t, "no distribution for path /home/alice", fmt.Sprint(err))
+}
+
+func TestEntryFile(t *testing.T) {
+	Equal(t, filepath.Join("/store", "web", "github.gpg"), EntryFile("web/github", WithStoreDir("/store")))
+}